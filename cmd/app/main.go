@@ -3,52 +3,187 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"awesomeProject/configs"
 	"awesomeProject/internal/handlers"
+	"awesomeProject/internal/metrics"
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/middlewares/authorization"
+	"awesomeProject/internal/middlewares/logging"
+	"awesomeProject/internal/middlewares/ratelimit"
+	"awesomeProject/internal/models"
 	"awesomeProject/internal/repositories"
 	"awesomeProject/internal/routers"
+	"awesomeProject/internal/services"
+	"awesomeProject/internal/tracing"
 	"awesomeProject/pkg/database"
+	"awesomeProject/pkg/utils"
 )
 
 func main() {
-	configDB := database.ConnectionConfig{
-		DriverName:      os.Getenv("AWP_DB_DRIVER"),
-		DataSourceName:  os.Getenv("AWP_DB_DATASOURCE"),
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
-		ConnMaxLifetime: time.Hour,
+	configPath := flag.String("config", os.Getenv(configs.EnvConfigPath), "path to the YAML or TOML configuration file")
+	flag.Parse()
+
+	config, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	db, err := database.NewConnection(config.Database)
+	if err != nil {
+		log.Fatalf("failed to configure db connection: %v", err)
+	}
+	// The server starts anyway, /readyz reports the database until it is
+	// reachable.
+	if err = db.Ping(); err != nil {
+		log.Printf("Database not reachable yet: %v", err)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if flag.Arg(0) == "migrate" {
+		err = runMigrate(context.Background(), migrator, flag.Args()[1:])
+		db.Close()
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// The sample data is for development databases only, it is never part of
+	// the versioned migrations.
+	if flag.Arg(0) == "seed" {
+		err = database.Seed(context.Background(), db)
+		db.Close()
+		if err != nil {
+			log.Fatalf("seed: %v", err)
+		}
+		log.Println("Seeded the development data")
+		return
+	}
+
+	if config.Database.MigrateOnStart {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("failed to apply migrations: %v", err)
+		}
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		}
 	}
 
-	db, err := database.NewConnection(configDB)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
 	if err != nil {
-		fmt.Errorf("failed to configure db connection: %v", err)
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+
+	// Statements are named after their query constant for the metrics and
+	// the spans, the pool stats come from the connection itself.
+	appMetrics := metrics.New()
+	appMetrics.RegisterDatabase(db)
+	db = database.Instrument(db, repositories.QueryName, appMetrics, tracing.Queries{})
+
+	keyManager, err := utils.NewKeyManager(config.JWT)
+	if err != nil {
+		log.Fatalf("failed to load signing keys: %v", err)
 	}
-	log.Printf("Pinging db %v", db.Ping())
 
 	userRepository := repositories.NewUserRepository(db)
 	userHandler := handlers.NewUserHandler(userRepository)
 	authRepository := repositories.NewAuthRepositoryImpl(db)
-	authHandler := handlers.NewAuth(authRepository)
+	sessionRepository := repositories.NewSession(db)
+	authHandler := handlers.NewAuth(authRepository, sessionRepository, keyManager, config.JWT)
+	sessionHandler := handlers.NewSessionHandler(sessionRepository)
 	categoryRepository := repositories.NewCategory(db)
-	categoryHandler := handlers.NewCategoryHandler(categoryRepository)
+	categoryHandler := handlers.NewCategoryHandler(categoryRepository, models.CategoryDeletePolicy(config.Catalog.CategoryDeletePolicy))
 	productRepository := repositories.NewProduct(db)
 	productHandler := handlers.NewProductHandler(productRepository)
+	trashRepository := repositories.NewTrash(db)
+	trashHandler := handlers.NewTrashHandler(trashRepository)
+
+	jwksHandler := handlers.NewJWKSHandler(keyManager)
+
+	isAuthenticated := authentication.IsAuthenticated(keyManager, sessionRepository)
+	authorizer := authorization.NewAuthorizer(repositories.NewRole(db), config.Authorization.CacheTTL)
 
-	router := routers.NewRouter(userHandler, categoryHandler, productHandler, authHandler)
+	jobRepository := repositories.NewJob(db)
+	jobQueue := services.NewJobQueue(jobRepository, authorizer, config.Jobs)
+	jobFiles := services.NewJobFiles(config.Jobs.FilesDir)
+	services.RegisterCatalogJobs(jobQueue, productRepository, categoryRepository, jobFiles)
+	jobHandler := handlers.NewJobHandler(jobRepository, jobQueue, jobFiles)
+
+	webhookRepository := repositories.NewWebhook(db)
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepository, config.Webhooks)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepository, webhookDispatcher)
+
+	workers := map[string]services.WorkerPool{"jobs": jobQueue}
+	if config.Webhooks.PollInterval > 0 {
+		workers["webhooks"] = webhookDispatcher
+	}
+	health := services.NewHealth(db, migrator, workers, config.Server.HealthTimeout)
+	healthHandler := handlers.NewHealthHandler(health)
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if config.RateLimit.Backend == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(repositories.NewRateLimit(db))
+	}
+	// No API keys are issued yet, configs.Validate rejects api_key limits.
+	limiter := ratelimit.New(rateLimitStore, nil, config.RateLimit)
+
+	router := routers.NewRouter(userHandler, categoryHandler, productHandler, trashHandler, jobHandler, webhookHandler, healthHandler, authHandler, sessionHandler, jwksHandler, isAuthenticated, authorizer, appMetrics, logging.New(config.Logging), limiter)
 
 	httpServer := http.Server{
-		Addr:    ":" + os.Getenv("AWP_PORT"),
-		Handler: router,
+		Addr:         ":" + config.Server.Port,
+		Handler:      router,
+		ReadTimeout:  config.Server.ReadTimeout,
+		WriteTimeout: config.Server.WriteTimeout,
+	}
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// The background loops are waited for on shutdown, so that none of them
+	// still uses the database once it is closed.
+	var background sync.WaitGroup
+	runInBackground := func(run func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(backgroundCtx)
+		}()
+	}
+
+	if config.Trash.Retention > 0 {
+		purger := services.NewTrashPurger(trashRepository, config.Trash.Retention, config.Trash.PurgeInterval)
+		runInBackground(purger.Run)
 	}
 
-	fmt.Printf("Server starting at :%v", os.Getenv("AWP_PORT")+"\n")
+	if config.Jobs.FileRetention > 0 {
+		sweeper := services.NewJobFileSweeper(jobFiles, config.Jobs.FileRetention, config.Jobs.FileSweepInterval)
+		runInBackground(sweeper.Run)
+	}
+
+	runInBackground(limiter.Run)
+
+	jobQueue.Start()
+
+	// Deliveries interrupted by shutdown are retried by the next dispatcher.
+	if config.Webhooks.PollInterval > 0 {
+		runInBackground(webhookDispatcher.Run)
+	}
+
+	log.Printf("Server starting at :%v", config.Server.Port)
 	go func() {
 		if err = httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("HTTP routers error: %v", err)
@@ -56,11 +191,41 @@ func main() {
 		log.Println("Stopped serving new connections.")
 	}()
 
+	// Metrics are served apart from the API, so that they are only
+	// reachable where the metrics address is.
+	var metricsServer *http.Server
+	if config.Server.MetricsAddr != "" {
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle("/metrics", appMetrics.Handler())
+
+		metricsServer = &http.Server{
+			Addr:         config.Server.MetricsAddr,
+			Handler:      metricsRouter,
+			ReadTimeout:  config.Server.ReadTimeout,
+			WriteTimeout: config.Server.WriteTimeout,
+		}
+
+		log.Printf("Metrics server starting at %v", config.Server.MetricsAddr)
+		go func() {
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
+	// Requests keep being served while the load balancers take the instance
+	// out of rotation.
+	health.Drain()
+	log.Printf("Draining for %v", config.Server.DrainDelay)
+	time.Sleep(config.Server.DrainDelay)
+
+	stopBackground()
+
+	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
 	defer func() {
 		db.Close()
 		shutdownRelease()
@@ -70,5 +235,23 @@ func main() {
 		log.Fatalf("HTTP shutdown error: %v", err)
 	}
 
+	if metricsServer != nil {
+		if err = metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Metrics server shutdown: %v", err)
+		}
+	}
+
+	// Jobs still running when the deadline passes are cancelled and queued
+	// again for the next start.
+	if err = jobQueue.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Job queue shutdown: %v", err)
+	}
+
+	background.Wait()
+
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
+	}
+
 	log.Println("Graceful shutdown complete.")
 }
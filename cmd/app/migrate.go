@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"awesomeProject/pkg/database"
+)
+
+var errMigrateUsage = errors.New("usage: app migrate up | down | status | to <version> | baseline <version>")
+
+func runMigrate(ctx context.Context, migrator *database.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	var (
+		done []database.Migration
+		err  error
+	)
+
+	switch args[0] {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx)
+	case "to", "baseline":
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], parseErr)
+		}
+
+		if args[0] == "to" {
+			done, err = migrator.To(ctx, version)
+		} else {
+			done, err = migrator.Baseline(ctx, version)
+		}
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	default:
+		return errMigrateUsage
+	}
+
+	for _, migration := range done {
+		fmt.Printf("migrated %04d_%s\n", migration.Version, migration.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(done) == 0 {
+		fmt.Println("no change")
+	}
+
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
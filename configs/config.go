@@ -1 +1,631 @@
 package configs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const EnvConfigPath = "AWP_CONFIG"
+
+type Config struct {
+	Server        Server        `yaml:"server"`
+	Logging       Logging       `yaml:"logging"`
+	Database      Database      `yaml:"database"`
+	JWT           JWT           `yaml:"jwt"`
+	Authorization Authorization `yaml:"authorization"`
+	Catalog       Catalog       `yaml:"catalog"`
+	Trash         Trash         `yaml:"trash"`
+	Jobs          Jobs          `yaml:"jobs"`
+	Webhooks      Webhooks      `yaml:"webhooks"`
+	Tracing       Tracing       `yaml:"tracing"`
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+}
+
+type Server struct {
+	Port            string        `yaml:"port" env:"AWP_PORT"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"AWP_SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"AWP_SERVER_WRITE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"AWP_SERVER_SHUTDOWN_TIMEOUT"`
+	// DrainDelay is how long the instance reports not ready after SIGTERM
+	// before it stops accepting connections, so that load balancers notice.
+	DrainDelay time.Duration `yaml:"drain_delay" env:"AWP_SERVER_DRAIN_DELAY"`
+	// HealthTimeout bounds each dependency check of /readyz.
+	HealthTimeout time.Duration `yaml:"health_timeout" env:"AWP_SERVER_HEALTH_TIMEOUT"`
+	// MetricsAddr is the host:port /metrics is served on, apart from the
+	// API so that it is only reachable by the scrapers. Empty serves no
+	// metrics.
+	MetricsAddr string `yaml:"metrics_addr" env:"AWP_SERVER_METRICS_ADDR"`
+}
+
+type Logging struct {
+	// RedactHeaders and RedactCookies name the request headers and cookies
+	// whose values are replaced in the access log. Header names are matched
+	// case-insensitively, cookie names exactly.
+	RedactHeaders []string `yaml:"redact_headers" env:"AWP_LOGGING_REDACT_HEADERS"`
+	RedactCookies []string `yaml:"redact_cookies" env:"AWP_LOGGING_REDACT_COOKIES"`
+}
+
+type Database struct {
+	Driver          string        `yaml:"driver" env:"AWP_DB_DRIVER"`
+	DataSource      string        `yaml:"data_source" env:"AWP_DB_DATASOURCE"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"AWP_DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"AWP_DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"AWP_DB_CONN_MAX_LIFETIME"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start" env:"AWP_DB_MIGRATE_ON_START"`
+}
+
+// minJWTSecretBytes is the shortest HS256 secret accepted, the size of the
+// SHA-256 output. Shorter secrets can be brute-forced from a single token.
+const minJWTSecretBytes = 32
+
+type JWT struct {
+	Secret          string        `yaml:"secret" env:"AWP_JWT_SECRET"`
+	TokenTTL        time.Duration `yaml:"token_ttl" env:"AWP_JWT_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"AWP_JWT_REFRESH_TOKEN_TTL"`
+	SigningKeyID    string        `yaml:"signing_key_id" env:"AWP_JWT_SIGNING_KEY_ID"`
+	Keys            []JWTKey      `yaml:"keys"`
+}
+
+// JWTKey describes one key known to the key manager. Keys without private
+// material are only used to verify tokens issued before a rotation.
+type JWTKey struct {
+	ID             string `yaml:"id"`
+	Algorithm      string `yaml:"algorithm"`
+	Secret         string `yaml:"secret"`
+	PrivateKey     string `yaml:"private_key"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKey      string `yaml:"public_key"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+type Authorization struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"AWP_AUTHORIZATION_CACHE_TTL"`
+}
+
+type Catalog struct {
+	// CategoryDeletePolicy is one of reject, cascade or reparent.
+	CategoryDeletePolicy string `yaml:"category_delete_policy" env:"AWP_CATALOG_CATEGORY_DELETE_POLICY"`
+}
+
+type Trash struct {
+	// Retention is how long deleted rows stay restorable. Zero keeps them
+	// until they are purged by hand.
+	Retention     time.Duration `yaml:"retention" env:"AWP_TRASH_RETENTION"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"AWP_TRASH_PURGE_INTERVAL"`
+}
+
+type Jobs struct {
+	// Workers is how many jobs run at once. Zero runs no workers, so jobs are
+	// only queued, e.g. for another instance to pick them up.
+	Workers      int           `yaml:"workers" env:"AWP_JOBS_WORKERS"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"AWP_JOBS_POLL_INTERVAL"`
+	// Lease is how long a running job may go without a heartbeat before
+	// another worker takes it over.
+	Lease       time.Duration `yaml:"lease" env:"AWP_JOBS_LEASE"`
+	MaxAttempts int           `yaml:"max_attempts" env:"AWP_JOBS_MAX_ATTEMPTS"`
+	// A failed attempt is retried after BackoffBase, doubled on every
+	// further attempt up to BackoffMax.
+	BackoffBase time.Duration `yaml:"backoff_base" env:"AWP_JOBS_BACKOFF_BASE"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env:"AWP_JOBS_BACKOFF_MAX"`
+	// FilesDir holds the uploads of import jobs and the files of export
+	// jobs. Instances sharing the jobs table must share it too.
+	FilesDir string `yaml:"files_dir" env:"AWP_JOBS_FILES_DIR"`
+	// FileRetention is how long job files are kept, so how long an export
+	// can be downloaded and an import may wait to run. Zero keeps them until
+	// they are deleted by hand.
+	FileRetention     time.Duration `yaml:"file_retention" env:"AWP_JOBS_FILE_RETENTION"`
+	FileSweepInterval time.Duration `yaml:"file_sweep_interval" env:"AWP_JOBS_FILE_SWEEP_INTERVAL"`
+}
+
+type Webhooks struct {
+	// PollInterval is how often the dispatcher looks for new events and due
+	// deliveries. Zero runs no dispatcher on this instance.
+	PollInterval time.Duration `yaml:"poll_interval" env:"AWP_WEBHOOKS_POLL_INTERVAL"`
+	// BatchSize is how many events are fanned out and how many deliveries
+	// are sent at once per poll.
+	BatchSize int           `yaml:"batch_size" env:"AWP_WEBHOOKS_BATCH_SIZE"`
+	Timeout   time.Duration `yaml:"timeout" env:"AWP_WEBHOOKS_TIMEOUT"`
+	// Lease is how long a claimed delivery is held before another dispatcher
+	// may send it again. It must exceed Timeout.
+	Lease       time.Duration `yaml:"lease" env:"AWP_WEBHOOKS_LEASE"`
+	MaxAttempts int           `yaml:"max_attempts" env:"AWP_WEBHOOKS_MAX_ATTEMPTS"`
+	// A failed delivery is retried after BackoffBase, doubled on every
+	// further attempt up to BackoffMax.
+	BackoffBase time.Duration `yaml:"backoff_base" env:"AWP_WEBHOOKS_BACKOFF_BASE"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env:"AWP_WEBHOOKS_BACKOFF_MAX"`
+	// EventRetention is how long dispatched events and their deliveries are
+	// kept. Zero keeps them forever.
+	EventRetention time.Duration `yaml:"event_retention" env:"AWP_WEBHOOKS_EVENT_RETENTION"`
+}
+
+type Tracing struct {
+	// Exporter is one of none, stdout or otlp. stdout writes the spans to
+	// File, or to the standard output when File is empty.
+	Exporter string `yaml:"exporter" env:"AWP_TRACING_EXPORTER"`
+	File     string `yaml:"file" env:"AWP_TRACING_FILE"`
+	// Endpoint is the host:port of the OTLP/HTTP collector.
+	Endpoint    string `yaml:"endpoint" env:"AWP_TRACING_ENDPOINT"`
+	Insecure    bool   `yaml:"insecure" env:"AWP_TRACING_INSECURE"`
+	ServiceName string `yaml:"service_name" env:"AWP_TRACING_SERVICE_NAME"`
+	// SampleRatio is the share of traces started here that are recorded.
+	// Traces continued from a caller follow its sampling decision.
+	SampleRatio float64 `yaml:"sample_ratio" env:"AWP_TRACING_SAMPLE_RATIO"`
+}
+
+type RateLimit struct {
+	// Backend is memory, which limits every instance on its own, or
+	// postgres, which shares the limits between instances.
+	Backend string `yaml:"backend" env:"AWP_RATE_LIMIT_BACKEND"`
+	// TrustForwardedFor takes the client IP from the last X-Forwarded-For
+	// entry. Only enable it behind a proxy that sets the header.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" env:"AWP_RATE_LIMIT_TRUST_FORWARDED_FOR"`
+	// Groups holds the rule of each route group, see RateLimitGroups.
+	// Authenticated groups without a rule of their own share the api one.
+	Groups map[string]RateLimitRule `yaml:"groups"`
+}
+
+// RateLimitGroups are the route groups rate limits can be set for. auth
+// holds signup, login, logout and token refresh, the others are
+// authenticated routes.
+var RateLimitGroups = []string{"auth", "api", "sessions", "users", "categories", "products", "trash", "jobs", "webhooks"}
+
+// RateLimitRule is a token bucket of Requests tokens, refilled evenly over
+// Period. Zero requests disable the limit.
+type RateLimitRule struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	// Key is ip or user. Requests without a user are limited by IP. api_key
+	// is rejected until API keys are issued.
+	Key string `yaml:"key"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Server: Server{
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			DrainDelay:      5 * time.Second,
+			HealthTimeout:   2 * time.Second,
+			MetricsAddr:     "127.0.0.1:9464",
+		},
+		Logging: Logging{
+			RedactHeaders: []string{"Authorization", "Proxy-Authorization"},
+			RedactCookies: []string{"token", "refresh_token"},
+		},
+		Database: Database{
+			Driver:          "postgres",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			MigrateOnStart:  true,
+		},
+		JWT: JWT{
+			TokenTTL:        15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Authorization: Authorization{
+			CacheTTL: time.Minute,
+		},
+		Catalog: Catalog{
+			CategoryDeletePolicy: "reject",
+		},
+		Trash: Trash{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Jobs: Jobs{
+			Workers:           2,
+			PollInterval:      time.Second,
+			Lease:             5 * time.Minute,
+			MaxAttempts:       5,
+			BackoffBase:       10 * time.Second,
+			BackoffMax:        10 * time.Minute,
+			FilesDir:          filepath.Join(os.TempDir(), "awesome-project", "job-files"),
+			FileRetention:     24 * time.Hour,
+			FileSweepInterval: time.Hour,
+		},
+		Webhooks: Webhooks{
+			PollInterval:   time.Second,
+			BatchSize:      50,
+			Timeout:        10 * time.Second,
+			Lease:          time.Minute,
+			MaxAttempts:    10,
+			BackoffBase:    30 * time.Second,
+			BackoffMax:     time.Hour,
+			EventRetention: 7 * 24 * time.Hour,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "awesomeProject",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimit{
+			Backend: "memory",
+			Groups: map[string]RateLimitRule{
+				"auth": {
+					Requests: 10,
+					Period:   time.Minute,
+					Key:      "ip",
+				},
+				"api": {
+					Requests: 600,
+					Period:   time.Minute,
+					Key:      "user",
+				},
+			},
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the optional file at path and
+// finally the environment, so that every deployment can override single values
+// without shipping a new file.
+func Load(path string) (*Config, error) {
+	config := DefaultConfig()
+
+	if path != "" {
+		err := loadFile(path, &config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := loadEnv(reflect.ValueOf(&config).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port == "" {
+		errs = append(errs, errors.New("server.port (AWP_PORT) is required"))
+	}
+
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+
+	if c.Server.DrainDelay < 0 {
+		errs = append(errs, errors.New("server.drain_delay must not be negative"))
+	}
+
+	if c.Server.HealthTimeout <= 0 {
+		errs = append(errs, errors.New("server.health_timeout must be positive"))
+	}
+
+	if c.Server.MetricsAddr != "" {
+		_, port, err := net.SplitHostPort(c.Server.MetricsAddr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("server.metrics_addr must be host:port: %w", err))
+		} else if port == c.Server.Port {
+			errs = append(errs, errors.New("server.metrics_addr must not use server.port"))
+		}
+	}
+
+	if c.Database.Driver == "" {
+		errs = append(errs, errors.New("database.driver (AWP_DB_DRIVER) is required"))
+	}
+
+	if c.Database.DataSource == "" {
+		errs = append(errs, errors.New("database.data_source (AWP_DB_DATASOURCE) is required"))
+	}
+
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, errors.New("database.max_open_conns must be at least 1"))
+	}
+
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must be between 0 and database.max_open_conns"))
+	}
+
+	if c.JWT.Secret == "" && len(c.JWT.Keys) == 0 {
+		errs = append(errs, errors.New("jwt.secret (AWP_JWT_SECRET) or jwt.keys is required"))
+	}
+
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < minJWTSecretBytes {
+		errs = append(errs, fmt.Errorf("jwt.secret (AWP_JWT_SECRET) must be at least %d bytes", minJWTSecretBytes))
+	}
+
+	for i, key := range c.JWT.Keys {
+		if key.ID == "" {
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].id is required", i))
+		}
+
+		if key.Algorithm == "" {
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].algorithm is required", i))
+		}
+
+		if key.Secret != "" && len(key.Secret) < minJWTSecretBytes {
+			errs = append(errs, fmt.Errorf("jwt.keys[%d].secret must be at least %d bytes", i, minJWTSecretBytes))
+		}
+	}
+
+	if len(c.JWT.Keys) > 0 && c.JWT.SigningKeyID == "" {
+		errs = append(errs, errors.New("jwt.signing_key_id (AWP_JWT_SIGNING_KEY_ID) is required when jwt.keys is set"))
+	}
+
+	if c.JWT.TokenTTL <= 0 {
+		errs = append(errs, errors.New("jwt.token_ttl must be positive"))
+	}
+
+	if c.JWT.RefreshTokenTTL <= c.JWT.TokenTTL {
+		errs = append(errs, errors.New("jwt.refresh_token_ttl must be longer than jwt.token_ttl"))
+	}
+
+	switch c.Catalog.CategoryDeletePolicy {
+	case "reject", "cascade", "reparent":
+	default:
+		errs = append(errs, fmt.Errorf("catalog.category_delete_policy must be one of reject, cascade or reparent, got %q", c.Catalog.CategoryDeletePolicy))
+	}
+
+	if c.Trash.Retention < 0 {
+		errs = append(errs, errors.New("trash.retention must not be negative"))
+	}
+
+	if c.Trash.Retention > 0 && c.Trash.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash.purge_interval must be positive"))
+	}
+
+	if c.Jobs.Workers < 0 {
+		errs = append(errs, errors.New("jobs.workers must not be negative"))
+	}
+
+	if c.Jobs.Workers > 0 && (c.Jobs.PollInterval <= 0 || c.Jobs.Lease <= 0) {
+		errs = append(errs, errors.New("jobs.poll_interval and jobs.lease must be positive"))
+	}
+
+	if c.Jobs.MaxAttempts < 1 {
+		errs = append(errs, errors.New("jobs.max_attempts must be at least 1"))
+	}
+
+	if c.Jobs.BackoffBase <= 0 || c.Jobs.BackoffMax < c.Jobs.BackoffBase {
+		errs = append(errs, errors.New("jobs.backoff_base must be positive and at most jobs.backoff_max"))
+	}
+
+	if c.Jobs.FilesDir == "" {
+		errs = append(errs, errors.New("jobs.files_dir is required"))
+	}
+
+	if c.Jobs.FileRetention < 0 {
+		errs = append(errs, errors.New("jobs.file_retention must not be negative"))
+	}
+
+	if c.Jobs.FileRetention > 0 && c.Jobs.FileSweepInterval <= 0 {
+		errs = append(errs, errors.New("jobs.file_sweep_interval must be positive"))
+	}
+
+	if c.Webhooks.PollInterval < 0 {
+		errs = append(errs, errors.New("webhooks.poll_interval must not be negative"))
+	}
+
+	if c.Webhooks.BatchSize < 1 {
+		errs = append(errs, errors.New("webhooks.batch_size must be at least 1"))
+	}
+
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.Lease <= c.Webhooks.Timeout {
+		errs = append(errs, errors.New("webhooks.timeout must be positive and below webhooks.lease"))
+	}
+
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be at least 1"))
+	}
+
+	if c.Webhooks.BackoffBase <= 0 || c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		errs = append(errs, errors.New("webhooks.backoff_base must be positive and at most webhooks.backoff_max"))
+	}
+
+	if c.Webhooks.EventRetention < 0 {
+		errs = append(errs, errors.New("webhooks.event_retention must not be negative"))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint (AWP_TRACING_ENDPOINT) is required for the otlp exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be one of none, stdout or otlp, got %q", c.Tracing.Exporter))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	switch c.RateLimit.Backend {
+	case "memory", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("rate_limit.backend must be one of memory or postgres, got %q", c.RateLimit.Backend))
+	}
+
+	groups := make([]string, 0, len(c.RateLimit.Groups))
+	for group := range c.RateLimit.Groups {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+
+	for _, group := range groups {
+		rule := c.RateLimit.Groups[group]
+		name := "rate_limit.groups." + group
+
+		switch {
+		case !slices.Contains(RateLimitGroups, group):
+			errs = append(errs, fmt.Errorf("%s is not a route group, must be one of %s", name, strings.Join(RateLimitGroups, ", ")))
+		case group == "auth":
+			// Credentials are checked by the handlers, so there is no user
+			// yet to key by.
+			errs = append(errs, rule.validate(name, "ip")...)
+		default:
+			errs = append(errs, rule.validate(name, "ip", "user")...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// validate checks the rule configured at name, whose key must be one of
+// keys.
+func (r RateLimitRule) validate(name string, keys ...string) []error {
+	if r.Requests == 0 {
+		return nil
+	}
+
+	var errs []error
+
+	if r.Requests < 0 || r.Period <= 0 {
+		errs = append(errs, fmt.Errorf("%s.requests and %s.period must be positive", name, name))
+	}
+
+	if !slices.Contains(keys, r.Key) {
+		errs = append(errs, fmt.Errorf("%s.key must be one of %s, got %q", name, strings.Join(keys, ", "), r.Key))
+	}
+
+	return errs
+}
+
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, config)
+	case ".toml":
+		err = unmarshalTOML(data, config)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// unmarshalTOML decodes a TOML file through its YAML form, so that both
+// formats share the keys of the yaml tags and their parsing, e.g. of
+// durations.
+func unmarshalTOML(data []byte, config *Config) error {
+	var values map[string]interface{}
+
+	err := toml.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+
+	data, err = yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(data, config)
+}
+
+func loadEnv(value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		fieldType := value.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			err := loadEnv(field)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := fieldType.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			continue
+		}
+
+		err := setField(field, raw)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case time.Duration:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	case []string:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		number, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(number)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(number)
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolean)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		dir  string
+		path string
+	)
+
+	writeConfig := func(content string) {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, "config.yaml")
+
+		GinkgoT().Setenv("AWP_PORT", "")
+		GinkgoT().Setenv("AWP_DB_DRIVER", "")
+		GinkgoT().Setenv("AWP_DB_DATASOURCE", "")
+		GinkgoT().Setenv("AWP_DB_MAX_OPEN_CONNS", "")
+		GinkgoT().Setenv("AWP_JWT_SECRET", "")
+		GinkgoT().Setenv("AWP_CATALOG_CATEGORY_DELETE_POLICY", "")
+	})
+
+	Describe("Load", func() {
+		It("should load values from the file", func() {
+			writeConfig(`
+server:
+  port: "9090"
+  shutdown_timeout: 5s
+database:
+  data_source: postgres://localhost/items
+  max_open_conns: 20
+jwt:
+  secret: file-secret-of-at-least-thirty-two-bytes
+`)
+
+			config, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Server.Port).To(Equal("9090"))
+			Expect(config.Server.ShutdownTimeout).To(Equal(5 * time.Second))
+			Expect(config.Database.Driver).To(Equal("postgres"))
+			Expect(config.Database.DataSource).To(Equal("postgres://localhost/items"))
+			Expect(config.Database.MaxOpenConns).To(Equal(20))
+			Expect(config.JWT.Secret).To(Equal("file-secret-of-at-least-thirty-two-bytes"))
+		})
+
+		It("should let the environment override the file", func() {
+			writeConfig(`
+database:
+  data_source: postgres://localhost/items
+jwt:
+  secret: file-secret-of-at-least-thirty-two-bytes
+`)
+			GinkgoT().Setenv("AWP_PORT", "7070")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_DB_MAX_OPEN_CONNS", "30")
+
+			config, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Server.Port).To(Equal("7070"))
+			Expect(config.JWT.Secret).To(Equal("env-secret-of-at-least-thirty-two-bytes"))
+			Expect(config.Database.MaxOpenConns).To(Equal(30))
+		})
+
+		It("should load without a file", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+
+			config, err := Load("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Server.Port).To(Equal("8080"))
+			Expect(config.Catalog.CategoryDeletePolicy).To(Equal("reject"))
+		})
+
+		It("should report every missing required field", func() {
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("AWP_DB_DATASOURCE"))
+			Expect(err.Error()).To(ContainSubstring("AWP_JWT_SECRET"))
+		})
+
+		It("should reject an unknown category delete policy", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_CATALOG_CATEGORY_DELETE_POLICY", "orphan")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("catalog.category_delete_policy"))
+		})
+
+		It("should require a purge interval when trash retention is set", func() {
+			writeConfig(`
+database:
+  data_source: postgres://localhost/items
+jwt:
+  secret: file-secret-of-at-least-thirty-two-bytes
+trash:
+  retention: 24h
+  purge_interval: 0s
+`)
+
+			_, err := Load(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("trash.purge_interval"))
+		})
+
+		It("should require a sweep interval when job file retention is set", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_JOBS_FILE_SWEEP_INTERVAL", "0s")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs.file_sweep_interval"))
+		})
+
+		It("should reject a job backoff longer than its maximum", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_JOBS_BACKOFF_BASE", "1h")
+			GinkgoT().Setenv("AWP_JOBS_BACKOFF_MAX", "1m")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs.backoff_base"))
+		})
+
+		It("should reject a webhook timeout that outlasts the lease", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_WEBHOOKS_TIMEOUT", "2m")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("webhooks.timeout"))
+		})
+
+		It("should reject a negative event retention", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_WEBHOOKS_EVENT_RETENTION", "-1h")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("webhooks.event_retention"))
+		})
+
+		It("should require an endpoint for the otlp exporter", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_TRACING_EXPORTER", "otlp")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("tracing.endpoint"))
+		})
+
+		It("should reject a metrics address on the API port", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_SERVER_METRICS_ADDR", ":8080")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("server.metrics_addr must not use server.port"))
+		})
+
+		It("should reject a metrics address without a port", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_SERVER_METRICS_ADDR", "localhost")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("server.metrics_addr must be host:port"))
+		})
+
+		It("should reject a JWT secret shorter than 32 bytes", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "secret")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jwt.secret (AWP_JWT_SECRET) must be at least 32 bytes"))
+		})
+
+		It("should reject an unknown rate limit backend", func() {
+			GinkgoT().Setenv("AWP_DB_DATASOURCE", "postgres://localhost/items")
+			GinkgoT().Setenv("AWP_JWT_SECRET", "env-secret-of-at-least-thirty-two-bytes")
+			GinkgoT().Setenv("AWP_RATE_LIMIT_BACKEND", "redis")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("rate_limit.backend"))
+		})
+
+		It("should add rate limit groups to the default ones", func() {
+			writeConfig(`
+database:
+  data_source: postgres://localhost/items
+jwt:
+  secret: file-secret-of-at-least-thirty-two-bytes
+rate_limit:
+  groups:
+    users:
+      requests: 60
+      period: 1m
+      key: user
+`)
+
+			config, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.RateLimit.Groups).To(HaveKeyWithValue("users", RateLimitRule{Requests: 60, Period: time.Minute, Key: "user"}))
+			Expect(config.RateLimit.Groups).To(HaveKey("auth"))
+			Expect(config.RateLimit.Groups).To(HaveKey("api"))
+		})
+
+		It("should reject api_key limits and unknown rate limit groups", func() {
+			writeConfig(`
+database:
+  data_source: postgres://localhost/items
+jwt:
+  secret: file-secret-of-at-least-thirty-two-bytes
+rate_limit:
+  groups:
+    api:
+      requests: 600
+      period: 1m
+      key: api_key
+    user:
+      requests: 60
+      period: 1m
+      key: user
+`)
+
+			_, err := Load(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`rate_limit.groups.api.key must be one of ip, user, got "api_key"`))
+			Expect(err.Error()).To(ContainSubstring("rate_limit.groups.user is not a route group"))
+		})
+
+		It("should return error on invalid environment value", func() {
+			GinkgoT().Setenv("AWP_DB_MAX_OPEN_CONNS", "many")
+
+			_, err := Load("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid value for AWP_DB_MAX_OPEN_CONNS"))
+		})
+
+		It("should load values from a TOML file", func() {
+			path = filepath.Join(dir, "config.toml")
+			writeConfig(`
+[server]
+port = "9090"
+shutdown_timeout = "5s"
+
+[database]
+data_source = "postgres://localhost/items"
+max_open_conns = 20
+
+[jwt]
+secret = "file-secret-of-at-least-thirty-two-bytes"
+
+[logging]
+redact_headers = ["Authorization", "X-Session"]
+`)
+
+			config, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Server.Port).To(Equal("9090"))
+			Expect(config.Server.ShutdownTimeout).To(Equal(5 * time.Second))
+			Expect(config.Database.MaxOpenConns).To(Equal(20))
+			Expect(config.JWT.Secret).To(Equal("file-secret-of-at-least-thirty-two-bytes"))
+			Expect(config.Logging.RedactHeaders).To(Equal([]string{"Authorization", "X-Session"}))
+		})
+
+		It("should return error on unsupported file format", func() {
+			path = filepath.Join(dir, "config.ini")
+			writeConfig("port=1")
+
+			_, err := Load(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported config file format"))
+		})
+
+		It("should return error when the file is missing", func() {
+			_, err := Load(filepath.Join(dir, "missing.yaml"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to read config file"))
+		})
+	})
+})
@@ -2,54 +2,71 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"net"
 	"net/http"
 	"time"
 
+	"awesomeProject/configs"
+	"awesomeProject/internal/middlewares/authentication"
 	"awesomeProject/internal/models"
 	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
 	"awesomeProject/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	refreshTokenCookie = "refresh_token"
+	refreshTokenPath   = "/api/v1"
+	defaultRole        = "user"
 )
 
 type Auther interface {
 	Register(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	RefreshToken(w http.ResponseWriter, r *http.Request)
 }
 
 type AuthHandler struct {
-	authRepository repositories.AuthRepository
+	authRepository    repositories.AuthRepository
+	sessionRepository repositories.SessionRepository
+	keys              *utils.KeyManager
+	jwtConfig         configs.JWT
 }
 
-func NewAuth(authRepository repositories.AuthRepository) Auther {
+func NewAuth(authRepository repositories.AuthRepository, sessionRepository repositories.SessionRepository, keys *utils.KeyManager, jwtConfig configs.JWT) Auther {
 	return &AuthHandler{
-		authRepository: authRepository,
+		authRepository:    authRepository,
+		sessionRepository: sessionRepository,
+		keys:              keys,
+		jwtConfig:         jwtConfig,
 	}
 }
 
 func (a *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user models.Auth
 
-	if r.Body == nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&user)
+	err := decodeValidBody(r, &user)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	user.Role = defaultIfEmpty(user.Role, defaultRole)
+
 	user.Password, err = utils.GenerateHashPassword(user.Password)
 	if err != nil {
-		http.Error(w, "Hashing password error", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.Internal(err))
 		return
 	}
 
-	err = a.authRepository.Register(&user)
+	err = a.authRepository.Register(r.Context(), &user)
 	if err != nil {
-		http.Error(w, "Register error", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
 		return
 	}
 
@@ -59,60 +76,206 @@ func (a *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (a *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var auth models.Auth
 
-	if r.Body == nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	err := decodeBody(r, &auth)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&auth)
+	user, err := a.authRepository.Login(r.Context(), &auth)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			err = errInvalidCredentials
+		}
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	passwordCheck := utils.CheckPasswordHash(user.Password, auth.Password)
+	if !passwordCheck {
+		apperrors.Write(w, r, errInvalidCredentials)
 		return
 	}
 
-	user, err := a.authRepository.Login(&auth)
+	refreshToken, err := utils.GenerateRefreshToken()
 	if err != nil {
-		fmt.Errorf("error logging in: %v", err)
-		http.Error(w, "Invalid login credentials", http.StatusUnauthorized)
+		apperrors.Write(w, r, apperrors.Internal(err))
 		return
 	}
 
-	passwordCheck := utils.CheckPasswordHash(user.Password, auth.Password)
-	if !passwordCheck {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	session := &models.Session{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+		ExpiresAt: time.Now().Add(a.jwtConfig.RefreshTokenTTL),
+	}
+
+	// The token is signed before the session is stored, so that a failure
+	// leaves no session behind whose tokens nobody has.
+	token, err := utils.GenerateJWT(*user, session.ID, a.keys, a.jwtConfig.TokenTTL)
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal(err))
 		return
 	}
 
-	token, err := utils.GenerateJWT(*user)
+	err = a.sessionRepository.CreateSession(r.Context(), session, utils.HashToken(refreshToken))
 	if err != nil {
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	a.writeTokens(w, token, refreshToken, http.StatusAccepted)
+}
+
+func (a *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	presented := refreshTokenFromRequest(r)
+	if presented == "" {
+		apperrors.Write(w, r, apperrors.Unauthorized("refresh_token_missing", "refresh token missing"))
+		return
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal(err))
+		return
+	}
+
+	session, err := a.sessionRepository.RotateRefreshToken(r.Context(), utils.HashToken(presented), utils.HashToken(refreshToken))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRefreshTokenReused), errors.Is(err, repositories.ErrInvalidRefreshToken):
+			clearTokenCookies(w)
+		case errors.Is(err, repositories.ErrSessionNotFound):
+			clearTokenCookies(w)
+			err = repositories.ErrInvalidRefreshToken
+		}
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	user, err := a.authRepository.GetUserByID(r.Context(), session.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			err = repositories.ErrInvalidRefreshToken
+		}
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	// The presented refresh token is used up by now. When no token can be
+	// issued the session is revoked, instead of left with a refresh token
+	// the client never got.
+	token, err := utils.GenerateJWT(*user, session.ID, a.keys, a.jwtConfig.TokenTTL)
+	if err != nil {
+		revokeErr := a.sessionRepository.RevokeSession(r.Context(), session.ID, session.UserID)
+		if revokeErr != nil {
+			logrus.WithError(revokeErr).WithField("session_id", session.ID).Error("Failed to revoke session after token signing failed")
+		}
+
+		clearTokenCookies(w)
+		apperrors.Write(w, r, apperrors.Internal(err))
+		return
+	}
+
+	a.writeTokens(w, token, refreshToken, http.StatusOK)
+}
+
+func (a *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil && cookie.Value != "" {
+		err = a.sessionRepository.RevokeSessionByRefreshToken(r.Context(), utils.HashToken(cookie.Value))
+		if err != nil && !errors.Is(err, repositories.ErrInvalidRefreshToken) {
+			apperrors.Write(w, r, err)
+			return
+		}
+	} else if claims, err := authentication.GetTokenClaims(r, a.keys); err == nil {
+		principal := authentication.NewPrincipal(claims)
+
+		err = a.sessionRepository.RevokeSession(r.Context(), principal.SessionID, principal.UserID)
+		if err != nil && !errors.Is(err, repositories.ErrSessionNotFound) {
+			apperrors.Write(w, r, err)
+			return
+		}
+	}
+
+	clearTokenCookies(w)
+
+	http.Redirect(w, r, "/api/v1/login", http.StatusSeeOther)
+}
+
+func (a *AuthHandler) writeTokens(w http.ResponseWriter, token, refreshToken string, status int) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
+		Name:     authentication.TokenCookie,
 		Value:    token,
 		Path:     "/",
-		Expires:  time.Now().Add(time.Hour * 24),
+		Expires:  time.Now().Add(a.jwtConfig.TokenTTL),
 		Secure:   false,
 		HttpOnly: true,
 	})
 
-	w.WriteHeader(http.StatusAccepted)
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    refreshToken,
+		Path:     refreshTokenPath,
+		Expires:  time.Now().Add(a.jwtConfig.RefreshTokenTTL),
+		Secure:   false,
+		HttpOnly: true,
+	})
+
+	writeJSON(w, status, models.TokenResponse{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(a.jwtConfig.TokenTTL.Seconds()),
+	})
 }
 
-func (a *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	cookie := &http.Cookie{
-		Name:     "token",
+func refreshTokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	if r.Body == nil {
+		return ""
+	}
+
+	var request models.RefreshRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return ""
+	}
+
+	return request.RefreshToken
+}
+
+func clearTokenCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     authentication.TokenCookie,
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   false,
 		Expires:  time.Unix(0, 0),
 		MaxAge:   -1,
-	}
+	})
 
-	http.SetCookie(w, cookie)
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    "",
+		Path:     refreshTokenPath,
+		HttpOnly: true,
+		Secure:   false,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+	})
+}
 
-	http.Redirect(w, r, "/api/v1/login", http.StatusSeeOther)
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
 }
@@ -2,15 +2,22 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
-	"errors"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"time"
 
+	"awesomeProject/configs"
 	"awesomeProject/internal/handlers/mocks"
 	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
 	"awesomeProject/pkg/utils"
+	"awesomeProject/pkg/validation"
 
 	"github.com/golang/mock/gomock"
 
@@ -18,10 +25,26 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// unsignableKeys returns a key manager whose RSA key is too small to sign
+// anything, so every GenerateJWT call fails.
+func unsignableKeys() *utils.KeyManager {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 256)
+	Expect(err).NotTo(HaveOccurred())
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	keys, err := utils.NewKeyManager(configs.JWT{
+		SigningKeyID: "small",
+		Keys:         []configs.JWTKey{{ID: "small", Algorithm: "RS256", PrivateKey: string(encoded)}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return keys
+}
+
 var _ = Describe("Auth Handler", func() {
 	var (
 		mockCtrl         *gomock.Controller
 		mockRepo         *mocks.MockAuthRepository
+		mockSessionRepo  *mocks.MockSessionRepository
 		authHandler      *AuthHandler
 		responseRecorder *httptest.ResponseRecorder
 	)
@@ -29,7 +52,17 @@ var _ = Describe("Auth Handler", func() {
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockRepo = mocks.NewMockAuthRepository(mockCtrl)
-		authHandler = &AuthHandler{authRepository: mockRepo}
+		mockSessionRepo = mocks.NewMockSessionRepository(mockCtrl)
+		jwtConfig := configs.JWT{Secret: "test-secret", TokenTTL: time.Hour, RefreshTokenTTL: 24 * time.Hour}
+		keys, err := utils.NewKeyManager(jwtConfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		authHandler = &AuthHandler{
+			authRepository:    mockRepo,
+			sessionRepository: mockSessionRepo,
+			keys:              keys,
+			jwtConfig:         jwtConfig,
+		}
 		responseRecorder = httptest.NewRecorder()
 	})
 
@@ -42,7 +75,7 @@ var _ = Describe("Auth Handler", func() {
 			user := &models.Auth{
 				Username: "testuser",
 				Email:    "testuser@example.com",
-				Password: "password",
+				Password: "password1",
 				Role:     "user",
 			}
 
@@ -52,7 +85,7 @@ var _ = Describe("Auth Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().Register(gomock.Any()).Return(nil).Times(1)
+			mockRepo.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 			authHandler.Register(responseRecorder, request)
 
@@ -67,6 +100,33 @@ var _ = Describe("Auth Handler", func() {
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
 		})
+		It("should return 422 with every invalid field", func() {
+			user := &models.Auth{
+				Username: "testuser",
+				Email:    "not-an-email",
+				Password: "short",
+				Role:     "admin",
+			}
+
+			requestBody, err := json.Marshal(user)
+			Expect(err).NotTo(HaveOccurred())
+			request, err := http.NewRequest("POST", "/api/v1/signup", bytes.NewBuffer(requestBody))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/json")
+
+			mockRepo.EXPECT().Register(gomock.Any(), gomock.Any()).Times(0)
+
+			authHandler.Register(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			problem := decodeProblem(responseRecorder)
+			Expect(problem.Code).To(Equal(validation.CodeValidationFailed))
+			Expect(problem.Errors).To(HaveKey("email"))
+			Expect(problem.Errors).To(HaveKey("password"))
+			Expect(problem.Errors).To(HaveKey("role"))
+			Expect(problem.Errors).NotTo(HaveKey("username"))
+		})
 	})
 
 	Describe("Login", func() {
@@ -87,10 +147,19 @@ var _ = Describe("Auth Handler", func() {
 			}
 
 			mockRepo.EXPECT().
-				Login(gomock.Eq(auth)).
+				Login(gomock.Any(), gomock.Eq(auth)).
 				Return(userResponse, nil).
 				Times(1)
 
+			mockSessionRepo.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, session *models.Session, refreshTokenHash string) error {
+					Expect(session.UserID).To(Equal("1"))
+					Expect(refreshTokenHash).To(HaveLen(64))
+					return nil
+				}).
+				Times(1)
+
 			requestBody, err := json.Marshal(auth)
 			Expect(err).NotTo(HaveOccurred())
 			request, err := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(requestBody))
@@ -100,6 +169,17 @@ var _ = Describe("Auth Handler", func() {
 			authHandler.Login(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+
+			var tokens models.TokenResponse
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&tokens)).To(Succeed())
+			Expect(tokens.AccessToken).NotTo(BeEmpty())
+			Expect(tokens.RefreshToken).NotTo(BeEmpty())
+
+			cookies := responseRecorder.Result().Cookies()
+			Expect(cookies).To(HaveLen(2))
+			Expect(cookies[0].Name).To(Equal("token"))
+			Expect(cookies[1].Name).To(Equal("refresh_token"))
+			Expect(cookies[1].Value).To(Equal(tokens.RefreshToken))
 		})
 
 		It("should return 401 for invalid credentials", func() {
@@ -115,15 +195,145 @@ var _ = Describe("Auth Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().Login(auth).Return(userResponse, errors.New("invalid login credentials")).Times(1)
+			mockRepo.EXPECT().Login(gomock.Any(), auth).Return(userResponse, repositories.ErrUserNotFound).Times(1)
+
+			authHandler.Login(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("invalid_credentials"))
+		})
+
+		It("should not create a session when the token cannot be signed", func() {
+			authHandler.keys = unsignableKeys()
+			auth := &models.Auth{Email: "testuser@example.com", Password: "password"}
+
+			hashedPassword, err := utils.GenerateHashPassword("password")
+			Expect(err).NotTo(HaveOccurred())
+			mockRepo.EXPECT().
+				Login(gomock.Any(), gomock.Eq(auth)).
+				Return(&models.UserResponse{ID: "1", Email: auth.Email, Password: hashedPassword, Role: "user"}, nil).
+				Times(1)
+			mockSessionRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			requestBody, err := json.Marshal(auth)
+			Expect(err).NotTo(HaveOccurred())
+			request, err := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(requestBody))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/json")
 
 			authHandler.Login(responseRecorder, request)
 
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(responseRecorder.Result().Cookies()).To(BeEmpty())
+		})
+	})
+
+	Describe("RefreshToken", func() {
+		It("should rotate the refresh token", func() {
+			request, err := http.NewRequest("POST", "/api/v1/token/refresh", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-token"})
+
+			mockSessionRepo.EXPECT().
+				RotateRefreshToken(gomock.Any(), utils.HashToken("old-token"), gomock.Any()).
+				Return(&models.Session{ID: "session", UserID: "1"}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				GetUserByID(gomock.Any(), "1").
+				Return(&models.UserResponse{ID: "1", Username: "test", Email: "test@example.com", Role: "user"}, nil).
+				Times(1)
+
+			authHandler.RefreshToken(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var tokens models.TokenResponse
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&tokens)).To(Succeed())
+			Expect(tokens.RefreshToken).NotTo(Equal("old-token"))
+
+			claims, err := utils.VerifyJWT(tokens.AccessToken, authHandler.keys)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims["sid"]).To(Equal("session"))
+		})
+		It("should revoke the rotated session when the token cannot be signed", func() {
+			authHandler.keys = unsignableKeys()
+			request, err := http.NewRequest("POST", "/api/v1/token/refresh", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-token"})
+
+			mockSessionRepo.EXPECT().
+				RotateRefreshToken(gomock.Any(), utils.HashToken("old-token"), gomock.Any()).
+				Return(&models.Session{ID: "session", UserID: "1"}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				GetUserByID(gomock.Any(), "1").
+				Return(&models.UserResponse{ID: "1", Username: "test", Email: "test@example.com", Role: "user"}, nil).
+				Times(1)
+			mockSessionRepo.EXPECT().RevokeSession(gomock.Any(), "session", "1").Return(nil).Times(1)
+
+			authHandler.RefreshToken(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
+			cookies := responseRecorder.Result().Cookies()
+			Expect(cookies).To(HaveLen(2))
+			Expect(cookies[0].MaxAge).To(BeNumerically("<", 0))
+		})
+		It("should accept the refresh token in the body", func() {
+			requestBody, err := json.Marshal(models.RefreshRequest{RefreshToken: "old-token"})
+			Expect(err).NotTo(HaveOccurred())
+			request, err := http.NewRequest("POST", "/api/v1/token/refresh", bytes.NewBuffer(requestBody))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockSessionRepo.EXPECT().
+				RotateRefreshToken(gomock.Any(), utils.HashToken("old-token"), gomock.Any()).
+				Return(nil, repositories.ErrInvalidRefreshToken).
+				Times(1)
+
+			authHandler.RefreshToken(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("should return 401 and clear cookies when the token is reused", func() {
+			request, err := http.NewRequest("POST", "/api/v1/token/refresh", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.AddCookie(&http.Cookie{Name: "refresh_token", Value: "old-token"})
+
+			mockSessionRepo.EXPECT().
+				RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, repositories.ErrRefreshTokenReused).
+				Times(1)
+
+			authHandler.RefreshToken(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring("session revoked"))
+			Expect(responseRecorder.Result().Cookies()).To(HaveLen(2))
+		})
+		It("should return 401 without a refresh token", func() {
+			request, err := http.NewRequest("POST", "/api/v1/token/refresh", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			authHandler.RefreshToken(responseRecorder, request)
+
 			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
 		})
 	})
 
 	Describe("Logout", func() {
+		It("should revoke the session of the refresh token", func() {
+			request, err := http.NewRequest("POST", "/api/v1/logout", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+
+			mockSessionRepo.EXPECT().
+				RevokeSessionByRefreshToken(gomock.Any(), utils.HashToken("refresh")).
+				Return(nil).
+				Times(1)
+
+			authHandler.Logout(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusSeeOther))
+		})
 		It("should clear the token cookie and redirect", func() {
 			request, err := http.NewRequest("POST", "/api/v1/logout", nil)
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/validation"
+)
+
+// validateItems checks the validate tags of each item of a batch and fails
+// the items that do not pass, so that one bad item does not reject a
+// partial batch as a whole.
+func validateItems[T any](items []T, result *models.BulkResult) {
+	for i := range items {
+		err := validation.Struct(&items[i])
+		if err != nil {
+			result.Fail(i, err)
+		}
+	}
+}
+
+// writeBulkResult responds with the per-item results of a bulk request.
+// An atomic batch rolled back for a failed item gets 422, anything else,
+// including a dry run, gets status.
+func writeBulkResult(w http.ResponseWriter, status int, result *models.BulkResult) {
+	if result.Abort() {
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, result)
+}
@@ -1,99 +1,215 @@
 package handlers
 
 import (
+	"net/http"
+
 	"awesomeProject/internal/models"
 	"awesomeProject/internal/repositories"
-	"encoding/json"
+	"awesomeProject/internal/transfer"
+	"awesomeProject/pkg/apperrors"
+
 	"github.com/gorilla/mux"
-	"net/http"
 )
 
 type Categorer interface {
 	GetCategoryHandler(w http.ResponseWriter, req *http.Request)
+	ListCategoriesHandler(w http.ResponseWriter, req *http.Request)
+	GetCategoryTreeHandler(w http.ResponseWriter, req *http.Request)
+	GetCategorySubtreeHandler(w http.ResponseWriter, req *http.Request)
+	GetCategoryBreadcrumbHandler(w http.ResponseWriter, req *http.Request)
 	UpdateCategoryHandler(w http.ResponseWriter, req *http.Request)
+	PatchCategoryHandler(w http.ResponseWriter, req *http.Request)
+	MoveCategoryHandler(w http.ResponseWriter, req *http.Request)
 	CreateCategoryHandler(w http.ResponseWriter, req *http.Request)
 	DeleteCategoryHandler(w http.ResponseWriter, req *http.Request)
+	CreateCategoriesHandler(w http.ResponseWriter, req *http.Request)
+	UpdateCategoriesHandler(w http.ResponseWriter, req *http.Request)
+	DeleteCategoriesHandler(w http.ResponseWriter, req *http.Request)
+	ExportCategoriesHandler(w http.ResponseWriter, req *http.Request)
+	ImportCategoriesHandler(w http.ResponseWriter, req *http.Request)
 }
 
 type CategoryHandler struct {
 	categoryRepo repositories.Categorer
+	deletePolicy models.CategoryDeletePolicy
 }
 
-func NewCategoryHandler(categoryRepo repositories.Categorer) Categorer {
+func NewCategoryHandler(categoryRepo repositories.Categorer, deletePolicy models.CategoryDeletePolicy) Categorer {
 	return &CategoryHandler{
 		categoryRepo: categoryRepo,
+		deletePolicy: deletePolicy,
 	}
 }
 
 func (c *CategoryHandler) GetCategoryHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	categoryID := mux.Vars(r)["category_id"]
 
-	category, err := c.categoryRepo.GetCategory(categoryID)
+	category, err := c.categoryRepo.GetCategory(r.Context(), categoryID)
 	if err != nil {
-		http.Error(w, "Category not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(category)
+	if writeNotModified(w, r, category.Version) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, category)
+}
+
+func (c *CategoryHandler) ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := c.categoryRepo.ListCategories(r.Context(), models.CategoryFilter{ListOptions: options})
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (c *CategoryHandler) GetCategoryTreeHandler(w http.ResponseWriter, r *http.Request) {
+	tree, err := c.categoryRepo.GetCategoryTree(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
 	}
+
+	writeJSON(w, http.StatusOK, tree)
 }
 
-func (c *CategoryHandler) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+func (c *CategoryHandler) GetCategorySubtreeHandler(w http.ResponseWriter, r *http.Request) {
+	categoryID := mux.Vars(r)["category_id"]
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	subtree, err := c.categoryRepo.GetCategorySubtree(r.Context(), categoryID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subtree)
+}
+
+func (c *CategoryHandler) GetCategoryBreadcrumbHandler(w http.ResponseWriter, r *http.Request) {
+	categoryID := mux.Vars(r)["category_id"]
+
+	breadcrumb, err := c.categoryRepo.GetCategoryBreadcrumb(r.Context(), categoryID)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	writeJSON(w, http.StatusOK, breadcrumb)
+}
+
+func (c *CategoryHandler) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
 	category := &models.Category{}
 
-	err := json.NewDecoder(r.Body).Decode(&category)
+	err := decodeValidBody(r, category)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	category.ID = mux.Vars(r)["category_id"]
 
-	err = c.categoryRepo.UpdateCategory(*category)
+	category.Version, err = ifMatchVersion(r)
 	if err != nil {
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = c.categoryRepo.UpdateCategory(r.Context(), *category)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (c *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+func (c *CategoryHandler) PatchCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	categoryID := mux.Vars(r)["category_id"]
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	category := &models.Category{}
+	category, err := c.categoryRepo.GetCategory(r.Context(), categoryID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	current := &models.CategoryPatch{Name: category.Name, ParentID: category.ParentID}
+	patch := &models.CategoryPatch{}
+
+	patch.Fields, err = applyPatch(w, r, current, patch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	patch.Version, err = patchVersion(version, category.Version)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	if len(patch.Fields) > 0 {
+		err = c.categoryRepo.PatchCategory(r.Context(), categoryID, *patch)
+		if err != nil {
+			apperrors.Write(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *CategoryHandler) MoveCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	move := &models.CategoryMove{}
+
+	err := decodeValidBody(r, move)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&category)
+	err = c.categoryRepo.MoveCategory(r.Context(), mux.Vars(r)["category_id"], move.ParentID, version)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	if category.Name == "" {
-		http.Error(w, "Category name is required", http.StatusBadRequest)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	category := &models.Category{}
+
+	err := decodeValidBody(r, category)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	err = c.categoryRepo.CreateCategory(*category)
+	err = c.categoryRepo.CreateCategory(r.Context(), *category)
 	if err != nil {
-		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
 		return
 	}
 
@@ -101,15 +217,115 @@ func (c *CategoryHandler) CreateCategoryHandler(w http.ResponseWriter, r *http.R
 }
 
 func (c *CategoryHandler) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	categoryID := mux.Vars(r)["category_id"]
 
-	err := c.categoryRepo.DeleteCategory(categoryID)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = c.categoryRepo.DeleteCategory(r.Context(), categoryID, c.deletePolicy, version)
 	if err != nil {
-		http.Error(w, "Failed to delete category", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (c *CategoryHandler) CreateCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.CategoryBatch
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.Items))
+	validateItems(batch.Items, result)
+
+	err = c.categoryRepo.CreateCategories(r.Context(), batch.Items, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusCreated, result)
+}
+
+func (c *CategoryHandler) UpdateCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.CategoryBatch
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.Items))
+	validateItems(batch.Items, result)
+
+	err = c.categoryRepo.UpdateCategories(r.Context(), batch.Items, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
+
+func (c *CategoryHandler) DeleteCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.BulkDelete
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.IDs))
+
+	err = c.categoryRepo.DeleteCategories(r.Context(), batch.IDs, c.deletePolicy, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
+
+// ExportCategoriesHandler streams every category as a CSV or NDJSON
+// download. Root categories have an empty parent_id.
+func (c *CategoryHandler) ExportCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	export, err := newExportWriter(w, r, "categories", transfer.CategoryColumns)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = c.categoryRepo.ExportCategories(r.Context(), func(category models.CategoryResponse) error {
+		return export.write(category, transfer.CategoryRecord(category))
+	})
+
+	export.finish(r, err)
+}
+
+// ImportCategoriesHandler creates the rows of an uploaded file that have no
+// id and renames the others.
+func (c *CategoryHandler) ImportCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, result, err := readImport(w, r, transfer.CategoryColumns, transfer.CategoryFromRecord)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = c.categoryRepo.ImportCategories(r.Context(), categories, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
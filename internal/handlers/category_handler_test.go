@@ -3,9 +3,9 @@ package handlers
 import (
 	"awesomeProject/internal/handlers/mocks"
 	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
 	"bytes"
 	"encoding/json"
-	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"net/http"
@@ -27,7 +27,7 @@ var _ = Describe("Category Handler", func() {
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockRepo = mocks.NewMockCategorer(mockCtrl)
-		categoryHandler = NewCategoryHandler(mockRepo)
+		categoryHandler = NewCategoryHandler(mockRepo, models.CategoryDeleteReparent)
 		responseRecorder = httptest.NewRecorder()
 	})
 
@@ -44,10 +44,9 @@ var _ = Describe("Category Handler", func() {
 			categoryID := mux.Vars(request)["category_id"]
 
 			mockRepo.EXPECT().
-				GetCategory(categoryID).
+				GetCategory(gomock.Any(), categoryID).
 				Return(&models.CategoryResponse{
 					Name:      "Books",
-					ProductID: 1,
 					CreatedAt: time.Time{},
 					UpdatedAt: time.Time{},
 				}, nil).
@@ -57,6 +56,35 @@ var _ = Describe("Category Handler", func() {
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
+		It("should return the row version as ETag", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/1", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				GetCategory(gomock.Any(), gomock.Any()).
+				Return(&models.CategoryResponse{Name: "Books", Version: 3}, nil).
+				Times(1)
+
+			categoryHandler.GetCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("ETag")).To(Equal(`"3"`))
+		})
+		It("should return 304 when If-None-Match matches", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/1", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", `"2", W/"3"`)
+
+			mockRepo.EXPECT().
+				GetCategory(gomock.Any(), gomock.Any()).
+				Return(&models.CategoryResponse{Name: "Books", Version: 3}, nil).
+				Times(1)
+
+			categoryHandler.GetCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotModified))
+			Expect(responseRecorder.Body.Len()).To(BeZero())
+		})
 		It("should return 404 when no category id provided", func() {
 			request, err := http.NewRequest("GET", "/api/v1/categories", nil)
 			Expect(err).NotTo(HaveOccurred())
@@ -65,8 +93,8 @@ var _ = Describe("Category Handler", func() {
 			categoryID := mux.Vars(request)["category_id"]
 
 			mockRepo.EXPECT().
-				GetCategory(categoryID).
-				Return(nil, errors.New("not found")).
+				GetCategory(gomock.Any(), categoryID).
+				Return(nil, repositories.ErrCategoryNotFound).
 				Times(1)
 
 			categoryHandler.GetCategoryHandler(responseRecorder, request)
@@ -81,8 +109,8 @@ var _ = Describe("Category Handler", func() {
 			categoryID := mux.Vars(request)["category_id"]
 
 			mockRepo.EXPECT().
-				GetCategory(categoryID).
-				Return(nil, errors.New("not found")).
+				GetCategory(gomock.Any(), categoryID).
+				Return(nil, repositories.ErrCategoryNotFound).
 				Times(1)
 
 			categoryHandler.GetCategoryHandler(responseRecorder, request)
@@ -91,11 +119,46 @@ var _ = Describe("Category Handler", func() {
 		})
 	})
 
+	Describe("ListCategoriesHandler", func() {
+		It("should return 200 with the page envelope", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories?created_before=2024-01-02T00:00:00Z", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			createdBefore := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+			mockRepo.EXPECT().
+				ListCategories(gomock.Any(), models.CategoryFilter{
+					ListOptions: models.ListOptions{CreatedBefore: &createdBefore},
+				}).
+				Return(&models.CategoryPage{
+					Data: []models.CategoryResponse{{ID: "1", Name: "Books"}},
+					Meta: models.PageMeta{NextCursor: "next", Total: 2, Limit: 1},
+				}, nil).
+				Times(1)
+
+			categoryHandler.ListCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"next_cursor":"next"`))
+		})
+		It("should return 400 on invalid cursor", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories?cursor=bad", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				ListCategories(gomock.Any(), gomock.Any()).
+				Return(nil, repositories.ErrInvalidCursor)
+
+			categoryHandler.ListCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
 	Describe("UpdateCategoryHandler", func() {
 		It("should return 200", func() {
 			category := models.Category{
 				Name:      "test",
-				ProductID: 1,
 				UpdatedAt: time.Time{},
 			}
 
@@ -106,7 +169,7 @@ var _ = Describe("Category Handler", func() {
 			request.Header.Set("Content-Type", "application/json")
 
 			mockRepo.EXPECT().
-				UpdateCategory(gomock.Eq(category)).
+				UpdateCategory(gomock.Any(), gomock.Eq(category)).
 				Return(nil).
 				Times(1)
 
@@ -114,7 +177,35 @@ var _ = Describe("Category Handler", func() {
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
-		It("should return 200, empty request", func() {
+		It("should pass the If-Match version to the repository", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/1", bytes.NewBufferString(`{"name":"test"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "1"})
+			request.Header.Set("If-Match", `"4"`)
+
+			mockRepo.EXPECT().
+				UpdateCategory(gomock.Any(), models.Category{ID: "1", Name: "test", Version: 4}).
+				Return(repositories.ErrVersionConflict).
+				Times(1)
+
+			categoryHandler.UpdateCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("version_conflict"))
+		})
+		It("should return 412 on a malformed If-Match", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/1", bytes.NewBufferString(`{"name":"test"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-Match", `W/"4"`)
+
+			mockRepo.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Times(0)
+
+			categoryHandler.UpdateCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionFailed))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("invalid_if_match"))
+		})
+		It("should return 422, empty request", func() {
 			category := models.Category{}
 
 			requestBody, err := json.Marshal(category)
@@ -123,21 +214,18 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().
-				UpdateCategory(gomock.Eq(category)).
-				Return(nil).
-				Times(1)
+			mockRepo.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Times(0)
 
 			categoryHandler.UpdateCategoryHandler(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
 		})
 		It("should return 400, nil body", func() {
 			request, err := http.NewRequest("PUT", "/api/v1/categories/1", nil)
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().UpdateCategory(gomock.Any()).Times(0)
+			mockRepo.EXPECT().UpdateCategory(gomock.Any(), gomock.Any()).Times(0)
 
 			categoryHandler.UpdateCategoryHandler(responseRecorder, request)
 
@@ -148,8 +236,7 @@ var _ = Describe("Category Handler", func() {
 	Describe("CreateCategoryHandler", func() {
 		It("should return 200", func() {
 			category := models.Category{
-				Name:      "testBook",
-				ProductID: 1,
+				Name: "testBook",
 			}
 
 			requestBody, err := json.Marshal(category)
@@ -158,7 +245,7 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateCategory(gomock.Any()).Return(nil).Times(1)
+			mockRepo.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 			categoryHandler.CreateCategoryHandler(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
@@ -168,13 +255,13 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateCategory(gomock.Any()).Times(0)
+			mockRepo.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Times(0)
 
 			categoryHandler.CreateCategoryHandler(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
 		})
-		It("should return 400, empty body", func() {
+		It("should return 422, empty body", func() {
 			category := models.Category{}
 
 			requestBody, err := json.Marshal(category)
@@ -183,10 +270,10 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateCategory(gomock.Any()).Times(0)
+			mockRepo.EXPECT().CreateCategory(gomock.Any(), gomock.Any()).Times(0)
 
 			categoryHandler.CreateCategoryHandler(responseRecorder, request)
-			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
 		})
 	})
 
@@ -198,7 +285,7 @@ var _ = Describe("Category Handler", func() {
 
 			categoryID := mux.Vars(request)["category_id"]
 
-			mockRepo.EXPECT().DeleteCategory(categoryID).Return(nil).Times(1)
+			mockRepo.EXPECT().DeleteCategory(gomock.Any(), categoryID, models.CategoryDeleteReparent, int64(0)).Return(nil).Times(1)
 			categoryHandler.DeleteCategoryHandler(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
@@ -207,9 +294,331 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().DeleteCategory(gomock.Any()).Return(errors.New("not found")).Times(1)
+			mockRepo.EXPECT().DeleteCategory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(repositories.ErrCategoryNotFound).Times(1)
+			categoryHandler.DeleteCategoryHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+		It("should return 409 when the category has children", func() {
+			request, err := http.NewRequest("DELETE", "/api/v1/categories/1", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().DeleteCategory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(repositories.ErrCategoryHasChildren).Times(1)
+			categoryHandler.DeleteCategoryHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("category_has_children"))
+		})
+		It("should pass the If-Match version to the repository", func() {
+			request, err := http.NewRequest("DELETE", "/api/v1/categories/1", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "1"})
+			request.Header.Set("If-Match", `"2"`)
+
+			mockRepo.EXPECT().DeleteCategory(gomock.Any(), "1", models.CategoryDeleteReparent, int64(2)).Return(nil).Times(1)
 			categoryHandler.DeleteCategoryHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("GetCategoryTreeHandler", func() {
+		It("should return 200 with the nested categories", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/tree", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				GetCategoryTree(gomock.Any()).
+				Return([]*models.CategoryNode{{
+					CategoryResponse: models.CategoryResponse{ID: "1", Name: "Books"},
+					Children: []*models.CategoryNode{{
+						CategoryResponse: models.CategoryResponse{ID: "2", Name: "Fiction"},
+						Children:         []*models.CategoryNode{},
+					}},
+				}}, nil).
+				Times(1)
+
+			categoryHandler.GetCategoryTreeHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var tree []models.CategoryNode
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &tree)).To(Succeed())
+			Expect(tree).To(HaveLen(1))
+			Expect(tree[0].Children[0].Name).To(Equal("Fiction"))
+		})
+	})
+
+	Describe("GetCategorySubtreeHandler", func() {
+		It("should return 404 when category not found", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/9/tree", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "9"})
+
+			mockRepo.EXPECT().
+				GetCategorySubtree(gomock.Any(), "9").
+				Return(nil, repositories.ErrCategoryNotFound).
+				Times(1)
+
+			categoryHandler.GetCategorySubtreeHandler(responseRecorder, request)
+
 			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
 		})
 	})
+
+	Describe("GetCategoryBreadcrumbHandler", func() {
+		It("should return 200 with the ancestors", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/2/breadcrumb", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "2"})
+
+			mockRepo.EXPECT().
+				GetCategoryBreadcrumb(gomock.Any(), "2").
+				Return([]models.CategoryResponse{{ID: "1", Name: "Books"}, {ID: "2", Name: "Fiction"}}, nil).
+				Times(1)
+
+			categoryHandler.GetCategoryBreadcrumbHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"name":"Fiction"`))
+		})
+	})
+
+	Describe("PatchCategoryHandler", func() {
+		It("should move the category to the root when parent_id is null", func() {
+			parentID := int64(4)
+
+			request, err := http.NewRequest("PATCH", "/api/v1/categories/2", bytes.NewBufferString(`{"parent_id":null}`))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/merge-patch+json")
+			request = mux.SetURLVars(request, map[string]string{"category_id": "2"})
+
+			mockRepo.EXPECT().
+				GetCategory(gomock.Any(), "2").
+				Return(&models.CategoryResponse{Name: "Novels", ParentID: &parentID, Version: 1}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				PatchCategory(gomock.Any(), "2", models.CategoryPatch{
+					Name:    "Novels",
+					Fields:  models.PatchFields{"parent_id": true},
+					Version: 1,
+				}).
+				Return(nil).
+				Times(1)
+
+			categoryHandler.PatchCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 409 when the move would create a cycle", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/categories/2", bytes.NewBufferString(`{"parent_id":5}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "2"})
+
+			mockRepo.EXPECT().
+				GetCategory(gomock.Any(), "2").
+				Return(&models.CategoryResponse{Name: "Novels", Version: 1}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				PatchCategory(gomock.Any(), "2", gomock.Any()).
+				Return(repositories.ErrCategoryCycle).
+				Times(1)
+
+			categoryHandler.PatchCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+		})
+		It("should return 404 when the category does not exist", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/categories/2", bytes.NewBufferString(`{"name":"x"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().GetCategory(gomock.Any(), gomock.Any()).Return(nil, repositories.ErrCategoryNotFound).Times(1)
+			mockRepo.EXPECT().PatchCategory(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			categoryHandler.PatchCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("MoveCategoryHandler", func() {
+		It("should return 200", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/2/parent", bytes.NewBufferString(`{"parent_id": 5}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "2"})
+
+			parentID := int64(5)
+			mockRepo.EXPECT().MoveCategory(gomock.Any(), "2", &parentID, int64(0)).Return(nil).Times(1)
+
+			categoryHandler.MoveCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should move to the root on a null parent", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/2/parent", bytes.NewBufferString(`{"parent_id": null}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "2"})
+
+			mockRepo.EXPECT().MoveCategory(gomock.Any(), "2", gomock.Nil(), int64(0)).Return(nil).Times(1)
+
+			categoryHandler.MoveCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 409 on a cycle", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/2/parent", bytes.NewBufferString(`{"parent_id": 3}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().MoveCategory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(repositories.ErrCategoryCycle).Times(1)
+
+			categoryHandler.MoveCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("category_cycle"))
+		})
+		It("should return 422 on an invalid parent id", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/2/parent", bytes.NewBufferString(`{"parent_id": 0}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().MoveCategory(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			categoryHandler.MoveCategoryHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	Describe("CreateCategoriesHandler", func() {
+		It("should return 201", func() {
+			request, err := http.NewRequest("POST", "/api/v1/categories/bulk", bytes.NewBufferString(`{"items":[{"name":"Books"},{"name":"Fantasy","parent_id":1}]}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				CreateCategories(gomock.Any(), gomock.Len(2), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Category, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			categoryHandler.CreateCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+		})
+		It("should report an invalid parent id by index", func() {
+			request, err := http.NewRequest("POST", "/api/v1/categories/bulk", bytes.NewBufferString(`{"mode":"partial","items":[{"name":"Books"},{"name":"Fantasy","parent_id":0}]}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				CreateCategories(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Category, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			categoryHandler.CreateCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Results[1].Errors).To(HaveKey("parent_id"))
+			Expect(result.Succeeded).To(Equal(1))
+		})
+	})
+
+	Describe("UpdateCategoriesHandler", func() {
+		It("should return 422 when an atomic batch is rolled back", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/categories/bulk", bytes.NewBufferString(`{"items":[{"id":"9","name":"Books"}]}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				UpdateCategories(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Category, result *models.BulkResult) error {
+					result.Fail(0, repositories.ErrCategoryNotFound)
+					result.Finish(false)
+					return nil
+				}).
+				Times(1)
+
+			categoryHandler.UpdateCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Results[0].Code).To(Equal("category_not_found"))
+		})
+	})
+
+	Describe("DeleteCategoriesHandler", func() {
+		It("should delete with the configured policy", func() {
+			request, err := http.NewRequest("POST", "/api/v1/categories/bulk/delete", bytes.NewBufferString(`{"ids":[1,2]}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				DeleteCategories(gomock.Any(), []int64{1, 2}, models.CategoryDeleteReparent, gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []int64, _ models.CategoryDeletePolicy, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			categoryHandler.DeleteCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("ExportCategoriesHandler", func() {
+		It("should leave parent_id empty for root categories", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/export", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			parentID := int64(1)
+			created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+			mockRepo.EXPECT().
+				ExportCategories(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, fn func(models.CategoryResponse) error) error {
+					Expect(fn(models.CategoryResponse{ID: "1", Name: "Books", CreatedAt: created, UpdatedAt: created})).To(Succeed())
+					return fn(models.CategoryResponse{ID: "2", Name: "Fantasy", ParentID: &parentID, CreatedAt: created, UpdatedAt: created})
+				}).
+				Times(1)
+
+			categoryHandler.ExportCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Disposition")).To(Equal("attachment; filename=categories.csv"))
+			Expect(responseRecorder.Body.String()).To(Equal("id,name,parent_id,created_at,updated_at\n" +
+				"1,Books,,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n" +
+				"2,Fantasy,1,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n"))
+		})
+	})
+
+	Describe("ImportCategoriesHandler", func() {
+		It("should read parent ids and fail rows with an invalid one", func() {
+			request, err := http.NewRequest("POST", "/api/v1/categories/import?mode=partial", bytes.NewBufferString("name,parent_id\nBooks,\nFantasy,1\nMusic,abc\n"))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "text/csv")
+
+			parentID := int64(1)
+
+			mockRepo.EXPECT().
+				ImportCategories(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, categories []models.Category, result *models.BulkResult) error {
+					Expect(categories[0]).To(Equal(models.Category{Name: "Books"}))
+					Expect(categories[1]).To(Equal(models.Category{Name: "Fantasy", ParentID: &parentID}))
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			categoryHandler.ImportCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Results[2].Errors).To(HaveKeyWithValue("parent_id", []string{"must be an id"}))
+		})
+	})
 })
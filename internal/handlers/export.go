@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"awesomeProject/internal/transfer"
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exportFlushRows is how many rows are buffered before they are sent.
+const exportFlushRows = 100
+
+// exportWriter streams the rows of an export as a download. Rows are
+// buffered and nothing reaches the client before the first flush, so an
+// error raised until then is still answered with a problem response.
+type exportWriter struct {
+	w    http.ResponseWriter
+	out  *sentWriter
+	file *transfer.Writer
+}
+
+// sentWriter records whether anything was written to the response.
+type sentWriter struct {
+	io.Writer
+	sent bool
+}
+
+func (s *sentWriter) Write(p []byte) (int, error) {
+	s.sent = true
+	return s.Writer.Write(p)
+}
+
+// newExportWriter reads the format query parameter, CSV by default, and
+// sets the download headers for name.
+func newExportWriter(w http.ResponseWriter, r *http.Request, name string, columns transfer.Columns) (*exportWriter, error) {
+	format, err := transfer.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
+	export := &exportWriter{w: w, out: &sentWriter{Writer: w}}
+
+	export.file, err = transfer.NewWriter(export.out, format, columns)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	w.Header().Set("Content-Type", transfer.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+		map[string]string{"filename": name + "." + format}))
+
+	return export, nil
+}
+
+// write adds one row, given both as the value NDJSON encodes and as the
+// record CSV writes.
+func (e *exportWriter) write(value interface{}, record []string) error {
+	err := e.file.Write(value, record)
+	if err != nil {
+		return err
+	}
+
+	if e.file.Rows()%exportFlushRows == 0 {
+		return e.flush()
+	}
+
+	return nil
+}
+
+func (e *exportWriter) flush() error {
+	err := e.file.Flush()
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// finish flushes what is left of the export. Once rows were sent the status
+// cannot change, so a later error only cuts the download short and is
+// logged.
+func (e *exportWriter) finish(r *http.Request, err error) {
+	if err == nil {
+		err = e.flush()
+	}
+
+	if err == nil {
+		return
+	}
+
+	if !e.out.sent {
+		e.w.Header().Del("Content-Disposition")
+		apperrors.Write(e.w, r, err)
+
+		return
+	}
+
+	logrus.WithError(err).WithField("request_url", r.URL.String()).Error("Export aborted")
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+)
+
+type Healther interface {
+	LivenessHandler(w http.ResponseWriter, r *http.Request)
+	ReadinessHandler(w http.ResponseWriter, r *http.Request)
+}
+
+type HealthHandler struct {
+	readiness services.ReadinessChecker
+}
+
+func NewHealthHandler(readiness services.ReadinessChecker) Healther {
+	return &HealthHandler{
+		readiness: readiness,
+	}
+}
+
+// LivenessHandler answers as long as the process serves requests. It checks
+// no dependency, so an outage of the database does not get it restarted.
+func (h *HealthHandler) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	writeJSON(w, http.StatusOK, models.HealthReport{Status: models.HealthOK})
+}
+
+// ReadinessHandler answers 503 with the failing checks while the instance
+// should not get traffic.
+func (h *HealthHandler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := h.readiness.Ready(r.Context())
+
+	status := http.StatusOK
+	if report.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	writeJSON(w, status, report)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"awesomeProject/pkg/database"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeDependencies stands in for the database, the migrator and the job
+// queue. Specs change its fields to make checks fail.
+type fakeDependencies struct {
+	ping    func(ctx context.Context) error
+	pending []database.Migration
+	workers models.WorkerStatus
+}
+
+func (f *fakeDependencies) PingContext(ctx context.Context) error {
+	return f.ping(ctx)
+}
+
+func (f *fakeDependencies) Pending(context.Context) ([]database.Migration, error) {
+	return f.pending, nil
+}
+
+func (f *fakeDependencies) Status() models.WorkerStatus {
+	return f.workers
+}
+
+var _ = Describe("Health Handler", func() {
+	var (
+		dependencies     *fakeDependencies
+		health           *services.Health
+		healthHandler    Healther
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	readiness := func() models.HealthReport {
+		request, err := http.NewRequest("GET", "/readyz", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		healthHandler.ReadinessHandler(responseRecorder, request)
+
+		var report models.HealthReport
+		Expect(json.NewDecoder(responseRecorder.Body).Decode(&report)).To(Succeed())
+
+		return report
+	}
+
+	BeforeEach(func() {
+		dependencies = &fakeDependencies{
+			ping:    func(context.Context) error { return nil },
+			workers: models.WorkerStatus{Configured: 2, Running: 2},
+		}
+		responseRecorder = httptest.NewRecorder()
+
+		health = services.NewHealth(dependencies, dependencies,
+			map[string]services.WorkerPool{"jobs": dependencies}, 50*time.Millisecond)
+		healthHandler = NewHealthHandler(health)
+	})
+
+	Describe("LivenessHandler", func() {
+		It("should return 200 even when the database is down", func() {
+			dependencies.ping = func(context.Context) error { return errors.New("connection refused") }
+
+			request, err := http.NewRequest("GET", "/healthz", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			healthHandler.LivenessHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("ReadinessHandler", func() {
+		It("should return 200 with every check passing", func() {
+			report := readiness()
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(report.Status).To(Equal(models.HealthOK))
+			Expect(report.Checks).To(HaveLen(3))
+			Expect(report.Checks["database"].Status).To(Equal(models.HealthOK))
+			Expect(report.Checks["jobs"].Status).To(Equal(models.HealthOK))
+		})
+		It("should return 503 when the database does not answer in time", func() {
+			dependencies.ping = func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			report := readiness()
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(report.Status).To(Equal(models.HealthUnavailable))
+			Expect(report.Checks["database"].Status).To(Equal(models.HealthFailing))
+			Expect(report.Checks["database"].Error).To(ContainSubstring("deadline exceeded"))
+			Expect(report.Checks["migrations"].Status).To(Equal(models.HealthOK))
+		})
+		It("should return 503 with the pending migrations", func() {
+			dependencies.pending = []database.Migration{{Version: 13, Name: "create_rate_limits"}}
+
+			report := readiness()
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(report.Checks["migrations"].Details).To(Equal(map[string]interface{}{
+				"pending": []interface{}{"0013_create_rate_limits"},
+			}))
+		})
+		It("should return 503 when workers fail to poll", func() {
+			dependencies.workers = models.WorkerStatus{Configured: 2, Running: 2, LastError: "connection reset"}
+
+			report := readiness()
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(report.Checks["jobs"].Error).To(Equal("connection reset"))
+		})
+		It("should return 503 without checking once draining", func() {
+			dependencies.ping = func(context.Context) error {
+				Fail("the database should not be checked")
+				return nil
+			}
+
+			health.Drain()
+			report := readiness()
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(report.Status).To(Equal(models.HealthDraining))
+			Expect(report.Checks).To(BeEmpty())
+		})
+	})
+})
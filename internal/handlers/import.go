@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/transfer"
+	"awesomeProject/pkg/apperrors"
+)
+
+const maxImportBytes = 10 << 20
+
+var (
+	errUnsupportedImport = apperrors.UnsupportedMediaType("unsupported_import_format",
+		"import must be "+transfer.CSVContentType+" or "+transfer.NDJSONContentType)
+	errImportTooLarge = apperrors.PayloadTooLarge("import_too_large",
+		fmt.Sprintf("import must be at most %d bytes", maxImportBytes))
+	errImportFile  = apperrors.BadRequest("missing_file", "multipart import must have a file field")
+	errInvalidMode = invalidParameter("mode must be atomic or partial")
+)
+
+// readImport reads the uploaded file of an import request, as the raw body
+// or as the file field of a multipart form, and returns its items with a
+// result that already records the rows that could not be read or are not
+// valid.
+func readImport[T any](w http.ResponseWriter, r *http.Request, columns transfer.Columns, fromRecord func(map[string]string) (T, error)) ([]T, *models.BulkResult, error) {
+	mode, dryRun, err := importOptions(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.Body == nil {
+		return nil, nil, errBodyMissing
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	source, format, err := importSource(r)
+	if err != nil {
+		return nil, nil, importError(err)
+	}
+
+	rows, err := transfer.Read(source, format, columns, fromRecord)
+	if err != nil {
+		return nil, nil, importError(err)
+	}
+
+	return transfer.Batch(rows, mode, dryRun)
+}
+
+// importOptions reads the mode and dry_run query parameters.
+func importOptions(r *http.Request) (models.BulkMode, bool, error) {
+	query := r.URL.Query()
+
+	mode := models.BulkMode(query.Get("mode"))
+	if mode != "" && mode != models.BulkAtomic && mode != models.BulkPartial {
+		return "", false, errInvalidMode
+	}
+
+	value := query.Get("dry_run")
+	if value == "" {
+		return mode, false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", false, invalidParameter("dry_run must be a boolean")
+	}
+
+	return mode, dryRun, nil
+}
+
+// importSource returns the file to import and its format. The format query
+// parameter wins over the file name and the content type.
+func importSource(r *http.Request) (io.Reader, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var (
+		source   io.Reader = r.Body
+		filename string
+	)
+
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, "", errImportFile
+		}
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		source = file
+		filename = header.Filename
+		mediaType, _, _ = mime.ParseMediaType(header.Header.Get("Content-Type"))
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" {
+		format, err := transfer.ParseFormat(format)
+		return source, format, err
+	}
+
+	switch {
+	case mediaType == transfer.CSVContentType || strings.EqualFold(filepath.Ext(filename), ".csv"):
+		return source, transfer.FormatCSV, nil
+	case mediaType == transfer.NDJSONContentType || strings.EqualFold(filepath.Ext(filename), ".ndjson"):
+		return source, transfer.FormatNDJSON, nil
+	default:
+		return nil, "", errUnsupportedImport
+	}
+}
+
+// importError maps the errors of reading the request to problems. Errors
+// that are already problems are kept.
+func importError(err error) error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errImportTooLarge.Wrap(err)
+	}
+
+	return apperrors.BadRequest(apperrors.CodeInvalidBody, "failed to read import").Wrap(err)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/internal/services"
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/gorilla/mux"
+)
+
+type Jober interface {
+	CreateJobHandler(w http.ResponseWriter, r *http.Request)
+	GetJobHandler(w http.ResponseWriter, r *http.Request)
+	GetJobFileHandler(w http.ResponseWriter, r *http.Request)
+}
+
+var errNoJobFile = apperrors.NotFound("job_file_not_found", "job has no file to download")
+
+type JobHandler struct {
+	jobs      repositories.JobRepository
+	scheduler services.JobScheduler
+	files     *services.JobFiles
+}
+
+func NewJobHandler(jobs repositories.JobRepository, scheduler services.JobScheduler, files *services.JobFiles) Jober {
+	return &JobHandler{
+		jobs:      jobs,
+		scheduler: scheduler,
+		files:     files,
+	}
+}
+
+// CreateJobHandler queues a job and answers 202 with the job and its
+// Location to poll.
+func (j *JobHandler) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	// Import jobs carry their file inline, so the body is limited like an
+	// upload to the import endpoints.
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	}
+
+	var request models.JobRequest
+
+	err := decodeValidBody(r, &request)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = errImportTooLarge.Wrap(err)
+		}
+
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	job, err := j.scheduler.Enqueue(r.Context(), principal.UserID, principal.Role, request)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetJobHandler returns a job of the authenticated user with its progress
+// and, once finished, its result or error.
+func (j *JobHandler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	job, err := j.jobs.GetJob(r.Context(), mux.Vars(r)["job_id"], principal.UserID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// GetJobFileHandler downloads the file of a succeeded export job of the
+// authenticated user.
+func (j *JobHandler) GetJobFileHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	job, err := j.jobs.GetJob(r.Context(), mux.Vars(r)["job_id"], principal.UserID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	var export models.ExportJobResult
+	if job.Status != models.JobSucceeded || json.Unmarshal(job.Result, &export) != nil || export.File == "" {
+		apperrors.Write(w, r, errNoJobFile)
+		return
+	}
+
+	file, err := j.files.Open(export.File)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		apperrors.Write(w, r, apperrors.Internal(err))
+		return
+	}
+
+	name := strings.TrimSuffix(job.Kind, ".export") + "." + export.Format
+
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/middlewares/authorization"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/internal/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Job Handler", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockRepo         *mocks.MockJobRepository
+		mockPermissions  *mocks.MockPermissionChecker
+		files            *services.JobFiles
+		jobHandler       Jober
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	authenticated := func(request *http.Request) *http.Request {
+		return request.WithContext(authentication.WithPrincipal(request.Context(),
+			&authentication.Principal{UserID: "1", Username: "test", Role: "user"}))
+	}
+
+	newRequest := func(body string) *http.Request {
+		request, err := http.NewRequest("POST", "/api/v1/jobs", bytes.NewBufferString(body))
+		Expect(err).NotTo(HaveOccurred())
+
+		return authenticated(request)
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockJobRepository(mockCtrl)
+		mockPermissions = mocks.NewMockPermissionChecker(mockCtrl)
+
+		queue := services.NewJobQueue(mockRepo, mockPermissions, configs.DefaultConfig().Jobs)
+		files = services.NewJobFiles(GinkgoT().TempDir())
+		services.RegisterCatalogJobs(queue, mocks.NewMockProductRepository(mockCtrl), mocks.NewMockCategorer(mockCtrl), files)
+
+		jobHandler = NewJobHandler(mockRepo, queue, files)
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("CreateJobHandler", func() {
+		It("should queue the job and return 202 with its location", func() {
+			mockPermissions.EXPECT().
+				HasPermission(gomock.Any(), "user", authorization.ProductsWrite).
+				Return(true, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				CreateJob(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, job *models.Job) error {
+					Expect(job.Kind).To(Equal(services.JobImportProducts))
+					Expect(job.CreatedBy).To(Equal("1"))
+					Expect(job.MaxAttempts).To(Equal(5))
+
+					var payload models.ImportJobPayload
+					Expect(json.Unmarshal(job.Payload, &payload)).To(Succeed())
+					Expect(payload.Data).To(BeEmpty())
+
+					file, err := files.Open(payload.File)
+					Expect(err).NotTo(HaveOccurred())
+					defer file.Close()
+
+					data, err := io.ReadAll(file)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(data)).To(Equal("name,category_ids\nbook,\n"))
+
+					job.ID = "7"
+					job.Status = models.JobQueued
+					return nil
+				}).
+				Times(1)
+
+			jobHandler.CreateJobHandler(responseRecorder,
+				newRequest(`{"kind":"products.import","payload":{"data":"name,category_ids\nbook,\n"}}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+			Expect(responseRecorder.Header().Get("Location")).To(Equal("/api/v1/jobs/7"))
+
+			var job models.Job
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&job)).To(Succeed())
+			Expect(job.Status).To(Equal(models.JobQueued))
+		})
+		It("should not require a permission for an export", func() {
+			mockRepo.EXPECT().CreateJob(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+			jobHandler.CreateJobHandler(responseRecorder, newRequest(`{"kind":"categories.export"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+		})
+		It("should return 422 for an unknown kind", func() {
+			jobHandler.CreateJobHandler(responseRecorder, newRequest(`{"kind":"users.export"}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("kind"))
+		})
+		It("should return 403 without the permission of the kind", func() {
+			mockPermissions.EXPECT().
+				HasPermission(gomock.Any(), "user", authorization.CategoriesWrite).
+				Return(false, nil).
+				Times(1)
+
+			jobHandler.CreateJobHandler(responseRecorder,
+				newRequest(`{"kind":"categories.import","payload":{"data":"name\nbooks\n"}}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+		})
+		It("should return 422 for an invalid payload", func() {
+			jobHandler.CreateJobHandler(responseRecorder,
+				newRequest(`{"kind":"products.export","payload":{"format":"xml"}}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("format"))
+		})
+		It("should return 422 for an import without data", func() {
+			mockPermissions.EXPECT().
+				HasPermission(gomock.Any(), "user", authorization.ProductsWrite).
+				Return(true, nil).
+				Times(1)
+
+			jobHandler.CreateJobHandler(responseRecorder,
+				newRequest(`{"kind":"products.import","payload":{"file":"0b7a4d8e-54a5-4c1e-9a4f-1f0c6a1f3e2d"}}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("data"))
+		})
+		It("should return 413 for a payload over the import limit", func() {
+			data := strings.Repeat("a", maxImportBytes)
+
+			jobHandler.CreateJobHandler(responseRecorder,
+				newRequest(`{"kind":"products.import","payload":{"data":"`+data+`"}}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("import_too_large"))
+		})
+		It("should return 401 without a principal", func() {
+			request, err := http.NewRequest("POST", "/api/v1/jobs", bytes.NewBufferString(`{"kind":"products.export"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			jobHandler.CreateJobHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("GetJobHandler", func() {
+		It("should return 200 with the job", func() {
+			request, err := http.NewRequest("GET", "/api/v1/jobs/7", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(authenticated(request), map[string]string{"job_id": "7"})
+
+			mockRepo.EXPECT().
+				GetJob(gomock.Any(), "7", "1").
+				Return(&models.Job{ID: "7", Status: models.JobRunning, Progress: 50}, nil).
+				Times(1)
+
+			jobHandler.GetJobHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var job models.Job
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&job)).To(Succeed())
+			Expect(job.Progress).To(Equal(50))
+		})
+		It("should return 404 for a job of another user", func() {
+			request, err := http.NewRequest("GET", "/api/v1/jobs/8", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(authenticated(request), map[string]string{"job_id": "8"})
+
+			mockRepo.EXPECT().GetJob(gomock.Any(), "8", "1").Return(nil, repositories.ErrJobNotFound).Times(1)
+
+			jobHandler.GetJobHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("GetJobFileHandler", func() {
+		newFileRequest := func(jobID string) *http.Request {
+			request, err := http.NewRequest("GET", "/api/v1/jobs/"+jobID+"/file", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			return mux.SetURLVars(authenticated(request), map[string]string{"job_id": jobID})
+		}
+
+		exportResult := func(file string) json.RawMessage {
+			result, err := json.Marshal(models.ExportJobResult{
+				Format:      "csv",
+				ContentType: "text/csv; charset=utf-8",
+				Rows:        1,
+				Size:        15,
+				File:        file,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			return result
+		}
+
+		It("should download the file of a succeeded export", func() {
+			name, size, err := files.Write(func(w io.Writer) error {
+				_, err := io.WriteString(w, "id,name\n1,book\n")
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(15)))
+
+			mockRepo.EXPECT().
+				GetJob(gomock.Any(), "7", "1").
+				Return(&models.Job{ID: "7", Kind: services.JobExportProducts, Status: models.JobSucceeded, Result: exportResult(name)}, nil).
+				Times(1)
+
+			jobHandler.GetJobFileHandler(responseRecorder, newFileRequest("7"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("text/csv; charset=utf-8"))
+			Expect(responseRecorder.Header().Get("Content-Disposition")).To(Equal("attachment; filename=products.csv"))
+			Expect(responseRecorder.Body.String()).To(Equal("id,name\n1,book\n"))
+		})
+		It("should return 404 for a job that is not done", func() {
+			mockRepo.EXPECT().
+				GetJob(gomock.Any(), "7", "1").
+				Return(&models.Job{ID: "7", Kind: services.JobExportProducts, Status: models.JobRunning}, nil).
+				Times(1)
+
+			jobHandler.GetJobFileHandler(responseRecorder, newFileRequest("7"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("job_file_not_found"))
+		})
+		It("should return 404 once the file was swept", func() {
+			name, _, err := files.Write(func(w io.Writer) error {
+				_, err := io.WriteString(w, "id,name\n1,book\n")
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deleted, err := files.Sweep(time.Now().Add(time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal(1))
+
+			mockRepo.EXPECT().
+				GetJob(gomock.Any(), "7", "1").
+				Return(&models.Job{ID: "7", Kind: services.JobExportProducts, Status: models.JobSucceeded, Result: exportResult(name)}, nil).
+				Times(1)
+
+			jobHandler.GetJobFileHandler(responseRecorder, newFileRequest("7"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("job_file_expired"))
+		})
+		It("should return 404 for a file outside the job files", func() {
+			mockRepo.EXPECT().
+				GetJob(gomock.Any(), "7", "1").
+				Return(&models.Job{ID: "7", Kind: services.JobExportProducts, Status: models.JobSucceeded, Result: exportResult("../../etc/passwd")}, nil).
+				Times(1)
+
+			jobHandler.GetJobFileHandler(responseRecorder, newFileRequest("7"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("job_file_expired"))
+		})
+	})
+})
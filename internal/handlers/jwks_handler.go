@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"awesomeProject/pkg/utils"
+)
+
+type JWKSer interface {
+	GetJWKS(w http.ResponseWriter, r *http.Request)
+}
+
+type JWKSHandler struct {
+	keys *utils.KeyManager
+}
+
+func NewJWKSHandler(keys *utils.KeyManager) JWKSer {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+func (j *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	writeJSON(w, http.StatusOK, j.keys.JWKS())
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+)
+
+// parseListOptions reads limit, cursor, sort, created_after and
+// created_before from the query string. Sort columns are validated by the
+// repository, which owns the whitelist.
+func parseListOptions(query url.Values) (models.ListOptions, error) {
+	options := models.ListOptions{
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > repositories.MaxPageLimit {
+			return options, invalidParameter(fmt.Sprintf("limit must be between 1 and %d", repositories.MaxPageLimit))
+		}
+		options.Limit = value
+	}
+
+	var err error
+
+	options.CreatedAfter, err = parseTimeParam(query, "created_after")
+	if err != nil {
+		return options, err
+	}
+
+	options.CreatedBefore, err = parseTimeParam(query, "created_before")
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, invalidParameter(name + " must be an RFC 3339 timestamp")
+	}
+
+	return &parsed, nil
+}
+
+func parseIntParam(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, invalidParameter(name + " must be a positive integer")
+	}
+
+	return parsed, nil
+}
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	models "awesomeProject/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,59 +35,217 @@ func (m *MockCategorer) EXPECT() *MockCategorerMockRecorder {
 	return m.recorder
 }
 
+// CreateCategories mocks base method.
+func (m *MockCategorer) CreateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategories", ctx, categories, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCategories indicates an expected call of CreateCategories.
+func (mr *MockCategorerMockRecorder) CreateCategories(ctx, categories, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategories", reflect.TypeOf((*MockCategorer)(nil).CreateCategories), ctx, categories, result)
+}
+
 // CreateCategory mocks base method.
-func (m *MockCategorer) CreateCategory(category models.Category) error {
+func (m *MockCategorer) CreateCategory(ctx context.Context, category models.Category) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateCategory", category)
+	ret := m.ctrl.Call(m, "CreateCategory", ctx, category)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateCategory indicates an expected call of CreateCategory.
-func (mr *MockCategorerMockRecorder) CreateCategory(category interface{}) *gomock.Call {
+func (mr *MockCategorerMockRecorder) CreateCategory(ctx, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockCategorer)(nil).CreateCategory), ctx, category)
+}
+
+// DeleteCategories mocks base method.
+func (m *MockCategorer) DeleteCategories(ctx context.Context, ids []int64, policy models.CategoryDeletePolicy, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategories", ctx, ids, policy, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategories indicates an expected call of DeleteCategories.
+func (mr *MockCategorerMockRecorder) DeleteCategories(ctx, ids, policy, result interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockCategorer)(nil).CreateCategory), category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategories", reflect.TypeOf((*MockCategorer)(nil).DeleteCategories), ctx, ids, policy, result)
 }
 
 // DeleteCategory mocks base method.
-func (m *MockCategorer) DeleteCategory(categoryID string) error {
+func (m *MockCategorer) DeleteCategory(ctx context.Context, categoryID string, policy models.CategoryDeletePolicy, version int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCategory", categoryID)
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, categoryID, policy, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteCategory indicates an expected call of DeleteCategory.
-func (mr *MockCategorerMockRecorder) DeleteCategory(categoryID interface{}) *gomock.Call {
+func (mr *MockCategorerMockRecorder) DeleteCategory(ctx, categoryID, policy, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCategorer)(nil).DeleteCategory), ctx, categoryID, policy, version)
+}
+
+// ExportCategories mocks base method.
+func (m *MockCategorer) ExportCategories(ctx context.Context, fn func(models.CategoryResponse) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportCategories", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportCategories indicates an expected call of ExportCategories.
+func (mr *MockCategorerMockRecorder) ExportCategories(ctx, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCategorer)(nil).DeleteCategory), categoryID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportCategories", reflect.TypeOf((*MockCategorer)(nil).ExportCategories), ctx, fn)
 }
 
 // GetCategory mocks base method.
-func (m *MockCategorer) GetCategory(categoryID string) (*models.CategoryResponse, error) {
+func (m *MockCategorer) GetCategory(ctx context.Context, categoryID string) (*models.CategoryResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCategory", categoryID)
+	ret := m.ctrl.Call(m, "GetCategory", ctx, categoryID)
 	ret0, _ := ret[0].(*models.CategoryResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCategory indicates an expected call of GetCategory.
-func (mr *MockCategorerMockRecorder) GetCategory(categoryID interface{}) *gomock.Call {
+func (mr *MockCategorerMockRecorder) GetCategory(ctx, categoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockCategorer)(nil).GetCategory), ctx, categoryID)
+}
+
+// GetCategoryBreadcrumb mocks base method.
+func (m *MockCategorer) GetCategoryBreadcrumb(ctx context.Context, categoryID string) ([]models.CategoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryBreadcrumb", ctx, categoryID)
+	ret0, _ := ret[0].([]models.CategoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryBreadcrumb indicates an expected call of GetCategoryBreadcrumb.
+func (mr *MockCategorerMockRecorder) GetCategoryBreadcrumb(ctx, categoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryBreadcrumb", reflect.TypeOf((*MockCategorer)(nil).GetCategoryBreadcrumb), ctx, categoryID)
+}
+
+// GetCategorySubtree mocks base method.
+func (m *MockCategorer) GetCategorySubtree(ctx context.Context, categoryID string) (*models.CategoryNode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategorySubtree", ctx, categoryID)
+	ret0, _ := ret[0].(*models.CategoryNode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategorySubtree indicates an expected call of GetCategorySubtree.
+func (mr *MockCategorerMockRecorder) GetCategorySubtree(ctx, categoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategorySubtree", reflect.TypeOf((*MockCategorer)(nil).GetCategorySubtree), ctx, categoryID)
+}
+
+// GetCategoryTree mocks base method.
+func (m *MockCategorer) GetCategoryTree(ctx context.Context) ([]*models.CategoryNode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryTree", ctx)
+	ret0, _ := ret[0].([]*models.CategoryNode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryTree indicates an expected call of GetCategoryTree.
+func (mr *MockCategorerMockRecorder) GetCategoryTree(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryTree", reflect.TypeOf((*MockCategorer)(nil).GetCategoryTree), ctx)
+}
+
+// ImportCategories mocks base method.
+func (m *MockCategorer) ImportCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCategories", ctx, categories, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportCategories indicates an expected call of ImportCategories.
+func (mr *MockCategorerMockRecorder) ImportCategories(ctx, categories, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCategories", reflect.TypeOf((*MockCategorer)(nil).ImportCategories), ctx, categories, result)
+}
+
+// ListCategories mocks base method.
+func (m *MockCategorer) ListCategories(ctx context.Context, filter models.CategoryFilter) (*models.CategoryPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategories", ctx, filter)
+	ret0, _ := ret[0].(*models.CategoryPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategories indicates an expected call of ListCategories.
+func (mr *MockCategorerMockRecorder) ListCategories(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategories", reflect.TypeOf((*MockCategorer)(nil).ListCategories), ctx, filter)
+}
+
+// MoveCategory mocks base method.
+func (m *MockCategorer) MoveCategory(ctx context.Context, categoryID string, parentID *int64, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveCategory", ctx, categoryID, parentID, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveCategory indicates an expected call of MoveCategory.
+func (mr *MockCategorerMockRecorder) MoveCategory(ctx, categoryID, parentID, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveCategory", reflect.TypeOf((*MockCategorer)(nil).MoveCategory), ctx, categoryID, parentID, version)
+}
+
+// PatchCategory mocks base method.
+func (m *MockCategorer) PatchCategory(ctx context.Context, categoryID string, patch models.CategoryPatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchCategory", ctx, categoryID, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchCategory indicates an expected call of PatchCategory.
+func (mr *MockCategorerMockRecorder) PatchCategory(ctx, categoryID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchCategory", reflect.TypeOf((*MockCategorer)(nil).PatchCategory), ctx, categoryID, patch)
+}
+
+// UpdateCategories mocks base method.
+func (m *MockCategorer) UpdateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategories", ctx, categories, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategories indicates an expected call of UpdateCategories.
+func (mr *MockCategorerMockRecorder) UpdateCategories(ctx, categories, result interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockCategorer)(nil).GetCategory), categoryID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategories", reflect.TypeOf((*MockCategorer)(nil).UpdateCategories), ctx, categories, result)
 }
 
 // UpdateCategory mocks base method.
-func (m *MockCategorer) UpdateCategory(category models.Category) error {
+func (m *MockCategorer) UpdateCategory(ctx context.Context, category models.Category) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateCategory", category)
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, category)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateCategory indicates an expected call of UpdateCategory.
-func (mr *MockCategorerMockRecorder) UpdateCategory(category interface{}) *gomock.Call {
+func (mr *MockCategorerMockRecorder) UpdateCategory(ctx, category interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockCategorer)(nil).UpdateCategory), category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockCategorer)(nil).UpdateCategory), ctx, category)
 }
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	models "awesomeProject/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,58 +36,201 @@ func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
 }
 
 // CreateProduct mocks base method.
-func (m *MockProductRepository) CreateProduct(product *models.Product) error {
+func (m *MockProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateProduct", product)
+	ret := m.ctrl.Call(m, "CreateProduct", ctx, product)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateProduct indicates an expected call of CreateProduct.
-func (mr *MockProductRepositoryMockRecorder) CreateProduct(product interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) CreateProduct(ctx, product interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockProductRepository)(nil).CreateProduct), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockProductRepository)(nil).CreateProduct), ctx, product)
+}
+
+// CreateProducts mocks base method.
+func (m *MockProductRepository) CreateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProducts", ctx, products, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateProducts indicates an expected call of CreateProducts.
+func (mr *MockProductRepositoryMockRecorder) CreateProducts(ctx, products, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProducts", reflect.TypeOf((*MockProductRepository)(nil).CreateProducts), ctx, products, result)
 }
 
 // DeleteProduct mocks base method.
-func (m *MockProductRepository) DeleteProduct(id string) error {
+func (m *MockProductRepository) DeleteProduct(ctx context.Context, id string, version int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProduct", id)
+	ret := m.ctrl.Call(m, "DeleteProduct", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteProduct indicates an expected call of DeleteProduct.
-func (mr *MockProductRepositoryMockRecorder) DeleteProduct(id interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) DeleteProduct(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockProductRepository)(nil).DeleteProduct), ctx, id, version)
+}
+
+// DeleteProducts mocks base method.
+func (m *MockProductRepository) DeleteProducts(ctx context.Context, ids []int64, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProducts", ctx, ids, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProducts indicates an expected call of DeleteProducts.
+func (mr *MockProductRepositoryMockRecorder) DeleteProducts(ctx, ids, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProducts", reflect.TypeOf((*MockProductRepository)(nil).DeleteProducts), ctx, ids, result)
+}
+
+// ExportProducts mocks base method.
+func (m *MockProductRepository) ExportProducts(ctx context.Context, fn func(models.ProductResponse) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportProducts", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportProducts indicates an expected call of ExportProducts.
+func (mr *MockProductRepositoryMockRecorder) ExportProducts(ctx, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockProductRepository)(nil).DeleteProduct), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportProducts", reflect.TypeOf((*MockProductRepository)(nil).ExportProducts), ctx, fn)
 }
 
 // GetProduct mocks base method.
-func (m *MockProductRepository) GetProduct(productID string) (*models.ProductResponse, error) {
+func (m *MockProductRepository) GetProduct(ctx context.Context, productID string) (*models.ProductResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProduct", productID)
+	ret := m.ctrl.Call(m, "GetProduct", ctx, productID)
 	ret0, _ := ret[0].(*models.ProductResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProduct indicates an expected call of GetProduct.
-func (mr *MockProductRepositoryMockRecorder) GetProduct(productID interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) GetProduct(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductRepository)(nil).GetProduct), ctx, productID)
+}
+
+// ImportProducts mocks base method.
+func (m *MockProductRepository) ImportProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportProducts", ctx, products, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportProducts indicates an expected call of ImportProducts.
+func (mr *MockProductRepositoryMockRecorder) ImportProducts(ctx, products, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportProducts", reflect.TypeOf((*MockProductRepository)(nil).ImportProducts), ctx, products, result)
+}
+
+// ListCategoryProducts mocks base method.
+func (m *MockProductRepository) ListCategoryProducts(ctx context.Context, categoryID string, options models.ListOptions) (*models.ProductPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategoryProducts", ctx, categoryID, options)
+	ret0, _ := ret[0].(*models.ProductPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategoryProducts indicates an expected call of ListCategoryProducts.
+func (mr *MockProductRepositoryMockRecorder) ListCategoryProducts(ctx, categoryID, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductRepository)(nil).GetProduct), productID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategoryProducts", reflect.TypeOf((*MockProductRepository)(nil).ListCategoryProducts), ctx, categoryID, options)
+}
+
+// ListProducts mocks base method.
+func (m *MockProductRepository) ListProducts(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx, filter)
+	ret0, _ := ret[0].(*models.ProductPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockProductRepositoryMockRecorder) ListProducts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockProductRepository)(nil).ListProducts), ctx, filter)
+}
+
+// PatchProduct mocks base method.
+func (m *MockProductRepository) PatchProduct(ctx context.Context, productID string, patch models.ProductPatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchProduct", ctx, productID, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchProduct indicates an expected call of PatchProduct.
+func (mr *MockProductRepositoryMockRecorder) PatchProduct(ctx, productID, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchProduct", reflect.TypeOf((*MockProductRepository)(nil).PatchProduct), ctx, productID, patch)
+}
+
+// SearchProducts mocks base method.
+func (m *MockProductRepository) SearchProducts(ctx context.Context, query string, options models.ListOptions) (*models.ProductSearchPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchProducts", ctx, query, options)
+	ret0, _ := ret[0].(*models.ProductSearchPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchProducts indicates an expected call of SearchProducts.
+func (mr *MockProductRepositoryMockRecorder) SearchProducts(ctx, query, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchProducts", reflect.TypeOf((*MockProductRepository)(nil).SearchProducts), ctx, query, options)
+}
+
+// SetProductCategories mocks base method.
+func (m *MockProductRepository) SetProductCategories(ctx context.Context, productID string, categoryIDs []int64, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductCategories", ctx, productID, categoryIDs, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductCategories indicates an expected call of SetProductCategories.
+func (mr *MockProductRepositoryMockRecorder) SetProductCategories(ctx, productID, categoryIDs, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductCategories", reflect.TypeOf((*MockProductRepository)(nil).SetProductCategories), ctx, productID, categoryIDs, version)
 }
 
 // UpdateProduct mocks base method.
-func (m *MockProductRepository) UpdateProduct(product *models.Product) error {
+func (m *MockProductRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProduct", product)
+	ret := m.ctrl.Call(m, "UpdateProduct", ctx, product)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateProduct indicates an expected call of UpdateProduct.
-func (mr *MockProductRepositoryMockRecorder) UpdateProduct(product interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) UpdateProduct(ctx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductRepository)(nil).UpdateProduct), ctx, product)
+}
+
+// UpdateProducts mocks base method.
+func (m *MockProductRepository) UpdateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProducts", ctx, products, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProducts indicates an expected call of UpdateProducts.
+func (mr *MockProductRepositoryMockRecorder) UpdateProducts(ctx, products, result interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductRepository)(nil).UpdateProduct), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProducts", reflect.TypeOf((*MockProductRepository)(nil).UpdateProducts), ctx, products, result)
 }
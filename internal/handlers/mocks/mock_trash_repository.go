@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../repositories/trash_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "awesomeProject/internal/models"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTrashRepository is a mock of TrashRepository interface.
+type MockTrashRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrashRepositoryMockRecorder
+}
+
+// MockTrashRepositoryMockRecorder is the mock recorder for MockTrashRepository.
+type MockTrashRepositoryMockRecorder struct {
+	mock *MockTrashRepository
+}
+
+// NewMockTrashRepository creates a new mock instance.
+func NewMockTrashRepository(ctrl *gomock.Controller) *MockTrashRepository {
+	mock := &MockTrashRepository{ctrl: ctrl}
+	mock.recorder = &MockTrashRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrashRepository) EXPECT() *MockTrashRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListTrash mocks base method.
+func (m *MockTrashRepository) ListTrash(ctx context.Context, kind models.TrashKind, options models.ListOptions) (*models.TrashPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", ctx, kind, options)
+	ret0, _ := ret[0].(*models.TrashPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockTrashRepositoryMockRecorder) ListTrash(ctx, kind, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockTrashRepository)(nil).ListTrash), ctx, kind, options)
+}
+
+// PurgeExpired mocks base method.
+func (m *MockTrashRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpired", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpired indicates an expected call of PurgeExpired.
+func (mr *MockTrashRepositoryMockRecorder) PurgeExpired(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpired", reflect.TypeOf((*MockTrashRepository)(nil).PurgeExpired), ctx, before)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockTrashRepository) PurgeTrash(ctx context.Context, kind models.TrashKind, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, kind, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockTrashRepositoryMockRecorder) PurgeTrash(ctx, kind, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockTrashRepository)(nil).PurgeTrash), ctx, kind, id)
+}
+
+// RestoreTrash mocks base method.
+func (m *MockTrashRepository) RestoreTrash(ctx context.Context, kind models.TrashKind, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrash", ctx, kind, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTrash indicates an expected call of RestoreTrash.
+func (mr *MockTrashRepositoryMockRecorder) RestoreTrash(ctx, kind, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrash", reflect.TypeOf((*MockTrashRepository)(nil).RestoreTrash), ctx, kind, id)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/jsonpatch"
+	"awesomeProject/pkg/validation"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	errUnsupportedPatch = apperrors.UnsupportedMediaType("unsupported_patch_format",
+		"PATCH body must be "+mergePatchContentType+" or "+jsonPatchContentType)
+	errPatchTestFailed = apperrors.Conflict("patch_test_failed", "a JSON Patch test operation did not match")
+	errPatchNotObject  = apperrors.BadRequest("invalid_patch", "patched document must be a JSON object")
+)
+
+// applyPatch applies the request body to current, the editable fields of a
+// resource, and decodes the result into target, a pointer to a zero value of
+// the same type. The body is a JSON Merge Patch, or a JSON Patch when sent as
+// application/json-patch+json. Fields current does not have are rejected.
+// It returns the fields whose values the patch changed.
+func applyPatch(w http.ResponseWriter, r *http.Request, current, target interface{}) (models.PatchFields, error) {
+	w.Header().Set("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+
+	apply, err := patchFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Body == nil {
+		return nil, errBodyMissing
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apperrors.BadRequest(apperrors.CodeInvalidBody, "failed to read request body").Wrap(err)
+	}
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	patched, err := apply(original, patch)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, errPatchTestFailed.Wrap(err)
+		}
+
+		return nil, apperrors.BadRequest("invalid_patch", err.Error())
+	}
+
+	before, err := fieldValues(original)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	err = rejectUnknownFields(patched, before)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(patched, target)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, apperrors.Validation(validation.CodeValidationFailed, "request has invalid fields",
+				map[string][]string{typeErr.Field: {"has the wrong type"}})
+		}
+
+		return nil, apperrors.BadRequest(apperrors.CodeInvalidBody, "patched document is not valid").Wrap(err)
+	}
+
+	err = validation.Struct(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare the re-encoded target rather than the patched bytes, so that
+	// spelling a value differently does not count as a change.
+	encoded, err := json.Marshal(target)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	after, err := fieldValues(encoded)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	changed := make(models.PatchFields)
+
+	for name, value := range before {
+		if !bytes.Equal(value, after[name]) {
+			changed[name] = true
+		}
+	}
+
+	return changed, nil
+}
+
+// patchFormat picks the patch algorithm from the Content-Type. A missing or
+// plain JSON content type is read as a merge patch.
+func patchFormat(r *http.Request) (func(doc, patch []byte) ([]byte, error), error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return jsonpatch.MergePatch, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errUnsupportedPatch
+	}
+
+	switch mediaType {
+	case mergePatchContentType, "application/json":
+		return jsonpatch.MergePatch, nil
+	case jsonPatchContentType:
+		return jsonpatch.Apply, nil
+	default:
+		return nil, errUnsupportedPatch
+	}
+}
+
+func rejectUnknownFields(patched []byte, known map[string]json.RawMessage) error {
+	values, err := fieldValues(patched)
+	if err != nil {
+		return errPatchNotObject
+	}
+
+	fields := make(map[string][]string)
+
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			fields[name] = []string{"is not a known field"}
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(validation.CodeValidationFailed, "request has unknown fields", fields)
+	}
+
+	return nil
+}
+
+func fieldValues(document []byte) (map[string]json.RawMessage, error) {
+	var values map[string]json.RawMessage
+
+	err := json.Unmarshal(document, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	if values == nil {
+		return nil, errors.New("document is null")
+	}
+
+	return values, nil
+}
+
+// patchVersion returns the row version a PATCH must be written against. The
+// patch was applied to the version just read, so the write must not land on
+// a newer one even when the request has no If-Match.
+func patchVersion(ifMatch, current int64) (int64, error) {
+	if ifMatch != 0 && ifMatch != current {
+		return 0, repositories.ErrVersionConflict
+	}
+
+	return current, nil
+}
@@ -1,18 +1,32 @@
 package handlers
 
 import (
+	"net/http"
+	"strings"
+
 	"awesomeProject/internal/models"
 	"awesomeProject/internal/repositories"
-	"encoding/json"
+	"awesomeProject/internal/transfer"
+	"awesomeProject/pkg/apperrors"
+
 	"github.com/gorilla/mux"
-	"net/http"
 )
 
 type Producter interface {
 	GetProductHandler(w http.ResponseWriter, req *http.Request)
+	ListProductsHandler(w http.ResponseWriter, req *http.Request)
+	SearchProductsHandler(w http.ResponseWriter, req *http.Request)
+	ListCategoryProductsHandler(w http.ResponseWriter, req *http.Request)
 	UpdateProductHandler(w http.ResponseWriter, req *http.Request)
+	PatchProductHandler(w http.ResponseWriter, req *http.Request)
+	SetProductCategoriesHandler(w http.ResponseWriter, req *http.Request)
 	CreateProductHandler(w http.ResponseWriter, req *http.Request)
 	DeleteProductHandler(w http.ResponseWriter, req *http.Request)
+	CreateProductsHandler(w http.ResponseWriter, req *http.Request)
+	UpdateProductsHandler(w http.ResponseWriter, req *http.Request)
+	DeleteProductsHandler(w http.ResponseWriter, req *http.Request)
+	ExportProductsHandler(w http.ResponseWriter, req *http.Request)
+	ImportProductsHandler(w http.ResponseWriter, req *http.Request)
 }
 
 type ProductHandler struct {
@@ -26,77 +40,191 @@ func NewProductHandler(product repositories.ProductRepository) Producter {
 }
 
 func (p *ProductHandler) GetProductHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	productID := mux.Vars(r)["product_id"]
 
-	product, err := p.product.GetProduct(productID)
+	product, err := p.product.GetProduct(r.Context(), productID)
 	if err != nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if writeNotModified(w, r, product.Version) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (p *ProductHandler) ListProductsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	options, err := parseListOptions(query)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	categoryID, err := parseIntParam(query, "category_id")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := p.product.ListProducts(r.Context(), models.ProductFilter{
+		ListOptions: options,
+		CategoryID:  categoryID,
+	})
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (p *ProductHandler) SearchProductsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		apperrors.Write(w, r, invalidParameter("search query must be provided"))
+		return
+	}
+
+	options, err := parseListOptions(query)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := p.product.SearchProducts(r.Context(), q, options)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
 
-	err = json.NewEncoder(w).Encode(product)
+func (p *ProductHandler) ListCategoryProductsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseListOptions(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
 	}
+
+	page, err := p.product.ListCategoryProducts(r.Context(), mux.Vars(r)["category_id"], options)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
 }
 
 func (p *ProductHandler) UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	product := &models.Product{}
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	err := decodeValidBody(r, product)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	var product *models.Product
+	product.ID = mux.Vars(r)["product_id"]
+
+	product.Version, err = ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&product)
+	err = p.product.UpdateProduct(r.Context(), product)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *ProductHandler) PatchProductHandler(w http.ResponseWriter, r *http.Request) {
 	productID := mux.Vars(r)["product_id"]
 
-	product.ID = productID
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	err = p.product.UpdateProduct(product)
+	product, err := p.product.GetProduct(r.Context(), productID)
 	if err != nil {
-		http.Error(w, "Failed to update product", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	current := &models.ProductPatch{Name: product.Name, CategoryIDs: product.CategoryIDs}
+	patch := &models.ProductPatch{}
+
+	patch.Fields, err = applyPatch(w, r, current, patch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	patch.Version, err = patchVersion(version, product.Version)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	if len(patch.Fields) > 0 {
+		err = p.product.PatchProduct(r.Context(), productID, *patch)
+		if err != nil {
+			apperrors.Write(w, r, err)
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
-func (p *ProductHandler) CreateProductHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+func (p *ProductHandler) SetProductCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories := &models.ProductCategories{}
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	err := decodeValidBody(r, categories)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	var product *models.Product
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&product)
+	err = p.product.SetProductCategories(r.Context(), mux.Vars(r)["product_id"], categories.CategoryIDs, version)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	if product.Name == "" {
-		http.Error(w, "Product name must be provided", http.StatusBadRequest)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *ProductHandler) CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+	product := &models.Product{}
+
+	err := decodeValidBody(r, product)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	err = p.product.CreateProduct(product)
+	err = p.product.CreateProduct(r.Context(), product)
 	if err != nil {
-		http.Error(w, "Failed to create product", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
 		return
 	}
 
@@ -104,15 +232,114 @@ func (p *ProductHandler) CreateProductHandler(w http.ResponseWriter, r *http.Req
 }
 
 func (p *ProductHandler) DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	productID := mux.Vars(r)["product_id"]
 
-	err := p.product.DeleteProduct(productID)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = p.product.DeleteProduct(r.Context(), productID, version)
 	if err != nil {
-		http.Error(w, "product not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (p *ProductHandler) CreateProductsHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.ProductBatch
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.Items))
+	validateItems(batch.Items, result)
+
+	err = p.product.CreateProducts(r.Context(), batch.Items, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusCreated, result)
+}
+
+func (p *ProductHandler) UpdateProductsHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.ProductBatch
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.Items))
+	validateItems(batch.Items, result)
+
+	err = p.product.UpdateProducts(r.Context(), batch.Items, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
+
+func (p *ProductHandler) DeleteProductsHandler(w http.ResponseWriter, r *http.Request) {
+	var batch models.BulkDelete
+
+	err := decodeValidBody(r, &batch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	result := models.NewBulkResult(batch.Mode, len(batch.IDs))
+
+	err = p.product.DeleteProducts(r.Context(), batch.IDs, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
+
+// ExportProductsHandler streams every product as a CSV or NDJSON download.
+func (p *ProductHandler) ExportProductsHandler(w http.ResponseWriter, r *http.Request) {
+	export, err := newExportWriter(w, r, "products", transfer.ProductColumns)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = p.product.ExportProducts(r.Context(), func(product models.ProductResponse) error {
+		return export.write(product, transfer.ProductRecord(product))
+	})
+
+	export.finish(r, err)
+}
+
+// ImportProductsHandler creates the rows of an uploaded file that have no id
+// and updates the others.
+func (p *ProductHandler) ImportProductsHandler(w http.ResponseWriter, r *http.Request) {
+	products, result, err := readImport(w, r, transfer.ProductColumns, transfer.ProductFromRecord)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = p.product.ImportProducts(r.Context(), products, result)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeBulkResult(w, http.StatusOK, result)
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"time"
 
 	"awesomeProject/internal/handlers/mocks"
 	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
@@ -46,12 +48,12 @@ var _ = Describe("Category Handler", func() {
 			productID := mux.Vars(request)["product_id"]
 
 			mockRepo.EXPECT().
-				GetProduct(productID).
+				GetProduct(gomock.Any(), productID).
 				Return(&models.ProductResponse{
-					Name:       "Hobbit",
-					CategoryID: 1,
-					CreatedAt:  time.Now(),
-					UpdatedAt:  time.Now(),
+					Name:        "Hobbit",
+					CategoryIDs: []int64{1},
+					CreatedAt:   time.Now(),
+					UpdatedAt:   time.Now(),
 				}, nil).
 				Times(1)
 
@@ -67,8 +69,8 @@ var _ = Describe("Category Handler", func() {
 			productID := mux.Vars(request)["product_id"]
 
 			mockRepo.EXPECT().
-				GetProduct(productID).
-				Return(nil, errors.New("no category id provided")).
+				GetProduct(gomock.Any(), productID).
+				Return(nil, repositories.ErrProductNotFound).
 				Times(1)
 
 			productHandler.GetProductHandler(responseRecorder, request)
@@ -83,8 +85,8 @@ var _ = Describe("Category Handler", func() {
 			productID := mux.Vars(request)["product_id"]
 
 			mockRepo.EXPECT().
-				GetProduct(productID).
-				Return(nil, errors.New("no category id provided")).
+				GetProduct(gomock.Any(), productID).
+				Return(nil, repositories.ErrProductNotFound).
 				Times(1)
 
 			productHandler.GetProductHandler(responseRecorder, request)
@@ -93,12 +95,122 @@ var _ = Describe("Category Handler", func() {
 		})
 	})
 
+	Describe("ListProductsHandler", func() {
+		It("should return 200 with the page envelope", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products?category_id=1&sort=name&cursor=abc", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				ListProducts(gomock.Any(), models.ProductFilter{
+					ListOptions: models.ListOptions{Cursor: "abc", Sort: "name"},
+					CategoryID:  1,
+				}).
+				Return(&models.ProductPage{
+					Data: []models.ProductResponse{{ID: "1", Name: "Hobbit", CategoryIDs: []int64{1}}},
+					Meta: models.PageMeta{Total: 1, Limit: 20},
+				}, nil).
+				Times(1)
+
+			productHandler.ListProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"meta":{"total":1,"limit":20}`))
+		})
+		It("should return 400 on invalid category id", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products?category_id=abc", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			productHandler.ListProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return 400 on invalid created_after", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products?created_after=yesterday", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			productHandler.ListProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("SearchProductsHandler", func() {
+		It("should return ranked results with snippets", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products/search?q=hob&limit=5", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				SearchProducts(gomock.Any(), "hob", models.ListOptions{Limit: 5}).
+				Return(&models.ProductSearchPage{
+					Data: []models.ProductSearchResult{{
+						ProductResponse: models.ProductResponse{ID: "1", Name: "Hobbit"},
+						Rank:            0.6,
+						Snippet:         "<mark>Hobbit</mark>",
+					}},
+					Meta: models.PageMeta{Total: 1, Limit: 5},
+				}, nil).
+				Times(1)
+
+			productHandler.SearchProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var page models.ProductSearchPage
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &page)).To(Succeed())
+			Expect(page.Data).To(HaveLen(1))
+			Expect(page.Data[0].Name).To(Equal("Hobbit"))
+			Expect(page.Data[0].Snippet).To(Equal("<mark>Hobbit</mark>"))
+		})
+		It("should pass the cursor on and return the next one", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products/search?q=hob&limit=1&cursor=first", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				SearchProducts(gomock.Any(), "hob", models.ListOptions{Limit: 1, Cursor: "first"}).
+				Return(&models.ProductSearchPage{
+					Data: []models.ProductSearchResult{{ProductResponse: models.ProductResponse{ID: "2", Name: "Hobbit"}}},
+					Meta: models.PageMeta{NextCursor: "second", Total: 3, Limit: 1},
+				}, nil).
+				Times(1)
+
+			productHandler.SearchProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var page models.ProductSearchPage
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &page)).To(Succeed())
+			Expect(page.Meta.NextCursor).To(Equal("second"))
+		})
+		It("should return 400 for an invalid cursor", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products/search?q=hob&cursor=bogus", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				SearchProducts(gomock.Any(), "hob", gomock.Any()).
+				Return(nil, repositories.ErrInvalidCursor).
+				Times(1)
+
+			productHandler.SearchProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("invalid_cursor"))
+		})
+		It("should return 400 when the query is missing", func() {
+			request, err := http.NewRequest("GET", "/api/v1/products/search?q=%20", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			productHandler.SearchProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
 	Describe("UpdateProductHandler", func() {
 		It("should return 200", func() {
 			product := &models.Product{
-				Name:       "Hobbit",
-				CategoryID: 1,
-				UpdatedAt:  time.Now().Round(time.Microsecond),
+				Name:        "Hobbit",
+				CategoryIDs: []int64{1, 2},
+				UpdatedAt:   time.Now().UTC().Round(time.Microsecond),
 			}
 
 			requestBody, err := json.Marshal(product)
@@ -108,7 +220,7 @@ var _ = Describe("Category Handler", func() {
 			request.Header.Set("Content-Type", "application/json")
 
 			mockRepo.EXPECT().
-				UpdateProduct(gomock.Eq(product)).
+				UpdateProduct(gomock.Any(), gomock.Eq(product)).
 				Return(nil).
 				Times(1)
 
@@ -116,7 +228,7 @@ var _ = Describe("Category Handler", func() {
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
-		It("should return 200, empty request", func() {
+		It("should return 422, empty request", func() {
 			product := &models.Product{}
 
 			requestBody, err := json.Marshal(product)
@@ -125,21 +237,19 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().
-				UpdateProduct(gomock.Eq(product)).
-				Return(nil).
-				Times(1)
+			mockRepo.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Times(0)
 
 			productHandler.UpdateProductHandler(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("name"))
 		})
 		It("should return 400, nil body", func() {
 			request, err := http.NewRequest("PUT", "/api/v1/products/1", nil)
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().UpdateProduct(gomock.Any()).Times(0)
+			mockRepo.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Times(0)
 
 			productHandler.UpdateProductHandler(responseRecorder, request)
 
@@ -147,9 +257,230 @@ var _ = Describe("Category Handler", func() {
 		})
 	})
 
+	Describe("PatchProductHandler", func() {
+		current := &models.ProductResponse{Name: "Laptop", CategoryIDs: []int64{1, 2}, Version: 3}
+
+		patchRequest := func(contentType, body string) *http.Request {
+			request, err := http.NewRequest("PATCH", "/api/v1/products/1", bytes.NewBufferString(body))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", contentType)
+
+			return mux.SetURLVars(request, map[string]string{"product_id": "1"})
+		}
+
+		It("should write only the fields a merge patch changes", func() {
+			request := patchRequest("application/merge-patch+json", `{"name":"Desktop","category_ids":[1,2]}`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().
+				PatchProduct(gomock.Any(), "1", models.ProductPatch{
+					Name:        "Desktop",
+					CategoryIDs: []int64{1, 2},
+					Fields:      models.PatchFields{"name": true},
+					Version:     3,
+				}).
+				Return(nil).
+				Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Accept-Patch")).To(ContainSubstring("application/json-patch+json"))
+		})
+
+		It("should apply a JSON Patch", func() {
+			request := patchRequest("application/json-patch+json",
+				`[{"op":"test","path":"/name","value":"Laptop"},{"op":"add","path":"/category_ids/-","value":5}]`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().
+				PatchProduct(gomock.Any(), "1", models.ProductPatch{
+					Name:        "Laptop",
+					CategoryIDs: []int64{1, 2, 5},
+					Fields:      models.PatchFields{"category_ids": true},
+					Version:     3,
+				}).
+				Return(nil).
+				Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should not write when nothing changes", func() {
+			request := patchRequest("application/merge-patch+json", `{"name":"Laptop"}`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().PatchProduct(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should return 422 for unknown fields", func() {
+			request := patchRequest("application/merge-patch+json", `{"price":10,"created_at":null}`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().PatchProduct(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("price"))
+		})
+
+		It("should return 422 when the patch removes a required field", func() {
+			request := patchRequest("application/merge-patch+json", `{"name":null}`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().PatchProduct(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKeyWithValue("name", []string{"is required"}))
+		})
+
+		It("should return 422 for a value of the wrong type", func() {
+			request := patchRequest("application/merge-patch+json", `{"name":5}`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("name"))
+		})
+
+		It("should return 409 when a test operation fails", func() {
+			request := patchRequest("application/json-patch+json", `[{"op":"test","path":"/name","value":"Desktop"}]`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("patch_test_failed"))
+		})
+
+		It("should return 400 for a JSON Patch that cannot be applied", func() {
+			request := patchRequest("application/json-patch+json", `[{"op":"remove","path":"/category_ids/7"}]`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("invalid_patch"))
+		})
+
+		It("should return 415 for other media types", func() {
+			request := patchRequest("text/plain", `name=Desktop`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnsupportedMediaType))
+			Expect(responseRecorder.Header().Get("Accept-Patch")).To(ContainSubstring("application/merge-patch+json"))
+		})
+
+		It("should return 412 when If-Match is stale", func() {
+			request := patchRequest("application/merge-patch+json", `{"name":"Desktop"}`)
+			request.Header.Set("If-Match", `"2"`)
+
+			mockRepo.EXPECT().GetProduct(gomock.Any(), "1").Return(current, nil).Times(1)
+			mockRepo.EXPECT().PatchProduct(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.PatchProductHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusPreconditionFailed))
+		})
+	})
+
+	Describe("SetProductCategoriesHandler", func() {
+		It("should return 200", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/products/1/categories",
+				bytes.NewBufferString(`{"category_ids":[1,3]}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"product_id": "1"})
+
+			mockRepo.EXPECT().SetProductCategories(gomock.Any(), "1", []int64{1, 3}, int64(0)).Return(nil).Times(1)
+
+			productHandler.SetProductCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 422 for a non-positive category id", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/products/1/categories",
+				bytes.NewBufferString(`{"category_ids":[1,0]}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().SetProductCategories(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.SetProductCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKey("category_ids[1]"))
+		})
+		It("should return 404 when a category does not exist", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/products/1/categories",
+				bytes.NewBufferString(`{"category_ids":[9]}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"product_id": "1"})
+
+			mockRepo.EXPECT().
+				SetProductCategories(gomock.Any(), "1", []int64{9}, int64(0)).
+				Return(repositories.ErrCategoryNotFound).
+				Times(1)
+
+			productHandler.SetProductCategoriesHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("category_not_found"))
+		})
+	})
+
+	Describe("ListCategoryProductsHandler", func() {
+		It("should return 200 with the page envelope", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/3/products?limit=5", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "3"})
+
+			mockRepo.EXPECT().
+				ListCategoryProducts(gomock.Any(), "3", models.ListOptions{Limit: 5}).
+				Return(&models.ProductPage{
+					Data: []models.ProductResponse{{ID: "1", Name: "Hobbit", CategoryIDs: []int64{3}}},
+					Meta: models.PageMeta{Total: 1, Limit: 5},
+				}, nil).
+				Times(1)
+
+			productHandler.ListCategoryProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"category_ids":[3]`))
+		})
+		It("should return 404 for an unknown category", func() {
+			request, err := http.NewRequest("GET", "/api/v1/categories/3/products", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"category_id": "3"})
+
+			mockRepo.EXPECT().
+				ListCategoryProducts(gomock.Any(), "3", models.ListOptions{}).
+				Return(nil, repositories.ErrCategoryNotFound).
+				Times(1)
+
+			productHandler.ListCategoryProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
 	Describe("CreateProductHandler", func() {
 		It("should return 200", func() {
-			product := &models.Product{Name: "testBook"}
+			product := &models.Product{Name: "testBook", CategoryIDs: []int64{1}}
 
 			requestBody, err := json.Marshal(product)
 			Expect(err).NotTo(HaveOccurred())
@@ -157,7 +488,7 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateProduct(product).Return(nil).Times(1)
+			mockRepo.EXPECT().CreateProduct(gomock.Any(), product).Return(nil).Times(1)
 
 			productHandler.CreateProductHandler(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
@@ -167,23 +498,27 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateProduct(gomock.Any()).Times(0)
+			mockRepo.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(0)
 
 			productHandler.CreateProductHandler(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
 		})
-		It("should return 400, empty body", func() {
+		It("should return 422, empty body", func() {
 			category := &models.Category{}
 
 			requestBody, _ := json.Marshal(category)
 			request, _ := http.NewRequest("POST", "/api/v1/products", bytes.NewBuffer(requestBody))
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().CreateProduct(gomock.Any()).Times(0)
+			mockRepo.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(0)
 
 			productHandler.CreateProductHandler(responseRecorder, request)
-			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			problem := decodeProblem(responseRecorder)
+			Expect(problem.Errors).To(HaveKeyWithValue("name", []string{"is required"}))
+			Expect(problem.Errors).To(HaveKeyWithValue("category_ids", []string{"is required"}))
 		})
 	})
 
@@ -195,7 +530,7 @@ var _ = Describe("Category Handler", func() {
 
 			categoryID := mux.Vars(request)["category_id"]
 
-			mockRepo.EXPECT().DeleteProduct(categoryID).Return(nil).Times(1)
+			mockRepo.EXPECT().DeleteProduct(gomock.Any(), categoryID, int64(0)).Return(nil).Times(1)
 			productHandler.DeleteProductHandler(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
@@ -204,9 +539,369 @@ var _ = Describe("Category Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().DeleteProduct(gomock.Any()).Return(errors.New("not found")).Times(1)
+			mockRepo.EXPECT().DeleteProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(repositories.ErrProductNotFound).Times(1)
 			productHandler.DeleteProductHandler(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
 		})
 	})
+
+	Describe("CreateProductsHandler", func() {
+		It("should return 201 with the id of every item", func() {
+			body := `{"items":[{"name":"Hobbit","category_ids":[1]},{"name":"Dune","category_ids":[2]}]}`
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk", bytes.NewBufferString(body))
+
+			mockRepo.EXPECT().
+				CreateProducts(gomock.Any(), gomock.Len(2), gomock.Any()).
+				DoAndReturn(func(_ interface{}, products []models.Product, result *models.BulkResult) error {
+					result.Succeed(0, "1")
+					result.Succeed(1, "2")
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.CreateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Mode).To(Equal(models.BulkAtomic))
+			Expect(result.Succeeded).To(Equal(2))
+			Expect(result.Results[1].ID).To(Equal("2"))
+		})
+
+		It("should report invalid items by index and roll back an atomic batch", func() {
+			body := `{"items":[{"name":"Hobbit","category_ids":[1]},{"category_ids":[1]}]}`
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk", bytes.NewBufferString(body))
+
+			mockRepo.EXPECT().
+				CreateProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					Expect(result.Abort()).To(BeTrue())
+					result.Finish(false)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.CreateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Committed).To(BeFalse())
+			Expect(result.Failed).To(Equal(1))
+			Expect(result.Results[1].Index).To(Equal(1))
+			Expect(result.Results[1].Code).To(Equal("validation_failed"))
+			Expect(result.Results[1].Errors).To(HaveKeyWithValue("name", []string{"is required"}))
+		})
+
+		It("should commit the valid items of a partial batch", func() {
+			body := `{"mode":"partial","items":[{"name":"Hobbit","category_ids":[1]},{"category_ids":[1]}]}`
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk", bytes.NewBufferString(body))
+
+			mockRepo.EXPECT().
+				CreateProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					Expect(result.Abort()).To(BeFalse())
+					result.Succeed(0, "1")
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.CreateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Succeeded).To(Equal(1))
+			Expect(result.Failed).To(Equal(1))
+		})
+
+		It("should return 422 for an empty batch", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk", bytes.NewBufferString(`{"items":[]}`))
+
+			mockRepo.EXPECT().CreateProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.CreateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			problem := decodeProblem(responseRecorder)
+			Expect(problem.Errors).To(HaveKeyWithValue("items", []string{"is required"}))
+		})
+
+		It("should return 422 for an unknown mode", func() {
+			body := `{"mode":"sometimes","items":[{"name":"Hobbit","category_ids":[1]}]}`
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk", bytes.NewBufferString(body))
+
+			mockRepo.EXPECT().CreateProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.CreateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	Describe("UpdateProductsHandler", func() {
+		It("should return 200", func() {
+			body := `{"items":[{"id":"1","name":"Hobbit","category_ids":[1]}]}`
+			request, _ := http.NewRequest("PUT", "/api/v1/products/bulk", bytes.NewBufferString(body))
+
+			mockRepo.EXPECT().
+				UpdateProducts(gomock.Any(), []models.Product{{ID: "1", Name: "Hobbit", CategoryIDs: []int64{1}}}, gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.UpdateProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("DeleteProductsHandler", func() {
+		It("should return 200", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk/delete", bytes.NewBufferString(`{"ids":[1,2]}`))
+
+			mockRepo.EXPECT().
+				DeleteProducts(gomock.Any(), []int64{1, 2}, gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []int64, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.DeleteProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should return 422 for a non-positive id", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/bulk/delete", bytes.NewBufferString(`{"ids":[0]}`))
+
+			mockRepo.EXPECT().DeleteProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.DeleteProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	Describe("ExportProductsHandler", func() {
+		var products []models.ProductResponse
+
+		BeforeEach(func() {
+			created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+			products = []models.ProductResponse{
+				{ID: "1", Name: "Hobbit", CategoryIDs: []int64{1, 2}, CreatedAt: created, UpdatedAt: created},
+				{ID: "2", Name: "Dune, Part One", CategoryIDs: []int64{3}, CreatedAt: created, UpdatedAt: created},
+			}
+
+			mockRepo.EXPECT().
+				ExportProducts(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, fn func(models.ProductResponse) error) error {
+					for _, product := range products {
+						Expect(fn(product)).To(Succeed())
+					}
+					return nil
+				}).
+				AnyTimes()
+		})
+
+		It("should stream CSV as a download", func() {
+			request, _ := http.NewRequest("GET", "/api/v1/products/export", nil)
+
+			productHandler.ExportProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("text/csv; charset=utf-8"))
+			Expect(responseRecorder.Header().Get("Content-Disposition")).To(Equal(`attachment; filename=products.csv`))
+			Expect(responseRecorder.Body.String()).To(Equal("id,name,category_ids,created_at,updated_at\n" +
+				"1,Hobbit,1;2,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n" +
+				`2,"Dune, Part One",3,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z` + "\n"))
+		})
+
+		It("should stream NDJSON", func() {
+			request, _ := http.NewRequest("GET", "/api/v1/products/export?format=ndjson", nil)
+
+			productHandler.ExportProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+			lines := bytes.Split(bytes.TrimSpace(responseRecorder.Body.Bytes()), []byte("\n"))
+			Expect(lines).To(HaveLen(2))
+
+			var product models.ProductResponse
+			Expect(json.Unmarshal(lines[1], &product)).To(Succeed())
+			Expect(product.Name).To(Equal("Dune, Part One"))
+		})
+
+		It("should return 400 for an unknown format", func() {
+			request, _ := http.NewRequest("GET", "/api/v1/products/export?format=xlsx", nil)
+
+			productHandler.ExportProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("ExportProductsHandler failures", func() {
+		It("should return a problem when the export fails before any row is sent", func() {
+			request, _ := http.NewRequest("GET", "/api/v1/products/export", nil)
+
+			mockRepo.EXPECT().ExportProducts(gomock.Any(), gomock.Any()).Return(errors.New("db error")).Times(1)
+
+			productHandler.ExportProductsHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(responseRecorder.Header().Get("Content-Disposition")).To(BeEmpty())
+		})
+	})
+
+	Describe("ImportProductsHandler", func() {
+		It("should import a CSV file and report row errors by line", func() {
+			body := "id,name,category_ids,created_at\n" +
+				",Hobbit,1;2,\n" +
+				"4,Dune,3,2024-05-01T10:00:00Z\n" +
+				",Emma,x,\n" +
+				",,1,\n"
+			request, _ := http.NewRequest("POST", "/api/v1/products/import?mode=partial", bytes.NewBufferString(body))
+			request.Header.Set("Content-Type", "text/csv")
+
+			mockRepo.EXPECT().
+				ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, products []models.Product, result *models.BulkResult) error {
+					Expect(products[0]).To(Equal(models.Product{Name: "Hobbit", CategoryIDs: []int64{1, 2}}))
+					Expect(products[1].ID).To(Equal("4"))
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Succeeded).To(Equal(2))
+			Expect(result.Failed).To(Equal(2))
+			Expect(result.Results[2].Line).To(Equal(4))
+			Expect(result.Results[2].Errors).To(HaveKey("category_ids"))
+			Expect(result.Results[3].Line).To(Equal(5))
+			Expect(result.Results[3].Errors).To(HaveKeyWithValue("name", []string{"is required"}))
+		})
+
+		It("should fail rows with the wrong number of fields", func() {
+			body := "name,category_ids\nHobbit,1,extra\n"
+			request, _ := http.NewRequest("POST", "/api/v1/products/import", bytes.NewBufferString(body))
+			request.Header.Set("Content-Type", "text/csv")
+
+			mockRepo.EXPECT().
+				ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					result.Finish(false)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.Results[0].Code).To(Equal("invalid_row"))
+		})
+
+		It("should import NDJSON as a dry run", func() {
+			body := `{"name":"Hobbit","category_ids":[1]}` + "\n\n" + `{"name":"Dune","category_ids":"3"}` + "\n"
+			request, _ := http.NewRequest("POST", "/api/v1/products/import?dry_run=true&mode=partial", bytes.NewBufferString(body))
+			request.Header.Set("Content-Type", "application/x-ndjson")
+
+			mockRepo.EXPECT().
+				ImportProducts(gomock.Any(), gomock.Len(2), gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					Expect(result.DryRun).To(BeTrue())
+					result.Finish(false)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var result models.BulkResult
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &result)).To(Succeed())
+			Expect(result.DryRun).To(BeTrue())
+			Expect(result.Committed).To(BeFalse())
+			Expect(result.Succeeded).To(Equal(1))
+			Expect(result.Results[1].Line).To(Equal(3))
+			Expect(result.Results[1].Errors).To(HaveKeyWithValue("category_ids", []string{"has the wrong type"}))
+		})
+
+		It("should read the file field of a multipart upload", func() {
+			var body bytes.Buffer
+
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", "catalog.csv")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = part.Write([]byte("name,category_ids\nHobbit,1\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(form.Close()).To(Succeed())
+
+			request, _ := http.NewRequest("POST", "/api/v1/products/import", &body)
+			request.Header.Set("Content-Type", form.FormDataContentType())
+
+			mockRepo.EXPECT().
+				ImportProducts(gomock.Any(), []models.Product{{Name: "Hobbit", CategoryIDs: []int64{1}}}, gomock.Any()).
+				DoAndReturn(func(_ interface{}, _ []models.Product, result *models.BulkResult) error {
+					result.Finish(true)
+					return nil
+				}).
+				Times(1)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should return 422 for an unknown column", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/import", bytes.NewBufferString("name,category_ids,price\n"))
+			request.Header.Set("Content-Type", "text/csv")
+
+			mockRepo.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeProblem(responseRecorder).Errors).To(HaveKeyWithValue("price", []string{"is not a known column"}))
+		})
+
+		It("should return 400 for a file without rows", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/import", bytes.NewBufferString("name,category_ids\n"))
+			request.Header.Set("Content-Type", "text/csv")
+
+			mockRepo.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("import_empty"))
+		})
+
+		It("should return 415 for other media types", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/import", bytes.NewBufferString("<xml/>"))
+			request.Header.Set("Content-Type", "application/xml")
+
+			mockRepo.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnsupportedMediaType))
+		})
+
+		It("should return 400 for an unknown mode", func() {
+			request, _ := http.NewRequest("POST", "/api/v1/products/import?mode=sometimes", bytes.NewBufferString("name,category_ids\n"))
+			request.Header.Set("Content-Type", "text/csv")
+
+			mockRepo.EXPECT().ImportProducts(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			productHandler.ImportProductsHandler(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
 })
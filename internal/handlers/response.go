@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"awesomeProject/internal/tracing"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+)
+
+var (
+	errBodyMissing        = apperrors.BadRequest(apperrors.CodeInvalidBody, "request body missing")
+	errUnauthenticated    = apperrors.Unauthorized(apperrors.CodeUnauthorized, "authentication required")
+	errInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "invalid login credentials")
+)
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return errBodyMissing
+	}
+
+	_, span := tracing.Start(r.Context(), "decode body")
+	defer span.End()
+
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		span.RecordError(err)
+		return apperrors.BadRequest(apperrors.CodeInvalidBody, "request body is not valid JSON").Wrap(err)
+	}
+
+	return nil
+}
+
+// decodeValidBody decodes the body into v and checks its validate tags.
+func decodeValidBody(r *http.Request, v interface{}) error {
+	err := decodeBody(r, v)
+	if err != nil {
+		return err
+	}
+
+	return validation.Struct(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// The status line is already sent, nothing useful can be done on failure.
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func invalidParameter(message string) error {
+	return apperrors.BadRequest(apperrors.CodeInvalidParameter, message)
+}
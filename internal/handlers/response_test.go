@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"awesomeProject/pkg/apperrors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func decodeProblem(recorder *httptest.ResponseRecorder) apperrors.Problem {
+	var problem apperrors.Problem
+
+	Expect(recorder.Header().Get("Content-Type")).To(Equal(apperrors.ProblemContentType))
+	Expect(json.NewDecoder(recorder.Body).Decode(&problem)).To(Succeed())
+
+	return problem
+}
+
+var _ = Describe("decodeBody", func() {
+	It("should reject a missing body", func() {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/products", nil)
+		request.Body = nil
+
+		var v map[string]interface{}
+
+		err := decodeBody(request, &v)
+		Expect(errors.Is(err, apperrors.BadRequest(apperrors.CodeInvalidBody, ""))).To(BeTrue())
+	})
+
+	It("should reject malformed JSON as invalid_body", func() {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader("{"))
+
+		var v map[string]interface{}
+
+		err := decodeBody(request, &v)
+		Expect(apperrors.From(err).Code).To(Equal(apperrors.CodeInvalidBody))
+		Expect(apperrors.From(err).Kind.Status()).To(Equal(http.StatusBadRequest))
+	})
+})
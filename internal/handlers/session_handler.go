@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/gorilla/mux"
+)
+
+type Sessioner interface {
+	GetSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+}
+
+type SessionHandler struct {
+	sessionRepository repositories.SessionRepository
+}
+
+func NewSessionHandler(sessionRepository repositories.SessionRepository) Sessioner {
+	return &SessionHandler{
+		sessionRepository: sessionRepository,
+	}
+}
+
+func (s *SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	sessions, err := s.sessionRepository.GetActiveSessions(r.Context(), principal.UserID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.SessionID
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	sessionID := mux.Vars(r)["session_id"]
+
+	err := s.sessionRepository.RevokeSession(r.Context(), sessionID, principal.UserID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Handler", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockRepo         *mocks.MockSessionRepository
+		sessionHandler   Sessioner
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	authenticated := func(request *http.Request) *http.Request {
+		return request.WithContext(authentication.WithPrincipal(request.Context(),
+			&authentication.Principal{UserID: "1", Username: "test", SessionID: "current"}))
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockSessionRepository(mockCtrl)
+		sessionHandler = NewSessionHandler(mockRepo)
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("GetSessions", func() {
+		It("should return 200 and mark the current session", func() {
+			request, err := http.NewRequest("GET", "/api/v1/sessions", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticated(request)
+
+			mockRepo.EXPECT().
+				GetActiveSessions(gomock.Any(), "1").
+				Return([]models.SessionResponse{{ID: "current"}, {ID: "other"}}, nil).
+				Times(1)
+
+			sessionHandler.GetSessions(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var sessions []models.SessionResponse
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&sessions)).To(Succeed())
+			Expect(sessions[0].Current).To(BeTrue())
+			Expect(sessions[1].Current).To(BeFalse())
+		})
+		It("should return 401 without a principal", func() {
+			request, err := http.NewRequest("GET", "/api/v1/sessions", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			sessionHandler.GetSessions(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("RevokeSession", func() {
+		It("should return 200", func() {
+			request, err := http.NewRequest("DELETE", "/api/v1/sessions/other", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticated(request)
+			request = mux.SetURLVars(request, map[string]string{"session_id": "other"})
+
+			mockRepo.EXPECT().RevokeSession(gomock.Any(), "other", "1").Return(nil).Times(1)
+
+			sessionHandler.RevokeSession(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 404 for a session of another user", func() {
+			request, err := http.NewRequest("DELETE", "/api/v1/sessions/foreign", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticated(request)
+			request = mux.SetURLVars(request, map[string]string{"session_id": "foreign"})
+
+			mockRepo.EXPECT().RevokeSession(gomock.Any(), "foreign", "1").Return(repositories.ErrSessionNotFound).Times(1)
+
+			sessionHandler.RevokeSession(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
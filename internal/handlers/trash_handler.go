@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/gorilla/mux"
+)
+
+type Trasher interface {
+	ListTrashHandler(w http.ResponseWriter, req *http.Request)
+	RestoreTrashHandler(w http.ResponseWriter, req *http.Request)
+	PurgeTrashHandler(w http.ResponseWriter, req *http.Request)
+}
+
+type TrashHandler struct {
+	trash repositories.TrashRepository
+}
+
+func NewTrashHandler(trash repositories.TrashRepository) Trasher {
+	return &TrashHandler{
+		trash: trash,
+	}
+}
+
+func (t *TrashHandler) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := t.trash.ListTrash(r.Context(), trashKind(r), options)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (t *TrashHandler) RestoreTrashHandler(w http.ResponseWriter, r *http.Request) {
+	err := t.trash.RestoreTrash(r.Context(), trashKind(r), mux.Vars(r)["id"])
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t *TrashHandler) PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	err := t.trash.PurgeTrash(r.Context(), trashKind(r), mux.Vars(r)["id"])
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func trashKind(r *http.Request) models.TrashKind {
+	return models.TrashKind(mux.Vars(r)["kind"])
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trash Handler", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockRepo         *mocks.MockTrashRepository
+		trashHandler     Trasher
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockTrashRepository(mockCtrl)
+		trashHandler = NewTrashHandler(mockRepo)
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("ListTrashHandler", func() {
+		It("should return 200 with the trashed items", func() {
+			request, err := http.NewRequest("GET", "/api/v1/trash/products?sort=-deleted_at", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"kind": "products"})
+
+			mockRepo.EXPECT().
+				ListTrash(gomock.Any(), models.TrashProducts, models.ListOptions{Sort: "-deleted_at"}).
+				Return(&models.TrashPage{Data: []models.TrashedItem{{ID: "1", Name: "Laptop"}}}, nil).
+				Times(1)
+
+			trashHandler.ListTrashHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Body.String()).To(ContainSubstring(`"name":"Laptop"`))
+		})
+	})
+
+	Describe("RestoreTrashHandler", func() {
+		It("should return 200", func() {
+			request, err := http.NewRequest("POST", "/api/v1/trash/users/3/restore", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"kind": "users", "id": "3"})
+
+			mockRepo.EXPECT().RestoreTrash(gomock.Any(), models.TrashUsers, "3").Return(nil).Times(1)
+
+			trashHandler.RestoreTrashHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 409 when the email is taken", func() {
+			request, err := http.NewRequest("POST", "/api/v1/trash/users/3/restore", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"kind": "users", "id": "3"})
+
+			mockRepo.EXPECT().RestoreTrash(gomock.Any(), models.TrashUsers, "3").Return(repositories.ErrUserAlreadyExists).Times(1)
+
+			trashHandler.RestoreTrashHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("PurgeTrashHandler", func() {
+		It("should return 404 when the item is not in the trash", func() {
+			request, err := http.NewRequest("DELETE", "/api/v1/trash/categories/3", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"kind": "categories", "id": "3"})
+
+			mockRepo.EXPECT().PurgeTrash(gomock.Any(), models.TrashCategories, "3").Return(repositories.ErrCategoryNotFound).Times(1)
+
+			trashHandler.PurgeTrashHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"awesomeProject/internal/middlewares/authentication"
 	"awesomeProject/internal/models"
 	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
 	"awesomeProject/pkg/utils"
 
 	"github.com/gorilla/mux"
@@ -15,10 +16,23 @@ type Userer interface {
 	GetUserByUsername(w http.ResponseWriter, r *http.Request)
 	GetAllUsers(w http.ResponseWriter, r *http.Request)
 	UpdateUser(w http.ResponseWriter, r *http.Request)
+	UpdateUserRole(w http.ResponseWriter, r *http.Request)
+	PatchUser(w http.ResponseWriter, r *http.Request)
 	CreateUser(w http.ResponseWriter, r *http.Request)
 	DeleteUser(w http.ResponseWriter, r *http.Request)
 }
 
+// errRoleChange refuses role changes on the routes users update themselves
+// with, roles are changed through PUT /users/{user_id}/role.
+var errRoleChange = apperrors.Forbidden("role_change_forbidden", "the role can only be changed through PUT /users/{user_id}/role")
+
+// userUpdateRequest is the body of a user update as sent. Role is only
+// decoded to refuse it.
+type userUpdateRequest struct {
+	models.UserUpdate
+	Role *string `json:"role"`
+}
+
 type UserHandler struct {
 	userRepository repositories.UserRepository
 }
@@ -30,134 +44,216 @@ func NewUserHandler(userRepository repositories.UserRepository) Userer {
 }
 
 func (u *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	name := mux.Vars(r)["username"]
 
-	userResponse, err := u.userRepository.GetUserByUsername(name)
+	userResponse, err := u.userRepository.GetUserByUsername(r.Context(), name)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(userResponse)
-	if err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	if writeNotModified(w, r, userResponse.Version) {
+		return
 	}
+
+	writeJSON(w, http.StatusOK, userResponse)
 }
 
 func (u *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
 
-	users, err := u.userRepository.GetAllUsers()
+	options, err := parseListOptions(query)
 	if err != nil {
-		http.Error(w, "Users not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(users)
+	page, err := u.userRepository.GetAllUsers(r.Context(), models.UserFilter{
+		ListOptions: options,
+		Role:        query.Get("role"),
+	})
 	if err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
 	}
+
+	writeJSON(w, http.StatusOK, page)
 }
 
 func (u *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	update := &userUpdateRequest{}
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	err := decodeValidBody(r, update)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	user := &models.User{}
-
-	err := json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		http.Error(w, "Failed to encode request", http.StatusBadRequest)
+	if update.Role != nil {
+		apperrors.Write(w, r, errRoleChange)
 		return
 	}
 
 	userID := mux.Vars(r)["user_id"]
 
-	cookieUserID, err := r.Cookie("userID")
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		http.Error(w, "Cookie not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
 		return
 	}
 
-	if cookieUserID.Value != userID {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if principal.UserID != userID {
+		apperrors.Write(w, r, apperrors.Forbidden(apperrors.CodeForbidden, "users can only update themselves"))
 		return
 	}
 
-	username, err := r.Cookie("username")
+	userResponse, err := u.userRepository.GetUserByID(r.Context(), principal.UserID)
 	if err != nil {
-		http.Error(w, "Cookie not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	userResponse, err := u.userRepository.GetUserByUsername(username.Value)
-	if err != nil || userResponse == nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	user := &models.User{
+		ID:       principal.UserID,
+		Username: defaultIfEmpty(update.Username, userResponse.Username),
+		Email:    defaultIfEmpty(update.Email, userResponse.Email),
+		Version:  version,
+	}
+
+	err = u.userRepository.UpdateUser(r.Context(), user)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	user.ID = cookieUserID.Value
+	w.WriteHeader(http.StatusOK)
+}
 
-	user.Username = defaultIfEmpty(user.Username, userResponse.Username)
-	user.Email = defaultIfEmpty(user.Email, userResponse.Email)
-	user.Role = defaultIfEmpty(user.Role, userResponse.Role)
+// UpdateUserRole changes the role of any user. The route requires the
+// users:write permission.
+func (u *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	update := &models.UserRoleUpdate{}
 
-	err = u.userRepository.UpdateUser(user)
+	err := decodeValidBody(r, update)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = u.userRepository.UpdateUserRole(r.Context(), mux.Vars(r)["user_id"], update.Role, version)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (u *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+func (u *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["user_id"]
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	if principal.UserID != userID {
+		apperrors.Write(w, r, apperrors.Forbidden(apperrors.CodeForbidden, "users can only update themselves"))
+		return
+	}
+
+	userResponse, err := u.userRepository.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	current := &models.UserPatch{Username: userResponse.Username, Email: userResponse.Email, Role: userResponse.Role}
+	patch := &models.UserPatch{}
+
+	patch.Fields, err = applyPatch(w, r, current, patch)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	if r.Body == nil {
-		http.Error(w, "Request body missing", http.StatusBadRequest)
+	if patch.Fields["role"] {
+		apperrors.Write(w, r, errRoleChange)
+		return
+	}
+
+	patch.Version, err = patchVersion(version, userResponse.Version)
+	if err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	if len(patch.Fields) > 0 {
+		err = u.userRepository.PatchUser(r.Context(), userID, *patch)
+		if err != nil {
+			apperrors.Write(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (u *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	user := &models.User{}
 
-	err := json.NewDecoder(r.Body).Decode(&user)
+	err := decodeValidBody(r, user)
 	if err != nil {
-		http.Error(w, "Failed to encode request", http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
 	user.Password, err = utils.GenerateHashPassword(user.Password)
 	if err != nil {
-		http.Error(w, "Hashing password error", http.StatusBadRequest)
+		apperrors.Write(w, r, apperrors.Internal(err))
 		return
 	}
 
-	err = u.userRepository.CreateUser(user)
+	err = u.userRepository.CreateUser(r.Context(), user)
 	if err != nil {
-		http.Error(w, "Failed to create user", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusCreated)
 }
 
 func (u *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	userID := mux.Vars(r)["user_id"]
 
-	err := u.userRepository.DeleteUser(userID)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = u.userRepository.DeleteUser(r.Context(), userID, version)
 	if err != nil {
-		http.Error(w, "Failed to delete user", http.StatusNotFound)
+		apperrors.Write(w, r, err)
 		return
 	}
 
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 
 	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/middlewares/authentication"
 	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
@@ -25,6 +29,11 @@ var _ = Describe("User Handler", func() {
 		responseRecorder *httptest.ResponseRecorder
 	)
 
+	authenticatedAs := func(request *http.Request, userID, username string) *http.Request {
+		return request.WithContext(authentication.WithPrincipal(request.Context(),
+			&authentication.Principal{UserID: userID, Username: username, SessionID: "session"}))
+	}
+
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockRepo = mocks.NewMockUserRepository(mockCtrl)
@@ -45,7 +54,7 @@ var _ = Describe("User Handler", func() {
 			userID := mux.Vars(request)["user_id"]
 
 			mockRepo.EXPECT().
-				GetUserByUsername(userID).
+				GetUserByUsername(gomock.Any(), userID).
 				Return(&models.UserResponse{
 					Email: "user@example.com",
 					Role:  "user",
@@ -63,8 +72,8 @@ var _ = Describe("User Handler", func() {
 			categoryID := mux.Vars(request)["user_id"]
 
 			mockRepo.EXPECT().
-				GetUserByUsername(categoryID).
-				Return(nil, errors.New("not found")).
+				GetUserByUsername(gomock.Any(), categoryID).
+				Return(nil, repositories.ErrUserNotFound).
 				Times(1)
 
 			userHandler.GetUserByUsername(responseRecorder, request)
@@ -79,8 +88,8 @@ var _ = Describe("User Handler", func() {
 			categoryID := mux.Vars(request)["user_id"]
 
 			mockRepo.EXPECT().
-				GetUserByUsername(categoryID).
-				Return(nil, errors.New("not found")).
+				GetUserByUsername(gomock.Any(), categoryID).
+				Return(nil, repositories.ErrUserNotFound).
 				Times(1)
 
 			userHandler.GetUserByUsername(responseRecorder, request)
@@ -91,46 +100,65 @@ var _ = Describe("User Handler", func() {
 
 	Describe("Get All Users Handler", func() {
 		It("should return 200", func() {
-			request, _ := http.NewRequest("GET", "/api/v1/users", nil)
+			request, _ := http.NewRequest("GET", "/api/v1/users?role=admin&sort=-created_at&limit=2", nil)
 			request.Header.Set("Content-Type", "application/json")
 
-			expectedUsers := []models.UserResponse{
-				{Email: "user1@example.com", Role: "user"},
-				{Email: "user2@example.com", Role: "admin"},
+			expectedUsers := &models.UserPage{
+				Data: []models.UserResponse{
+					{Email: "user1@example.com", Role: "admin"},
+					{Email: "user2@example.com", Role: "admin"},
+				},
+				Meta: models.PageMeta{NextCursor: "next", Total: 3, Limit: 2},
 			}
 
 			mockRepo.EXPECT().
-				GetAllUsers().
+				GetAllUsers(gomock.Any(), models.UserFilter{
+					ListOptions: models.ListOptions{Limit: 2, Sort: "-created_at"},
+					Role:        "admin",
+				}).
 				Return(expectedUsers, nil).
 				Times(1)
 
 			userHandler.GetAllUsers(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var page models.UserPage
+			Expect(json.Unmarshal(responseRecorder.Body.Bytes(), &page)).To(Succeed())
+			Expect(page.Data).To(HaveLen(2))
+			Expect(page.Meta.NextCursor).To(Equal("next"))
+			Expect(page.Meta.Total).To(Equal(int64(3)))
 		})
-		It("should return 404 not found", func() {
-			request, err := http.NewRequest("GET", "/api/v1/users", nil)
+		It("should return 400 on invalid limit", func() {
+			request, err := http.NewRequest("GET", "/api/v1/users?limit=0", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			userHandler.GetAllUsers(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return 400 on invalid sort column", func() {
+			request, err := http.NewRequest("GET", "/api/v1/users?sort=password", nil)
 			Expect(err).NotTo(HaveOccurred())
-			request.Header.Set("Content-Type", "application/json")
 
 			mockRepo.EXPECT().
-				GetAllUsers().
-				Return([]models.UserResponse{}, errors.New("no users found"))
+				GetAllUsers(gomock.Any(), gomock.Any()).
+				Return(nil, fmt.Errorf("failed to get all users: %w", repositories.ErrInvalidSort))
 
 			userHandler.GetAllUsers(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
 		})
-		It("should return 404 not found", func() {
+		It("should return 500 on database error", func() {
 			request, err := http.NewRequest("GET", "/api/v1/users", nil)
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 
-			mockRepo.EXPECT().GetAllUsers().Return(nil, errors.New("database error"))
+			mockRepo.EXPECT().GetAllUsers(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
 
 			userHandler.GetAllUsers(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+			Expect(responseRecorder.Code).To(Equal(http.StatusInternalServerError))
 		})
 	})
 
@@ -139,7 +167,6 @@ var _ = Describe("User Handler", func() {
 			user := &models.User{
 				Username: "updated_username",
 				Email:    "updated_email@example.com",
-				Role:     "user",
 			}
 
 			userResponse := &models.UserResponse{
@@ -154,26 +181,25 @@ var _ = Describe("User Handler", func() {
 			request, err := http.NewRequest("PUT", "/api/v1/users/1", bytes.NewBuffer(userJSON))
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
-			request.AddCookie(&http.Cookie{Name: "userID", Value: "1"})
-			request.AddCookie(&http.Cookie{Name: "username", Value: "testuser"})
+			request = authenticatedAs(request, "1", "testuser")
 
 			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
 			user.ID = "1"
 
 			mockRepo.EXPECT().
-				GetUserByUsername("testuser").
+				GetUserByID(gomock.Any(), "1").
 				Return(userResponse, nil).
 				Times(1)
 
 			mockRepo.EXPECT().
-				UpdateUser(user).
+				UpdateUser(gomock.Any(), user).
 				Return(nil).
 				Times(1)
 
 			userHandler.UpdateUser(responseRecorder, request)
 			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
 		})
-		It("should return 401 Unauthorized", func() {
+		It("should return 403 Forbidden for another user", func() {
 			user := models.User{Username: "testuser"}
 
 			requestBody, err := json.Marshal(user)
@@ -181,14 +207,17 @@ var _ = Describe("User Handler", func() {
 			request, err := http.NewRequest("PUT", "/api/v1/users/1", bytes.NewBuffer(requestBody))
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
-			request.AddCookie(&http.Cookie{Name: "userID", Value: "2"})
+			request = authenticatedAs(request, "2", "other")
+
+			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
 
 			userHandler.UpdateUser(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
-			Expect(responseRecorder.Body.String()).To(Equal("Unauthorized\n"))
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(responseRecorder.Header().Get("Content-Type")).To(Equal(apperrors.ProblemContentType))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal(apperrors.CodeForbidden))
 		})
-		It("should return 404 Not Found, missing cookie", func() {
+		It("should ignore forged identity cookies", func() {
 			user := models.User{Username: "testuser"}
 
 			requestBody, err := json.Marshal(user)
@@ -197,14 +226,14 @@ var _ = Describe("User Handler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
 			request.AddCookie(&http.Cookie{Name: "userID", Value: "1"})
+			request.AddCookie(&http.Cookie{Name: "username", Value: "testuser"})
 
 			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
-			user.ID = "1"
 
 			userHandler.UpdateUser(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
-			Expect(responseRecorder.Body.String()).To(Equal("Cookie not found\n"))
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal(apperrors.CodeUnauthorized))
 		})
 		It("should return 404 Not Found in repository", func() {
 			user := models.User{Username: "testuser"}
@@ -214,21 +243,152 @@ var _ = Describe("User Handler", func() {
 			request, err := http.NewRequest("PUT", "/api/v1/users/1", bytes.NewBuffer(requestBody))
 			Expect(err).NotTo(HaveOccurred())
 			request.Header.Set("Content-Type", "application/json")
-			request.AddCookie(&http.Cookie{Name: "userID", Value: "1"})
-			request.AddCookie(&http.Cookie{Name: "username", Value: "testuser"})
+			request = authenticatedAs(request, "1", "testuser")
 
 			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
 			user.ID = "1"
 
 			mockRepo.EXPECT().
-				GetUserByUsername(user.Username).
-				Return(nil, errors.New("user not found")).
+				GetUserByID(gomock.Any(), "1").
+				Return(nil, repositories.ErrUserNotFound).
 				Times(1)
 
 			userHandler.UpdateUser(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
-			Expect(responseRecorder.Body.String()).To(Equal("User not found\n"))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("user_not_found"))
+		})
+		It("should return 403 Forbidden for a role change", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/users/1", bytes.NewBufferString(`{"email":"testuser@example.com","role":"admin"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticatedAs(request, "1", "testuser")
+			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
+
+			mockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+
+			userHandler.UpdateUser(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("role_change_forbidden"))
+		})
+	})
+
+	Describe("UpdateUserRole", func() {
+		It("should change the role of the user", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/users/2/role", bytes.NewBufferString(`{"role":"editor"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-Match", `"3"`)
+			request = mux.SetURLVars(request, map[string]string{"user_id": "2"})
+
+			mockRepo.EXPECT().UpdateUserRole(gomock.Any(), "2", "editor", int64(3)).Return(nil).Times(1)
+
+			userHandler.UpdateUserRole(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 422 for an unknown role", func() {
+			request, err := http.NewRequest("PUT", "/api/v1/users/2/role", bytes.NewBufferString(`{"role":"root"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = mux.SetURLVars(request, map[string]string{"user_id": "2"})
+
+			userHandler.UpdateUserRole(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	Describe("PatchUser", func() {
+		It("should change the email without touching other fields", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/users/1", bytes.NewBufferString(`{"email":"new@example.com"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/merge-patch+json")
+			request = authenticatedAs(request, "1", "testuser")
+			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
+
+			mockRepo.EXPECT().
+				GetUserByID(gomock.Any(), "1").
+				Return(&models.UserResponse{ID: "1", Username: "testuser", Email: "testuser@example.com", Role: "editor", Version: 2}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				PatchUser(gomock.Any(), "1", models.UserPatch{
+					Username: "testuser",
+					Email:    "new@example.com",
+					Role:     "editor",
+					Fields:   models.PatchFields{"email": true},
+					Version:  2,
+				}).
+				Return(nil).
+				Times(1)
+
+			userHandler.PatchUser(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		DescribeTable("should return 403 Forbidden for a role change",
+			func(contentType, body string) {
+				request, err := http.NewRequest("PATCH", "/api/v1/users/1", bytes.NewBufferString(body))
+				Expect(err).NotTo(HaveOccurred())
+				request.Header.Set("Content-Type", contentType)
+				request = authenticatedAs(request, "1", "testuser")
+				request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
+
+				mockRepo.EXPECT().
+					GetUserByID(gomock.Any(), "1").
+					Return(&models.UserResponse{ID: "1", Username: "testuser", Email: "testuser@example.com", Role: "user", Version: 2}, nil).
+					Times(1)
+				mockRepo.EXPECT().PatchUser(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				userHandler.PatchUser(responseRecorder, request)
+				Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
+				Expect(decodeProblem(responseRecorder).Code).To(Equal("role_change_forbidden"))
+			},
+			Entry("merge patch", "application/merge-patch+json", `{"role":"admin"}`),
+			Entry("merge patch clearing it", "application/merge-patch+json", `{"role":null}`),
+			Entry("JSON patch", "application/json-patch+json", `[{"op":"replace","path":"/role","value":"admin"}]`),
+		)
+		It("should allow testing the role in a JSON patch", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/users/1", bytes.NewBufferString(`[{"op":"test","path":"/role","value":"user"},{"op":"replace","path":"/email","value":"new@example.com"}]`))
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Content-Type", "application/json-patch+json")
+			request = authenticatedAs(request, "1", "testuser")
+			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
+
+			mockRepo.EXPECT().
+				GetUserByID(gomock.Any(), "1").
+				Return(&models.UserResponse{ID: "1", Username: "testuser", Email: "testuser@example.com", Role: "user", Version: 2}, nil).
+				Times(1)
+			mockRepo.EXPECT().
+				PatchUser(gomock.Any(), "1", gomock.Any()).
+				Return(nil).
+				Times(1)
+
+			userHandler.PatchUser(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should reject the password as an unknown field", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/users/1", bytes.NewBufferString(`{"password":"hunter22"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticatedAs(request, "1", "testuser")
+			request = mux.SetURLVars(request, map[string]string{"user_id": "1"})
+
+			mockRepo.EXPECT().
+				GetUserByID(gomock.Any(), "1").
+				Return(&models.UserResponse{ID: "1", Username: "testuser", Email: "testuser@example.com"}, nil).
+				Times(1)
+			mockRepo.EXPECT().PatchUser(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			userHandler.PatchUser(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+		It("should return 403 Forbidden for another user", func() {
+			request, err := http.NewRequest("PATCH", "/api/v1/users/2", bytes.NewBufferString(`{"email":"x@example.com"}`))
+			Expect(err).NotTo(HaveOccurred())
+			request = authenticatedAs(request, "1", "testuser")
+			request = mux.SetURLVars(request, map[string]string{"user_id": "2"})
+
+			mockRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(0)
+
+			userHandler.PatchUser(responseRecorder, request)
+			Expect(responseRecorder.Code).To(Equal(http.StatusForbidden))
 		})
 	})
 
@@ -241,13 +401,13 @@ var _ = Describe("User Handler", func() {
 			userHandler.CreateUser(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
-			Expect(responseRecorder.Body.String()).To(ContainSubstring("Failed to encode request"))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal(apperrors.CodeInvalidBody))
 		})
-		It("should return 404 Not Found if user already exists", func() {
+		It("should return 409 Conflict if user already exists", func() {
 			user := &models.User{
 				Username: "testuser",
 				Email:    "test@example.com",
-				Password: "password",
+				Password: "password1",
 				Role:     "user",
 			}
 
@@ -259,20 +419,20 @@ var _ = Describe("User Handler", func() {
 			request.Header.Set("Content-Type", "application/json")
 
 			mockRepo.EXPECT().
-				CreateUser(gomock.Any()).
-				Return(errors.New("user already exists")).
+				CreateUser(gomock.Any(), gomock.Any()).
+				Return(repositories.ErrUserAlreadyExists).
 				Times(1)
 
 			userHandler.CreateUser(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
-			Expect(responseRecorder.Body.String()).To(ContainSubstring("Failed to create user"))
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			Expect(decodeProblem(responseRecorder).Code).To(Equal("user_already_exists"))
 		})
-		It("should return 200 OK if user is created successfully", func() {
+		It("should return 201 Created if user is created successfully", func() {
 			user := &models.User{
 				Username: "testuser",
 				Email:    "test@example.com",
-				Password: "password",
+				Password: "password1",
 				Role:     "user",
 			}
 
@@ -284,13 +444,13 @@ var _ = Describe("User Handler", func() {
 			request.Header.Set("Content-Type", "application/json")
 
 			mockRepo.EXPECT().
-				CreateUser(gomock.Any()).
+				CreateUser(gomock.Any(), gomock.Any()).
 				Return(nil).
 				Times(1)
 
 			userHandler.CreateUser(responseRecorder, request)
 
-			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
 		})
 	})
 
@@ -302,14 +462,13 @@ var _ = Describe("User Handler", func() {
 			userID := mux.Vars(request)["user_id"]
 
 			mockRepo.EXPECT().
-				DeleteUser(userID).
-				Return(errors.New("user not found")).
+				DeleteUser(gomock.Any(), userID, int64(0)).
+				Return(repositories.ErrUserNotFound).
 				Times(1)
 
 			userHandler.DeleteUser(responseRecorder, request)
 
 			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
-			Expect(responseRecorder.Body.String()).To(ContainSubstring("Failed to delete user"))
 		})
 		It("should return 200 OK if user is deleted successfully", func() {
 			request, err := http.NewRequest("DELETE", "/api/v1/users/{user_id}", nil)
@@ -318,7 +477,7 @@ var _ = Describe("User Handler", func() {
 			userID := mux.Vars(request)["user_id"]
 
 			mockRepo.EXPECT().
-				DeleteUser(userID).
+				DeleteUser(gomock.Any(), userID, int64(0)).
 				Return(nil).
 				Times(1)
 
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/internal/services"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+
+	"github.com/gorilla/mux"
+)
+
+var errWebhookDisabled = apperrors.Conflict("webhook_disabled", "webhook subscription is disabled")
+
+type Webhooker interface {
+	CreateWebhookHandler(w http.ResponseWriter, r *http.Request)
+	ListWebhooksHandler(w http.ResponseWriter, r *http.Request)
+	TestWebhookHandler(w http.ResponseWriter, r *http.Request)
+	DisableWebhookHandler(w http.ResponseWriter, r *http.Request)
+}
+
+type WebhookHandler struct {
+	webhooks repositories.WebhookRepository
+	tester   services.WebhookTester
+}
+
+func NewWebhookHandler(webhooks repositories.WebhookRepository, tester services.WebhookTester) Webhooker {
+	return &WebhookHandler{
+		webhooks: webhooks,
+		tester:   tester,
+	}
+}
+
+// CreateWebhookHandler registers a subscription and answers 201 with it.
+// The generated secret is only ever shown in this response.
+func (h *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		apperrors.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	var request models.WebhookSubscriptionRequest
+
+	err := decodeValidBody(r, &request)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	err = validateWebhook(request)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	secret, err := webhookSecret()
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:        request.URL,
+		Secret:     secret,
+		EventTypes: request.EventTypes,
+		CreatedBy:  principal.UserID,
+	}
+
+	err = h.webhooks.CreateWebhook(r.Context(), subscription)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/webhooks/"+subscription.ID)
+	writeJSON(w, http.StatusCreated, subscription)
+}
+
+func (h *WebhookHandler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscriptions)
+}
+
+// TestWebhookHandler sends a ping event to an active subscription and
+// answers with how its endpoint responded.
+func (h *WebhookHandler) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.webhooks.GetWebhook(r.Context(), mux.Vars(r)["webhook_id"])
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	if !subscription.Active {
+		apperrors.Write(w, r, errWebhookDisabled)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.tester.Test(r.Context(), subscription))
+}
+
+// DisableWebhookHandler stops a subscription from receiving events. Its
+// pending deliveries are cancelled.
+func (h *WebhookHandler) DisableWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	err := h.webhooks.DisableWebhook(r.Context(), mux.Vars(r)["webhook_id"])
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// validateWebhook checks what the validate tags cannot: that the URL is an
+// absolute http or https URL and that every event type exists.
+func validateWebhook(request models.WebhookSubscriptionRequest) error {
+	fields := make(map[string][]string)
+
+	endpoint, err := url.Parse(request.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		fields["url"] = []string{"must be an absolute http or https URL"}
+	}
+
+	known := models.EventTypes()
+
+	for _, eventType := range request.EventTypes {
+		if !slices.Contains(known, eventType) {
+			fields["event_types"] = append(fields["event_types"], fmt.Sprintf("%q is not a known event type", eventType))
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(validation.CodeValidationFailed, "webhook subscription is not valid", fields)
+	}
+
+	return nil
+}
+
+func webhookSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
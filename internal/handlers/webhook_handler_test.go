@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/internal/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook Handler", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockRepo         *mocks.MockWebhookRepository
+		webhookHandler   Webhooker
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	withWebhookID := func(method, path, id string) *http.Request {
+		request, err := http.NewRequest(method, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		return mux.SetURLVars(request, map[string]string{"webhook_id": id})
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockWebhookRepository(mockCtrl)
+
+		dispatcher := services.NewWebhookDispatcher(mockRepo, configs.DefaultConfig().Webhooks)
+		webhookHandler = NewWebhookHandler(mockRepo, dispatcher)
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("CreateWebhookHandler", func() {
+		newRequest := func(body string) *http.Request {
+			request, err := http.NewRequest("POST", "/api/v1/webhooks", bytes.NewBufferString(body))
+			Expect(err).NotTo(HaveOccurred())
+
+			return request.WithContext(authentication.WithPrincipal(request.Context(),
+				&authentication.Principal{UserID: "1", Username: "admin", Role: "admin"}))
+		}
+
+		It("should register the subscription and return 201 with its secret", func() {
+			mockRepo.EXPECT().
+				CreateWebhook(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ interface{}, subscription *models.WebhookSubscription) error {
+					Expect(subscription.URL).To(Equal("https://example.com/hook"))
+					Expect(subscription.EventTypes).To(Equal([]string{"product.created"}))
+					Expect(subscription.CreatedBy).To(Equal("1"))
+
+					subscription.ID = "4"
+					subscription.Active = true
+					return nil
+				}).
+				Times(1)
+
+			webhookHandler.CreateWebhookHandler(responseRecorder,
+				newRequest(`{"url":"https://example.com/hook","event_types":["product.created"]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusCreated))
+			Expect(responseRecorder.Header().Get("Location")).To(Equal("/api/v1/webhooks/4"))
+
+			var subscription models.WebhookSubscription
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&subscription)).To(Succeed())
+			Expect(subscription.Secret).To(HavePrefix("whsec_"))
+			Expect(subscription.Active).To(BeTrue())
+		})
+		It("should return 422 for a relative URL and an unknown event type", func() {
+			webhookHandler.CreateWebhookHandler(responseRecorder,
+				newRequest(`{"url":"/hook","event_types":["product.sold"]}`))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			problem := decodeProblem(responseRecorder)
+			Expect(problem.Errors).To(HaveKey("url"))
+			Expect(problem.Errors).To(HaveKey("event_types"))
+		})
+		It("should return 401 without a principal", func() {
+			request, err := http.NewRequest("POST", "/api/v1/webhooks", bytes.NewBufferString(`{"url":"https://example.com"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			webhookHandler.CreateWebhookHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("ListWebhooksHandler", func() {
+		It("should return 200 with the subscriptions", func() {
+			request, err := http.NewRequest("GET", "/api/v1/webhooks", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockRepo.EXPECT().
+				ListWebhooks(gomock.Any()).
+				Return([]models.WebhookSubscription{{ID: "4", URL: "https://example.com/hook", Active: true}}, nil).
+				Times(1)
+
+			webhookHandler.ListWebhooksHandler(responseRecorder, request)
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var subscriptions []models.WebhookSubscription
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&subscriptions)).To(Succeed())
+			Expect(subscriptions).To(HaveLen(1))
+		})
+	})
+
+	Describe("TestWebhookHandler", func() {
+		It("should send a signed ping to the endpoint", func() {
+			var received *http.Request
+			var body []byte
+
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer endpoint.Close()
+
+			mockRepo.EXPECT().
+				GetWebhook(gomock.Any(), "4").
+				Return(&models.WebhookSubscription{ID: "4", URL: endpoint.URL, Secret: "whsec_1", Active: true}, nil).
+				Times(1)
+
+			webhookHandler.TestWebhookHandler(responseRecorder, withWebhookID("POST", "/api/v1/webhooks/4/test", "4"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+
+			var result models.WebhookTestResult
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&result)).To(Succeed())
+			Expect(result).To(Equal(models.WebhookTestResult{Delivered: true, Status: http.StatusNoContent}))
+
+			Expect(received.Header.Get(services.HeaderWebhookEvent)).To(Equal(models.EventPing))
+
+			signature := received.Header.Get(services.HeaderWebhookSignature)
+			timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signature).To(Equal(services.SignWebhook("whsec_1", time.Unix(timestamp, 0), body)))
+		})
+		It("should report the status of a failing endpoint", func() {
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer endpoint.Close()
+
+			mockRepo.EXPECT().
+				GetWebhook(gomock.Any(), "4").
+				Return(&models.WebhookSubscription{ID: "4", URL: endpoint.URL, Active: true}, nil).
+				Times(1)
+
+			webhookHandler.TestWebhookHandler(responseRecorder, withWebhookID("POST", "/api/v1/webhooks/4/test", "4"))
+
+			var result models.WebhookTestResult
+			Expect(json.NewDecoder(responseRecorder.Body).Decode(&result)).To(Succeed())
+			Expect(result.Delivered).To(BeFalse())
+			Expect(result.Status).To(Equal(http.StatusInternalServerError))
+		})
+		It("should return 409 for a disabled subscription", func() {
+			mockRepo.EXPECT().
+				GetWebhook(gomock.Any(), "4").
+				Return(&models.WebhookSubscription{ID: "4", URL: "https://example.com/hook"}, nil).
+				Times(1)
+
+			webhookHandler.TestWebhookHandler(responseRecorder, withWebhookID("POST", "/api/v1/webhooks/4/test", "4"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("DisableWebhookHandler", func() {
+		It("should return 200 once the subscription is disabled", func() {
+			mockRepo.EXPECT().DisableWebhook(gomock.Any(), "4").Return(nil).Times(1)
+
+			webhookHandler.DisableWebhookHandler(responseRecorder, withWebhookID("POST", "/api/v1/webhooks/4/disable", "4"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusOK))
+		})
+		It("should return 404 for an unknown subscription", func() {
+			mockRepo.EXPECT().DisableWebhook(gomock.Any(), "5").Return(repositories.ErrWebhookNotFound).Times(1)
+
+			webhookHandler.DisableWebhookHandler(responseRecorder, withWebhookID("POST", "/api/v1/webhooks/5/disable", "5"))
+
+			Expect(responseRecorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
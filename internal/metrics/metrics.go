@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"awesomeProject/internal/middlewares/middleware"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics collects the Prometheus metrics of the service: requests per route
+// template, statements per query name and the stats of the connection pool.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route template, method and status class.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests by route template and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Latency of database statements by query name.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"query"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Failed database statements by query name.",
+		}, []string{"query"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.queryErrors,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDatabase adds the stats of the connection pool of db.
+func (m *Metrics) RegisterDatabase(db interface{ Stats() sql.DBStats }) {
+	m.registry.MustRegister(newPoolCollector(db.Stats))
+}
+
+// Middleware records the count and latency of the requests of a route. It
+// goes before authentication in the chain, so requests rejected by it are
+// counted as well.
+func (m *Metrics) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := middleware.RouteTemplate(r)
+		recorder := middleware.NewStatusWriter(w)
+		started := time.Now()
+
+		next(recorder, r)
+
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(started).Seconds())
+		m.requests.WithLabelValues(route, r.Method, statusClass(recorder.Status())).Inc()
+	}
+}
+
+// StartQuery records the latency of the statement named name and counts it
+// as failed when it returns an error. It makes Metrics a
+// database.QueryObserver.
+func (m *Metrics) StartQuery(ctx context.Context, name string) (context.Context, func(err error)) {
+	started := time.Now()
+
+	return ctx, func(err error) {
+		m.queryDuration.WithLabelValues(name).Observe(time.Since(started).Seconds())
+
+		if err != nil {
+			m.queryErrors.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// statusClass returns the class of status, e.g. 4xx.
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
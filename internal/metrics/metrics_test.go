@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var m *Metrics
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		return recorder.Body.String()
+	}
+
+	BeforeEach(func() {
+		m = New()
+	})
+
+	Describe("Middleware", func() {
+		It("should count requests by route template and status class", func() {
+			router := mux.NewRouter()
+			router.HandleFunc("/products/{product_id}", m.Middleware(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})).Methods("GET")
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/products/7", nil))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/products/8", nil))
+
+			body := scrape()
+			Expect(body).To(ContainSubstring(`http_requests_total{method="GET",route="/products/{product_id}",status="4xx"} 2`))
+			Expect(body).To(ContainSubstring(`http_request_duration_seconds_count{method="GET",route="/products/{product_id}"} 2`))
+		})
+
+		It("should count a response without an explicit status as 2xx", func() {
+			handler := m.Middleware(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			})
+
+			handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			Expect(scrape()).To(ContainSubstring(`http_requests_total{method="GET",route="unknown",status="2xx"} 1`))
+		})
+	})
+
+	Describe("StartQuery", func() {
+		It("should record the latency and errors of a query by name", func() {
+			_, done := m.StartQuery(context.Background(), "GetProduct")
+			done(nil)
+			_, done = m.StartQuery(context.Background(), "GetProduct")
+			done(errors.New("connection reset"))
+
+			body := scrape()
+			Expect(body).To(ContainSubstring(`db_query_duration_seconds_count{query="GetProduct"} 2`))
+			Expect(body).To(ContainSubstring(`db_query_errors_total{query="GetProduct"} 1`))
+		})
+	})
+
+	Describe("RegisterDatabase", func() {
+		It("should report the stats of the connection pool", func() {
+			db, _, err := sqlmock.New()
+			Expect(err).NotTo(HaveOccurred())
+			defer db.Close()
+
+			db.SetMaxOpenConns(7)
+			m.RegisterDatabase(db)
+
+			Expect(scrape()).To(ContainSubstring("db_pool_max_open_connections 7"))
+		})
+	})
+})
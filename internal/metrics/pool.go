@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reports the stats of a connection pool at every scrape.
+type poolCollector struct {
+	stats func() sql.DBStats
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newPoolCollector(stats func() sql.DBStats) *poolCollector {
+	return &poolCollector{
+		stats:             stats,
+		maxOpen:           prometheus.NewDesc("db_pool_max_open_connections", "Maximum number of open connections.", nil, nil),
+		open:              prometheus.NewDesc("db_pool_open_connections", "Open connections, in use and idle.", nil, nil),
+		inUse:             prometheus.NewDesc("db_pool_in_use_connections", "Connections in use.", nil, nil),
+		idle:              prometheus.NewDesc("db_pool_idle_connections", "Idle connections.", nil, nil),
+		waitCount:         prometheus.NewDesc("db_pool_wait_count_total", "Times a statement waited for a connection.", nil, nil),
+		waitDuration:      prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Time spent waiting for a connection.", nil, nil),
+		maxIdleClosed:     prometheus.NewDesc("db_pool_max_idle_closed_total", "Connections closed because of the idle limit.", nil, nil),
+		maxIdleTimeClosed: prometheus.NewDesc("db_pool_max_idle_time_closed_total", "Connections closed because of the idle time limit.", nil, nil),
+		maxLifetimeClosed: prometheus.NewDesc("db_pool_max_lifetime_closed_total", "Connections closed because of the lifetime limit.", nil, nil),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
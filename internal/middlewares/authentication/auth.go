@@ -1,59 +1,104 @@
 package authentication
 
 import (
+	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
+	"strings"
 	"time"
 
+	"awesomeProject/internal/middlewares/logging"
+	"awesomeProject/internal/middlewares/middleware"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
 	"awesomeProject/pkg/utils"
 )
 
-func IsAuthenticated(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		claims, err := GetTokenFromCookie(r)
-		if err != nil {
-			http.Error(w, "Couldn't get token", http.StatusUnauthorized)
-		}
+const TokenCookie = "token"
+
+var (
+	ErrTokenMissing = errors.New("token missing")
+
+	errInvalidToken   = apperrors.Unauthorized("invalid_token", "missing or invalid token")
+	errSessionRevoked = apperrors.Unauthorized("session_revoked", "session revoked")
+)
+
+func IsAuthenticated(keys *utils.KeyManager, sessions repositories.SessionRepository) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, err := GetTokenClaims(r, keys)
+			if err != nil {
+				apperrors.Write(w, r, errInvalidToken.Wrap(err))
+				return
+			}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "username",
-			Value:    claims["username"].(string),
-			Path:     "/",
-			Secure:   false,
-			HttpOnly: true,
-		})
+			principal := NewPrincipal(claims)
+			if principal.UserID == "" || principal.SessionID == "" {
+				apperrors.Write(w, r, errInvalidToken)
+				return
+			}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "userID",
-			Value:    claims["userID"].(string),
-			Path:     "/",
-			Secure:   false,
-			HttpOnly: true,
-		})
+			active, err := sessions.IsSessionActive(r.Context(), principal.SessionID)
+			if err != nil {
+				apperrors.Write(w, r, err)
+				return
+			}
 
-		next.ServeHTTP(w, r)
+			if !active {
+				apperrors.Write(w, r, errSessionRevoked)
+				return
+			}
+
+			logging.SetUserID(r.Context(), principal.UserID)
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
 	}
 }
 
-func GetTokenFromCookie(r *http.Request) (jwt.MapClaims, error) {
-	token, err := r.Cookie("token")
+// GetTokenClaims verifies the token sent either as "Authorization: Bearer"
+// header, for non-browser clients, or as the token cookie.
+func GetTokenClaims(r *http.Request, keys *utils.KeyManager) (jwt.MapClaims, error) {
+	token, err := TokenFromRequest(r)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, err := utils.VerifyJWT(token.Value)
+	claims, err := utils.VerifyJWT(token, keys)
 	if err != nil {
 		return nil, err
 	}
 
+	if claims == nil {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
 	expirationDate, err := claims.GetExpirationTime()
 	if err != nil {
 		return nil, err
 	}
 
-	if expirationDate.Before(time.Now()) {
-		return nil, err
+	if expirationDate == nil || expirationDate.Before(time.Now()) {
+		return nil, jwt.ErrTokenExpired
 	}
 
 	return claims, nil
 }
+
+func TokenFromRequest(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", ErrTokenMissing
+		}
+
+		return strings.TrimSpace(token), nil
+	}
+
+	cookie, err := r.Cookie(TokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", ErrTokenMissing
+	}
+
+	return cookie.Value, nil
+}
@@ -0,0 +1,105 @@
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/handlers/mocks"
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/utils"
+
+	"github.com/golang/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IsAuthenticated", func() {
+	var (
+		mockCtrl     *gomock.Controller
+		mockSessions *mocks.MockSessionRepository
+		keys         *utils.KeyManager
+		token        string
+		principal    *Principal
+		handler      http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockSessions = mocks.NewMockSessionRepository(mockCtrl)
+
+		var err error
+		keys, err = utils.NewKeyManager(configs.JWT{Secret: "test-secret"})
+		Expect(err).NotTo(HaveOccurred())
+
+		token, err = utils.GenerateJWT(models.UserResponse{ID: "1", Username: "test", Role: "admin"}, "session", keys, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		principal = nil
+		handler = IsAuthenticated(keys, mockSessions)(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = PrincipalFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should store the principal from the token cookie", func() {
+		request := httptest.NewRequest("GET", "/api/v1/users", nil)
+		request.AddCookie(&http.Cookie{Name: TokenCookie, Value: token})
+		mockSessions.EXPECT().IsSessionActive(gomock.Any(), "session").Return(true, nil)
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(principal.UserID).To(Equal("1"))
+		Expect(principal.Role).To(Equal("admin"))
+		Expect(recorder.Result().Cookies()).To(BeEmpty())
+	})
+
+	It("should accept a bearer token", func() {
+		request := httptest.NewRequest("GET", "/api/v1/users", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		mockSessions.EXPECT().IsSessionActive(gomock.Any(), "session").Return(true, nil)
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(principal.SessionID).To(Equal("session"))
+	})
+
+	It("should reject a malformed authorization header", func() {
+		request := httptest.NewRequest("GET", "/api/v1/users", nil)
+		request.Header.Set("Authorization", "Basic "+token)
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(principal).To(BeNil())
+	})
+
+	It("should reject a revoked session", func() {
+		request := httptest.NewRequest("GET", "/api/v1/users", nil)
+		request.AddCookie(&http.Cookie{Name: TokenCookie, Value: token})
+		mockSessions.EXPECT().IsSessionActive(gomock.Any(), "session").Return(false, nil)
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject a request without a token", func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest("GET", "/api/v1/users", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+	})
+})
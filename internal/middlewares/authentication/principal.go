@@ -0,0 +1,49 @@
+package authentication
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type principalKey struct{}
+
+// Principal is the verified identity of the caller. It is only ever built from
+// a validated token, so handlers can rely on it instead of client input.
+type Principal struct {
+	UserID    string
+	Username  string
+	Email     string
+	Role      string
+	SessionID string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+func NewPrincipal(claims jwt.MapClaims) *Principal {
+	principal := &Principal{}
+
+	principal.UserID, _ = claims["userID"].(string)
+	principal.Username, _ = claims["username"].(string)
+	principal.Email, _ = claims["email"].(string)
+	principal.Role, _ = claims["role"].(string)
+	principal.SessionID, _ = claims["sid"].(string)
+	principal.TokenID, _ = claims["jti"].(string)
+
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		principal.ExpiresAt = expiresAt.Time
+	}
+
+	return principal
+}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return principal, ok && principal != nil
+}
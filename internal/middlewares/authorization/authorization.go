@@ -0,0 +1,107 @@
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/middlewares/middleware"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
+)
+
+const (
+	UsersRead        = "users:read"
+	UsersWrite       = "users:write"
+	UsersDelete      = "users:delete"
+	ProductsWrite    = "products:write"
+	ProductsDelete   = "products:delete"
+	CategoriesWrite  = "categories:write"
+	CategoriesDelete = "categories:delete"
+	TrashManage      = "trash:manage"
+	WebhooksManage   = "webhooks:manage"
+)
+
+type cachedPermissions struct {
+	permissions map[string]struct{}
+	expiresAt   time.Time
+}
+
+// Authorizer resolves the role of the authenticated principal to the permissions stored
+// in the database. Lookups are cached for cacheTTL so a role change applies
+// shortly after it is made without hitting the database on every request.
+type Authorizer struct {
+	roles    repositories.RoleRepository
+	cacheTTL time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions
+}
+
+func NewAuthorizer(roles repositories.RoleRepository, cacheTTL time.Duration) *Authorizer {
+	return &Authorizer{
+		roles:    roles,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedPermissions),
+	}
+}
+
+func (a *Authorizer) RequirePermission(permission string) middleware.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := authentication.PrincipalFromContext(r.Context())
+			if !ok {
+				apperrors.Write(w, r, apperrors.Unauthorized(apperrors.CodeUnauthorized, "authentication required"))
+				return
+			}
+
+			allowed, err := a.HasPermission(r.Context(), principal.Role, permission)
+			if err != nil {
+				apperrors.Write(w, r, err)
+				return
+			}
+
+			if !allowed {
+				apperrors.Write(w, r, apperrors.Forbidden(apperrors.CodeForbidden, "missing permission "+permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+func (a *Authorizer) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	if role == "" {
+		return false, nil
+	}
+
+	a.mu.RLock()
+	cached, ok := a.cache[role]
+	a.mu.RUnlock()
+
+	if !ok || time.Now().After(cached.expiresAt) {
+		permissions, err := a.roles.GetRolePermissions(ctx, role)
+		if err != nil {
+			return false, err
+		}
+
+		cached = cachedPermissions{
+			permissions: make(map[string]struct{}, len(permissions)),
+			expiresAt:   time.Now().Add(a.cacheTTL),
+		}
+		for _, p := range permissions {
+			cached.permissions[p] = struct{}{}
+		}
+
+		a.mu.Lock()
+		a.cache[role] = cached
+		a.mu.Unlock()
+	}
+
+	_, allowed := cached.permissions[permission]
+
+	return allowed, nil
+}
@@ -0,0 +1,101 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"awesomeProject/internal/middlewares/authentication"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeRoles struct {
+	permissions map[string][]string
+	calls       int
+	err         error
+}
+
+func (f *fakeRoles) GetRolePermissions(_ context.Context, role string) ([]string, error) {
+	f.calls++
+	return f.permissions[role], f.err
+}
+
+var _ = Describe("Authorizer", func() {
+	var (
+		roles      *fakeRoles
+		authorizer *Authorizer
+		handler    http.HandlerFunc
+	)
+
+	requestAs := func(role string) *http.Request {
+		request := httptest.NewRequest("DELETE", "/api/v1/products/1", nil)
+
+		return request.WithContext(authentication.WithPrincipal(request.Context(), &authentication.Principal{UserID: "1", Role: role}))
+	}
+
+	BeforeEach(func() {
+		roles = &fakeRoles{permissions: map[string][]string{
+			"admin":  {ProductsWrite, ProductsDelete},
+			"editor": {ProductsWrite},
+		}}
+		authorizer = NewAuthorizer(roles, time.Minute)
+		handler = authorizer.RequirePermission(ProductsDelete)(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	It("should allow a role with the permission", func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, requestAs("admin"))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should forbid a role without the permission", func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, requestAs("editor"))
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should forbid an unknown role", func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, requestAs("guest"))
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should return 401 without a principal", func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest("DELETE", "/api/v1/products/1", nil))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should return 500 when permissions can't be loaded", func() {
+		roles.err = errors.New("db error")
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, requestAs("admin"))
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+	})
+
+	It("should cache role permissions", func() {
+		for i := 0; i < 3; i++ {
+			allowed, err := authorizer.HasPermission(context.Background(), "admin", ProductsDelete)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		}
+		Expect(roles.calls).To(Equal(1))
+	})
+
+	It("should reload permissions after the cache expires", func() {
+		authorizer = NewAuthorizer(roles, 0)
+
+		_, err := authorizer.HasPermission(context.Background(), "admin", ProductsDelete)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = authorizer.HasPermission(context.Background(), "admin", ProductsDelete)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roles.calls).To(Equal(2))
+	})
+})
@@ -1,40 +1,151 @@
 package logging
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/middlewares/middleware"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+// HeaderRequestID carries the ID of a request, from the client when it sends
+// one and back to it in the response.
+const HeaderRequestID = "X-Request-ID"
+
+const redacted = "[REDACTED]"
+
+// requestIDPattern limits the request IDs taken from clients to what fits
+// into a log line unescaped.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type loggingKey struct{}
+
 type Logging struct {
 	RequestID     string
 	RequestMethod string
 	RequestURL    *url.URL
 	RequestHeader http.Header
+	// UserID is set by the authentication middleware once the caller is
+	// known.
+	UserID string
 }
 
+// NewLogging describes r, reusing the request ID sent by the client when it
+// is well-formed.
 func NewLogging(r *http.Request) *Logging {
+	requestID := r.Header.Get(HeaderRequestID)
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+
 	return &Logging{
-		RequestID:     uuid.NewString(),
+		RequestID:     requestID,
 		RequestURL:    r.URL,
 		RequestMethod: r.Method,
 		RequestHeader: r.Header,
 	}
 }
 
-func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// SetUserID records the caller of the request logged in ctx. It does nothing
+// for requests that are not logged.
+func SetUserID(ctx context.Context, userID string) {
+	if trace, ok := ctx.Value(loggingKey{}).(*Logging); ok {
+		trace.UserID = userID
+	}
+}
+
+// Logger writes an access log entry for every request, with the values of
+// the configured headers and cookies redacted.
+type Logger struct {
+	headers map[string]bool
+	cookies map[string]bool
+}
+
+func New(config configs.Logging) *Logger {
+	logger := &Logger{
+		headers: make(map[string]bool, len(config.RedactHeaders)),
+		cookies: make(map[string]bool, len(config.RedactCookies)),
+	}
+
+	for _, name := range config.RedactHeaders {
+		logger.headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	for _, name := range config.RedactCookies {
+		logger.cookies[name] = true
+	}
+
+	return logger
+}
+
+// Middleware logs the request once it was handled, with the status, size and
+// duration of its response. The request ID is returned as X-Request-ID.
+func (l *Logger) Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		trace := NewLogging(r)
+		trace.RequestHeader = l.redact(r.Header)
+
+		w.Header().Set(HeaderRequestID, trace.RequestID)
+
+		recorder := middleware.NewStatusWriter(w)
+		started := time.Now()
+
+		next(recorder, r.WithContext(context.WithValue(r.Context(), loggingKey{}, trace)))
 
 		logrus.WithFields(logrus.Fields{
 			"request_id":     trace.RequestID,
 			"request_url":    trace.RequestURL.String(),
 			"request_method": trace.RequestMethod,
 			"request_header": trace.RequestHeader,
-		}).Info("Request received")
+			"user_id":        trace.UserID,
+			"status":         recorder.Status(),
+			"bytes":          recorder.Bytes(),
+			"duration_ms":    float64(time.Since(started).Microseconds()) / 1000,
+		}).Info("Request handled")
+	}
+}
+
+// redact returns a copy of header with the values of the redacted headers
+// and cookies replaced.
+func (l *Logger) redact(header http.Header) http.Header {
+	redactedHeader := header.Clone()
 
-		next.ServeHTTP(w, r)
+	for name, values := range redactedHeader {
+		switch {
+		case l.headers[name]:
+			redactedHeader[name] = []string{redacted}
+		case name == "Cookie":
+			redactedHeader[name] = l.redactCookies(values)
+		}
 	}
+
+	return redactedHeader
+}
+
+func (l *Logger) redactCookies(values []string) []string {
+	redactedValues := make([]string, len(values))
+
+	for i, value := range values {
+		cookies := strings.Split(value, ";")
+
+		for j, cookie := range cookies {
+			name, _, found := strings.Cut(strings.TrimSpace(cookie), "=")
+			if found && l.cookies[name] {
+				cookies[j] = name + "=" + redacted
+			} else {
+				cookies[j] = strings.TrimSpace(cookie)
+			}
+		}
+
+		redactedValues[i] = strings.Join(cookies, "; ")
+	}
+
+	return redactedValues
 }
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"awesomeProject/configs"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		hook   *test.Hook
+		logger *Logger
+	)
+
+	BeforeEach(func() {
+		hook = test.NewGlobal()
+		DeferCleanup(hook.Reset)
+
+		logger = New(configs.DefaultConfig().Logging)
+	})
+
+	It("should log the response with the user and redacted credentials", func() {
+		handler := logger.Middleware(func(w http.ResponseWriter, r *http.Request) {
+			SetUserID(r.Context(), "7")
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+		})
+
+		request := httptest.NewRequest("POST", "/api/v1/products", nil)
+		request.Header.Set("Authorization", "Bearer secret")
+		request.Header.Set("Accept", "application/json")
+		request.AddCookie(&http.Cookie{Name: "token", Value: "jwt"})
+		request.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		entry := hook.LastEntry()
+		Expect(entry).NotTo(BeNil())
+		Expect(entry.Level).To(Equal(logrus.InfoLevel))
+		Expect(entry.Data).To(HaveKeyWithValue("status", http.StatusCreated))
+		Expect(entry.Data).To(HaveKeyWithValue("bytes", int64(10)))
+		Expect(entry.Data).To(HaveKeyWithValue("user_id", "7"))
+		Expect(entry.Data).To(HaveKey("duration_ms"))
+
+		header := entry.Data["request_header"].(http.Header)
+		Expect(header.Get("Authorization")).To(Equal(redacted))
+		Expect(header.Get("Accept")).To(Equal("application/json"))
+		Expect(header.Get("Cookie")).To(Equal("token=[REDACTED]; theme=dark"))
+
+		Expect(request.Header.Get("Authorization")).To(Equal("Bearer secret"))
+		Expect(recorder.Header().Get(HeaderRequestID)).To(Equal(entry.Data["request_id"]))
+	})
+
+	It("should reuse the request ID sent by the client", func() {
+		request := httptest.NewRequest("GET", "/api/v1/products", nil)
+		request.Header.Set(HeaderRequestID, "client-42")
+
+		recorder := httptest.NewRecorder()
+		logger.Middleware(func(http.ResponseWriter, *http.Request) {})(recorder, request)
+
+		Expect(recorder.Header().Get(HeaderRequestID)).To(Equal("client-42"))
+		Expect(hook.LastEntry().Data).To(HaveKeyWithValue("request_id", "client-42"))
+	})
+
+	It("should replace a malformed request ID", func() {
+		request := httptest.NewRequest("GET", "/api/v1/products", nil)
+		request.Header.Set(HeaderRequestID, "forged\nentry")
+
+		recorder := httptest.NewRecorder()
+		logger.Middleware(func(http.ResponseWriter, *http.Request) {})(recorder, request)
+
+		Expect(recorder.Header().Get(HeaderRequestID)).NotTo(BeEmpty())
+		Expect(recorder.Header().Get(HeaderRequestID)).NotTo(Equal("forged\nentry"))
+	})
+})
@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/middlewares/middleware"
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderAPIKey identifies the client of the routes limited by API key.
+const HeaderAPIKey = "X-API-Key"
+
+var errRateLimited = apperrors.TooManyRequests("rate_limited", "too many requests")
+
+// APIKeys verifies the API keys clients send, so that only keys the server
+// stored get a bucket of their own. Any other header value would let a
+// client reset its limit by sending a new one.
+type APIKeys interface {
+	// Verify returns the ID of the stored key matching apiKey, and false
+	// when there is none.
+	Verify(ctx context.Context, apiKey string) (string, bool, error)
+}
+
+// Limiter limits the requests of route groups with a token bucket per group
+// and client. The RateLimit-* headers tell clients about their bucket, a
+// request finding it empty gets 429 with Retry-After.
+type Limiter struct {
+	store   Store
+	apiKeys APIKeys
+	config  configs.RateLimit
+}
+
+// New returns a limiter keeping its buckets in store. apiKeys may be nil, the
+// api_key limits then fall back to the user or the IP. configs.Validate
+// rejects api_key limits until API keys are issued.
+func New(store Store, apiKeys APIKeys, config configs.RateLimit) *Limiter {
+	return &Limiter{
+		store:   store,
+		apiKeys: apiKeys,
+		config:  config,
+	}
+}
+
+// Group returns the middleware limiting the routes of group. Authenticated
+// groups without a rule of their own share the bucket of the api group. The
+// middleware goes after the authentication one, so that requests can be
+// limited by user.
+func (l *Limiter) Group(group string) middleware.Middleware {
+	rule, ok := l.config.Groups[group]
+	if !ok && group != "auth" {
+		group, rule = "api", l.config.Groups["api"]
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return l.limit(group, rule, next)
+	}
+}
+
+// Run forgets idle buckets every period until ctx is done. A bucket left
+// alone for the longest period is full again, so forgetting it changes
+// nothing.
+func (l *Limiter) Run(ctx context.Context) {
+	var idle time.Duration
+	for _, rule := range l.config.Groups {
+		idle = max(idle, rule.Period)
+	}
+
+	if idle <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := l.store.Sweep(ctx, time.Now().Add(-idle))
+		if err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to sweep rate limits")
+		}
+	}
+}
+
+func (l *Limiter) limit(group string, rule configs.RateLimitRule, next http.HandlerFunc) http.HandlerFunc {
+	if rule.Requests == 0 {
+		return next
+	}
+
+	capacity := float64(rule.Requests)
+	rate := capacity / rule.Period.Seconds()
+	limit := strconv.Itoa(rule.Requests)
+	policy := fmt.Sprintf("%d;w=%d", rule.Requests, int(rule.Period.Seconds()))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		remaining, allowed, err := l.store.Take(r.Context(), group+":"+l.key(r, rule.Key), capacity, rate)
+		if err != nil {
+			// A failing store lets requests through rather than taking the
+			// whole API down with it.
+			logrus.WithError(err).Error("Failed to take rate limit token")
+			next(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", limit)
+		header.Set("RateLimit-Remaining", strconv.Itoa(int(remaining)))
+		header.Set("RateLimit-Reset", seconds((capacity-remaining)/rate))
+		header.Set("RateLimit-Policy", policy)
+
+		if !allowed {
+			header.Set("Retry-After", seconds((1-remaining)/rate))
+			apperrors.Write(w, r, errRateLimited)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// key returns the client of r the bucket belongs to. A verified API key is
+// keyed by its ID, so that the store holds no credentials. Requests without
+// one are limited by user, then by IP.
+func (l *Limiter) key(r *http.Request, kind string) string {
+	if kind == "api_key" {
+		if keyID, ok := l.apiKeyID(r); ok {
+			return "api_key:" + keyID
+		}
+	}
+
+	if kind == "user" || kind == "api_key" {
+		if principal, ok := authentication.PrincipalFromContext(r.Context()); ok {
+			return "user:" + principal.UserID
+		}
+	}
+
+	return "ip:" + l.clientIP(r)
+}
+
+// apiKeyID returns the ID of the stored API key r was sent with.
+func (l *Limiter) apiKeyID(r *http.Request) (string, bool) {
+	apiKey := r.Header.Get(HeaderAPIKey)
+	if apiKey == "" || l.apiKeys == nil {
+		return "", false
+	}
+
+	keyID, ok, err := l.apiKeys.Verify(r.Context(), apiKey)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to verify API key")
+		return "", false
+	}
+
+	return keyID, ok
+}
+
+// clientIP returns the address of the client, or the last one a trusted
+// proxy added to X-Forwarded-For. Entries before it are set by the client.
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.config.TrustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// seconds rounds d, in seconds, up to whole seconds.
+func seconds(d float64) string {
+	return strconv.Itoa(int(math.Ceil(d)))
+}
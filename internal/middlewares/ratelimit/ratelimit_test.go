@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/middlewares/authentication"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// failingStore fails every take.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, float64) (float64, bool, error) {
+	return 0, false, errors.New("connection reset")
+}
+
+func (failingStore) Sweep(context.Context, time.Time) error {
+	return nil
+}
+
+// storedAPIKeys knows the API keys by value, with their IDs.
+type storedAPIKeys map[string]string
+
+func (k storedAPIKeys) Verify(_ context.Context, apiKey string) (string, bool, error) {
+	keyID, ok := k[apiKey]
+
+	return keyID, ok, nil
+}
+
+var _ = Describe("Limiter", func() {
+	var (
+		now     time.Time
+		store   *MemoryStore
+		config  configs.RateLimit
+		handled int
+	)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		handled++
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	send := func(handler http.HandlerFunc, request *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		return recorder
+	}
+
+	newRequest := func(remoteAddr string) *http.Request {
+		request := httptest.NewRequest("POST", "/api/v1/login", nil)
+		request.RemoteAddr = remoteAddr
+
+		return request
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		store = NewMemoryStore()
+		store.now = func() time.Time { return now }
+
+		config = configs.DefaultConfig().RateLimit
+		config.Groups["auth"] = configs.RateLimitRule{Requests: 2, Period: 10 * time.Second, Key: "ip"}
+		handled = 0
+	})
+
+	It("should return 429 with Retry-After once the bucket is empty", func() {
+		handler := New(store, nil, config).Group("auth")(ok)
+
+		first := send(handler, newRequest("10.0.0.1:4000"))
+		Expect(first.Code).To(Equal(http.StatusNoContent))
+		Expect(first.Header().Get("RateLimit-Limit")).To(Equal("2"))
+		Expect(first.Header().Get("RateLimit-Remaining")).To(Equal("1"))
+		Expect(first.Header().Get("RateLimit-Reset")).To(Equal("5"))
+		Expect(first.Header().Get("RateLimit-Policy")).To(Equal("2;w=10"))
+
+		Expect(send(handler, newRequest("10.0.0.1:4001")).Code).To(Equal(http.StatusNoContent))
+
+		limited := send(handler, newRequest("10.0.0.1:4002"))
+		Expect(limited.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(limited.Header().Get("Retry-After")).To(Equal("5"))
+		Expect(limited.Header().Get("RateLimit-Remaining")).To(Equal("0"))
+		Expect(handled).To(Equal(2))
+
+		Expect(send(handler, newRequest("10.0.0.2:4000")).Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("should refill the bucket over the period", func() {
+		handler := New(store, nil, config).Group("auth")(ok)
+
+		send(handler, newRequest("10.0.0.1:4000"))
+		send(handler, newRequest("10.0.0.1:4000"))
+		Expect(send(handler, newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusTooManyRequests))
+
+		now = now.Add(5 * time.Second)
+		Expect(send(handler, newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("should limit authenticated routes by user", func() {
+		config.Groups["api"] = configs.RateLimitRule{Requests: 1, Period: time.Minute, Key: "user"}
+		handler := New(store, nil, config).Group("api")(ok)
+
+		asUser := func(userID string) *http.Request {
+			request := newRequest("10.0.0.1:4000")
+			return request.WithContext(authentication.WithPrincipal(request.Context(), &authentication.Principal{UserID: userID}))
+		}
+
+		Expect(send(handler, asUser("1")).Code).To(Equal(http.StatusNoContent))
+		Expect(send(handler, asUser("1")).Code).To(Equal(http.StatusTooManyRequests))
+		Expect(send(handler, asUser("2")).Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("should share the api bucket between groups without a rule of their own", func() {
+		config.Groups["api"] = configs.RateLimitRule{Requests: 1, Period: time.Minute, Key: "ip"}
+		config.Groups["users"] = configs.RateLimitRule{Requests: 1, Period: time.Minute, Key: "ip"}
+		limiter := New(store, nil, config)
+
+		Expect(send(limiter.Group("products")(ok), newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusNoContent))
+		Expect(send(limiter.Group("categories")(ok), newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusTooManyRequests))
+		Expect(send(limiter.Group("users")(ok), newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusNoContent))
+		Expect(send(limiter.Group("users")(ok), newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusTooManyRequests))
+	})
+
+	It("should limit by stored API key and fall back to the client IP", func() {
+		limiter := New(store, storedAPIKeys{"secret": "key-1"}, config)
+
+		withKey := func(apiKey string) *http.Request {
+			request := newRequest("10.0.0.1:4000")
+			request.Header.Set(HeaderAPIKey, apiKey)
+			return request
+		}
+
+		Expect(limiter.key(withKey("secret"), "api_key")).To(Equal("api_key:key-1"))
+		Expect(limiter.key(withKey("unknown"), "api_key")).To(Equal("ip:10.0.0.1"))
+		Expect(limiter.key(newRequest("10.0.0.1:4000"), "api_key")).To(Equal("ip:10.0.0.1"))
+		Expect(New(store, nil, config).key(withKey("secret"), "api_key")).To(Equal("ip:10.0.0.1"))
+	})
+
+	It("should not reset the limit when the API key header changes", func() {
+		config.Groups["auth"] = configs.RateLimitRule{Requests: 1, Period: time.Minute, Key: "api_key"}
+		handler := New(store, storedAPIKeys{"secret": "key-1"}, config).Group("auth")(ok)
+
+		withKey := func(apiKey string) *http.Request {
+			request := newRequest("10.0.0.1:4000")
+			request.Header.Set(HeaderAPIKey, apiKey)
+			return request
+		}
+
+		Expect(send(handler, withKey("rotated-1")).Code).To(Equal(http.StatusNoContent))
+		Expect(send(handler, withKey("rotated-2")).Code).To(Equal(http.StatusTooManyRequests))
+		Expect(send(handler, withKey("rotated-3")).Code).To(Equal(http.StatusTooManyRequests))
+		Expect(send(handler, withKey("secret")).Code).To(Equal(http.StatusNoContent))
+		Expect(send(handler, withKey("secret")).Code).To(Equal(http.StatusTooManyRequests))
+	})
+
+	It("should only trust X-Forwarded-For when configured to", func() {
+		request := newRequest("10.0.0.1:4000")
+		request.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+		Expect(New(store, nil, config).clientIP(request)).To(Equal("10.0.0.1"))
+
+		config.TrustForwardedFor = true
+		Expect(New(store, nil, config).clientIP(request)).To(Equal("198.51.100.7"))
+	})
+
+	It("should let requests through when the store fails", func() {
+		handler := New(failingStore{}, nil, config).Group("auth")(ok)
+
+		Expect(send(handler, newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("should not limit a group without requests", func() {
+		config.Groups["auth"] = configs.RateLimitRule{}
+		handler := New(store, nil, config).Group("auth")(ok)
+
+		for i := 0; i < 5; i++ {
+			Expect(send(handler, newRequest("10.0.0.1:4000")).Code).To(Equal(http.StatusNoContent))
+		}
+	})
+
+	Describe("MemoryStore", func() {
+		It("should forget idle buckets", func() {
+			_, _, err := store.Take(context.Background(), "auth:ip:10.0.0.1", 2, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.Sweep(context.Background(), now.Add(time.Second))).To(Succeed())
+			Expect(store.buckets).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"awesomeProject/internal/repositories"
+)
+
+// Store holds the token buckets of the limiter.
+type Store interface {
+	// Take takes a token from the bucket of key, which holds up to capacity
+	// tokens and refills at rate tokens per second. A new bucket starts
+	// full. It returns the tokens left and whether one was taken.
+	Take(ctx context.Context, key string, capacity, rate float64) (float64, bool, error)
+	// Sweep forgets the buckets not touched since before.
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore keeps the buckets in memory, so every instance limits on its
+// own.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, capacity, rate float64) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+
+	b.tokens--
+
+	return b.tokens, true, nil
+}
+
+func (s *MemoryStore) Sweep(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+// postgresStore keeps the buckets in the database, so that the limits hold
+// across instances.
+type postgresStore struct {
+	limits repositories.RateLimitRepository
+}
+
+func NewPostgresStore(limits repositories.RateLimitRepository) Store {
+	return &postgresStore{limits: limits}
+}
+
+func (s *postgresStore) Take(ctx context.Context, key string, capacity, rate float64) (float64, bool, error) {
+	return s.limits.TakeToken(ctx, key, capacity, rate)
+}
+
+func (s *postgresStore) Sweep(ctx context.Context, before time.Time) error {
+	_, err := s.limits.DeleteIdleRateLimits(ctx, before)
+
+	return err
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"slices"
+
+	"awesomeProject/pkg/apperrors"
+)
+
+// BulkMode decides what happens to a bulk request when some of its items
+// fail.
+type BulkMode string
+
+const (
+	// BulkAtomic writes nothing unless every item succeeds.
+	BulkAtomic BulkMode = "atomic"
+	// BulkPartial writes the items that succeed and reports the others.
+	BulkPartial BulkMode = "partial"
+)
+
+// ProductBatch is the body of a bulk product create or update. Items are
+// validated one by one so that a partial batch can report each of them.
+type ProductBatch struct {
+	Mode  BulkMode  `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []Product `json:"items" validate:"required,max=1000"`
+}
+
+// CategoryBatch is the body of a bulk category create or update.
+type CategoryBatch struct {
+	Mode  BulkMode   `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []Category `json:"items" validate:"required,max=1000"`
+}
+
+// BulkDelete is the body of a bulk delete.
+type BulkDelete struct {
+	Mode BulkMode `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	IDs  []int64  `json:"ids" validate:"required,max=1000,dive,gt=0"`
+}
+
+// BulkResult reports the outcome of every item of a bulk request, in the
+// order the items were sent.
+type BulkResult struct {
+	Mode BulkMode `json:"mode"`
+	// DryRun batches are checked and written, then always rolled back.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Committed is false when the batch was rolled back.
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// BulkItemResult is the outcome of one item. Code and Error are set when
+// the item failed. Line is the line of an imported file the item was read
+// from.
+type BulkItemResult struct {
+	Index  int                 `json:"index"`
+	Line   int                 `json:"line,omitempty"`
+	ID     string              `json:"id,omitempty"`
+	Code   string              `json:"code,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Errors map[string][]string `json:"errors,omitempty"`
+}
+
+// NewBulkResult returns a result for count items. An empty mode is atomic.
+func NewBulkResult(mode BulkMode, count int) *BulkResult {
+	if mode == "" {
+		mode = BulkAtomic
+	}
+
+	results := make([]BulkItemResult, count)
+	for i := range results {
+		results[i].Index = i
+	}
+
+	return &BulkResult{Mode: mode, Results: results}
+}
+
+// Fail records err as the outcome of the item at index. Only the first
+// failure of an item is kept.
+func (r *BulkResult) Fail(index int, err error) {
+	item := &r.Results[index]
+	if item.Code != "" {
+		return
+	}
+
+	appErr := apperrors.From(err)
+	item.Code = appErr.Code
+	item.Error = appErr.Message
+	item.Errors = appErr.Fields
+	r.Failed++
+}
+
+// OK reports whether the item at index has not failed so far.
+func (r *BulkResult) OK(index int) bool {
+	return r.Results[index].Code == ""
+}
+
+// Succeed records the id of the row the item at index wrote.
+func (r *BulkResult) Succeed(index int, id string) {
+	r.Results[index].ID = id
+}
+
+// Merge records the outcome of sub, a batch made of the items at indexes,
+// as the outcome of those items.
+func (r *BulkResult) Merge(indexes []int, sub *BulkResult) {
+	for j, i := range indexes {
+		item := sub.Results[j]
+		r.Results[i].ID = item.ID
+
+		if item.Code != "" && r.OK(i) {
+			r.Results[i].Code = item.Code
+			r.Results[i].Error = item.Error
+			r.Results[i].Errors = item.Errors
+			r.Failed++
+		}
+	}
+}
+
+// Snapshot returns a copy of r for Restore.
+func (r *BulkResult) Snapshot() BulkResult {
+	snapshot := *r
+	snapshot.Results = slices.Clone(r.Results)
+
+	return snapshot
+}
+
+// Restore brings r back to snapshot, e.g. before a failed write is retried.
+func (r *BulkResult) Restore(snapshot BulkResult) {
+	*r = snapshot
+	r.Results = slices.Clone(snapshot.Results)
+}
+
+// Abort reports whether the batch must not be written, which is the case
+// for an atomic batch with a failed item.
+func (r *BulkResult) Abort() bool {
+	return r.Mode == BulkAtomic && r.Failed > 0
+}
+
+// Finish records whether the batch was committed and counts the items that
+// were written, or would have been for a dry run. The ids of rows that were
+// rolled back are dropped.
+func (r *BulkResult) Finish(committed bool) {
+	r.Committed = committed
+	r.Succeeded = 0
+
+	if committed || (r.DryRun && !r.Abort()) {
+		r.Succeeded = len(r.Results) - r.Failed
+	}
+
+	if !committed {
+		for i := range r.Results {
+			r.Results[i].ID = ""
+		}
+	}
+}
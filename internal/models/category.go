@@ -2,18 +2,58 @@ package models
 
 import "time"
 
+// CategoryDeletePolicy decides what happens to the children of a deleted
+// category.
+type CategoryDeletePolicy string
+
+const (
+	// CategoryDeleteReject refuses to delete a category that has children.
+	CategoryDeleteReject CategoryDeletePolicy = "reject"
+	// CategoryDeleteCascade deletes the whole subtree.
+	CategoryDeleteCascade CategoryDeletePolicy = "cascade"
+	// CategoryDeleteReparent moves the children up to the deleted category's
+	// parent, or to the root.
+	CategoryDeleteReparent CategoryDeletePolicy = "reparent"
+)
+
 type Category struct {
 	ID        string    `json:"id,omitempty"`
-	Name      string    `json:"name"`
-	ProductID int       `json:"product_id,omitempty"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	ParentID  *int64    `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// Version is the row version the write expects, taken from If-Match.
+	// Zero writes unconditionally.
+	Version int64 `json:"-"`
 }
 
 type CategoryResponse struct {
 	ID        string    `json:"id,omitempty"`
 	Name      string    `json:"name"`
-	ProductID int       `json:"product_id"`
+	ParentID  *int64    `json:"parent_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version,omitempty"`
+}
+
+// CategoryPatch is the editable part of a category that PATCH requests are
+// applied to. A null parent_id moves the category to the root.
+type CategoryPatch struct {
+	Name     string      `json:"name" validate:"required,max=255"`
+	ParentID *int64      `json:"parent_id" validate:"omitempty,gt=0"`
+	Fields   PatchFields `json:"-"`
+	// Version is the row version the patch was applied to.
+	Version int64 `json:"-"`
+}
+
+// CategoryNode is a category with its descendants nested below it.
+type CategoryNode struct {
+	CategoryResponse
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryMove is the body for moving a category. A null parent_id moves the
+// category to the root.
+type CategoryMove struct {
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gt=0"`
 }
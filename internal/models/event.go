@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Resources whose changes are announced by events.
+const (
+	EventProduct  = "product"
+	EventCategory = "category"
+	EventUser     = "user"
+)
+
+// Changes announced by events.
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+	EventPurged   = "purged"
+)
+
+// EventPing is the type of the event sent to test a webhook subscription.
+const EventPing = "webhook.ping"
+
+// Event announces that a resource changed. It carries no copy of the
+// resource, receivers fetch the current state when they need it, so events
+// delivered late or out of order do no harm.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// EventType returns the type of the event for action on resource, e.g.
+// product.created.
+func EventType(resource, action string) string {
+	return resource + "." + action
+}
+
+// EventTypes returns every type of event a webhook can subscribe to.
+func EventTypes() []string {
+	var types []string
+
+	for _, resource := range []string{EventProduct, EventCategory, EventUser} {
+		for _, action := range []string{EventCreated, EventUpdated, EventDeleted, EventRestored, EventPurged} {
+			types = append(types, EventType(resource, action))
+		}
+	}
+
+	return types
+}
@@ -0,0 +1,32 @@
+package models
+
+const (
+	HealthOK          = "ok"
+	HealthFailing     = "failing"
+	HealthUnavailable = "unavailable"
+	HealthDraining    = "draining"
+)
+
+// HealthReport is the answer of the readiness endpoint. Status is ok only
+// when every check is.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the outcome of checking one dependency.
+type HealthCheck struct {
+	Status     string      `json:"status"`
+	DurationMS float64     `json:"duration_ms"`
+	Error      string      `json:"error,omitempty"`
+	Details    interface{} `json:"details,omitempty"`
+}
+
+// WorkerStatus describes a pool of background workers.
+type WorkerStatus struct {
+	Configured int `json:"configured"`
+	Running    int `json:"running"`
+	// LastError is the error of the last poll, empty once a poll succeeds
+	// again.
+	LastError string `json:"last_error,omitempty"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus is where a job is in its life. A failed attempt puts the job
+// back to queued until it runs out of attempts and is dead-lettered.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead"
+)
+
+// Job is a unit of background work. Result is set once the job succeeded and
+// Error holds the failure of the last attempt. RunAt is when the job is due,
+// the time of the next retry for a job that failed before.
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      JobStatus       `json:"status"`
+	Payload     json.RawMessage `json:"-"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Progress    int             `json:"progress"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedBy   string          `json:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// JobRequest is the body of a job submission. The payload is checked by the
+// job kind.
+type JobRequest struct {
+	Kind    string          `json:"kind" validate:"required,max=100"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ImportJobPayload imports a file given inline as Data, like the import
+// endpoints do with an upload. When the job is queued Data is moved to the
+// job files, the stored payload only names it in File.
+type ImportJobPayload struct {
+	Format string   `json:"format" validate:"omitempty,oneof=csv ndjson"`
+	Mode   BulkMode `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	DryRun bool     `json:"dry_run"`
+	Data   string   `json:"data,omitempty"`
+	File   string   `json:"file,omitempty"`
+}
+
+// ExportJobPayload exports a file in Format, CSV by default.
+type ExportJobPayload struct {
+	Format string `json:"format" validate:"omitempty,oneof=csv ndjson"`
+}
+
+// ExportJobResult describes the exported file. File names it in the job
+// files, it is downloaded from GET /jobs/{job_id}/file.
+type ExportJobResult struct {
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Rows        int    `json:"rows"`
+	Size        int64  `json:"size"`
+	File        string `json:"file"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// ListOptions holds the pagination, sorting and created-at filters shared by
+// every list endpoint. Sort names a column, prefixed with "-" for descending.
+type ListOptions struct {
+	Limit         int
+	Cursor        string
+	Sort          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+type ProductFilter struct {
+	ListOptions
+	CategoryID int
+}
+
+type CategoryFilter struct {
+	ListOptions
+}
+
+type UserFilter struct {
+	ListOptions
+	Role string
+}
+
+type Page[T any] struct {
+	Data []T      `json:"data"`
+	Meta PageMeta `json:"meta"`
+}
+
+type PageMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+}
+
+type (
+	ProductPage       = Page[ProductResponse]
+	ProductSearchPage = Page[ProductSearchResult]
+	CategoryPage      = Page[CategoryResponse]
+	UserPage          = Page[UserResponse]
+	TrashPage         = Page[TrashedItem]
+)
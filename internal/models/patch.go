@@ -0,0 +1,5 @@
+package models
+
+// PatchFields is the set of JSON field names whose values a PATCH request
+// changed. Only the matching columns are written.
+type PatchFields map[string]bool
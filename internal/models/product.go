@@ -3,17 +3,45 @@ package models
 import "time"
 
 type Product struct {
-	ID         string    `json:"id,omitempty"`
-	Name       string    `json:"name"`
-	CategoryID int       `json:"category_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name" validate:"required,max=255"`
+	CategoryIDs []int64   `json:"category_ids" validate:"required,max=50,dive,gt=0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Version is the row version the write expects, taken from If-Match.
+	// Zero writes unconditionally.
+	Version int64 `json:"-"`
 }
 
 type ProductResponse struct {
-	ID         string    `json:"id,omitempty"`
-	Name       string    `json:"name"`
-	CategoryID int       `json:"category_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name"`
+	CategoryIDs []int64   `json:"category_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int64     `json:"version,omitempty"`
+}
+
+// ProductPatch is the editable part of a product that PATCH requests are
+// applied to. The patched document is validated as a whole.
+type ProductPatch struct {
+	Name        string      `json:"name" validate:"required,max=255"`
+	CategoryIDs []int64     `json:"category_ids" validate:"required,max=50,dive,gt=0"`
+	Fields      PatchFields `json:"-"`
+	// Version is the row version the patch was applied to.
+	Version int64 `json:"-"`
+}
+
+// ProductCategories is the body of a request replacing the categories a
+// product belongs to.
+type ProductCategories struct {
+	CategoryIDs []int64 `json:"category_ids" validate:"required,max=50,dive,gt=0"`
+}
+
+// ProductSearchResult is a product matched by full-text or fuzzy search.
+// Snippet is the name with matched terms wrapped in <mark> tags.
+type ProductSearchResult struct {
+	ProductResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
 }
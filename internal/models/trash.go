@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// TrashKind names a resource whose deleted rows are kept in the trash.
+type TrashKind string
+
+const (
+	TrashProducts   TrashKind = "products"
+	TrashCategories TrashKind = "categories"
+	TrashUsers      TrashKind = "users"
+)
+
+// TrashedItem is a deleted row awaiting restore or purge. Name is the
+// username for users.
+type TrashedItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
@@ -1,24 +1,58 @@
 package models
 
+import "time"
+
 type User struct {
 	ID       string `json:"id,omitempty"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role,omitempty"`
+	Username string `json:"username" validate:"required,min=3,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=admin editor user"`
+	// Version is the row version the write expects, taken from If-Match.
+	// Zero writes unconditionally.
+	Version int64 `json:"-"`
+}
+
+// UserUpdate is the body of a user update. Omitted fields keep their value,
+// the password and the role cannot be changed this way.
+type UserUpdate struct {
+	Username string `json:"username,omitempty" validate:"omitempty,min=3,max=255"`
+	Email    string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+}
+
+// UserRoleUpdate is the body of a role change, which takes the users:write
+// permission.
+type UserRoleUpdate struct {
+	Role string `json:"role" validate:"required,oneof=admin editor user"`
+}
+
+// UserPatch is the part of a user that PATCH requests are applied to. The
+// password cannot be changed this way, and Role is only there to be tested:
+// patches changing it are refused.
+type UserPatch struct {
+	Username string      `json:"username" validate:"required,min=3,max=255"`
+	Email    string      `json:"email" validate:"required,email,max=255"`
+	Role     string      `json:"role" validate:"omitempty,oneof=admin editor user"`
+	Fields   PatchFields `json:"-"`
+	// Version is the row version the patch was applied to.
+	Version int64 `json:"-"`
 }
 
 type UserResponse struct {
-	ID       string `json:"id,omitempty"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	ID        string     `json:"id,omitempty"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Password  string     `json:"-"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Version   int64      `json:"version,omitempty"`
 }
 
+// Auth is used for both sign-up and login. Self sign-up can only create
+// plain users, other roles are assigned through the users endpoints.
 type Auth struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Username string `json:"username" validate:"required,min=3,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password"`
+	Role     string `json:"role" validate:"omitempty,oneof=user"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// WebhookSubscription delivers the events of EventTypes, or of every type
+// when empty, to URL. Each delivery is signed with Secret, which is only
+// shown when the subscription is created.
+type WebhookSubscription struct {
+	ID         string     `json:"id"`
+	URL        string     `json:"url"`
+	Secret     string     `json:"secret,omitempty"`
+	EventTypes []string   `json:"event_types"`
+	Active     bool       `json:"active"`
+	CreatedBy  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+// WebhookSubscriptionRequest is the body of a subscription registration.
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	EventTypes []string `json:"event_types" validate:"max=50,dive,required,max=100"`
+}
+
+// WebhookDelivery is an attempt to deliver Event to a subscription. Attempts
+// counts the current attempt.
+type WebhookDelivery struct {
+	ID             string
+	Attempts       int
+	SubscriptionID string
+	URL            string
+	Secret         string
+	Event          Event
+}
+
+// WebhookTestResult reports how the endpoint of a subscription answered a
+// ping event.
+type WebhookTestResult struct {
+	Delivered bool   `json:"delivered"`
+	Status    int    `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -1,13 +1,19 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
 	"awesomeProject/internal/models"
 	"awesomeProject/pkg/database"
 )
 
 type AuthRepository interface {
-	Register(user *models.Auth) error
-	Login(auth *models.Auth) (*models.UserResponse, error)
+	Register(ctx context.Context, user *models.Auth) error
+	Login(ctx context.Context, auth *models.Auth) (*models.UserResponse, error)
+	GetUserByID(ctx context.Context, id string) (*models.UserResponse, error)
 }
 
 type AuthRepositoryImpl struct {
@@ -20,22 +26,56 @@ func NewAuthRepositoryImpl(db database.Database) AuthRepository {
 	}
 }
 
-func (a *AuthRepositoryImpl) Register(user *models.Auth) error {
-	_, err := a.db.Exec(AddCustomer, &user.Username, &user.Email, &user.Password, &user.Role)
+func (a *AuthRepositoryImpl) Register(ctx context.Context, user *models.Auth) error {
+	return database.WithSerializableTx(ctx, a.db, func(tx database.Querier) error {
+		exists, err := checkUserExists(ctx, user.Email, tx)
+		if err != nil {
+			return fmt.Errorf("failed to check user existence: %w", err)
+		}
+
+		if exists {
+			return ErrUserAlreadyExists
+		}
+
+		var id string
+
+		err = tx.QueryRowContext(ctx, AddCustomer, &user.Username, &user.Email, &user.Password, &user.Role).Scan(&id)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to register user: %w", err)
+		}
+
+		return recordEvents(ctx, tx, models.EventUser, models.EventCreated, id)
+	})
+}
+
+func (a *AuthRepositoryImpl) Login(ctx context.Context, auth *models.Auth) (*models.UserResponse, error) {
+	var user models.UserResponse
+
+	err := a.db.QueryRowContext(ctx, GetUserByEmail, auth.Email).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return nil
+	return &user, nil
 }
 
-func (a *AuthRepositoryImpl) Login(auth *models.Auth) (*models.UserResponse, error) {
+func (a *AuthRepositoryImpl) GetUserByID(ctx context.Context, id string) (*models.UserResponse, error) {
 	var user models.UserResponse
 
-	err := a.db.QueryRow(GetUserByEmail, auth.Email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role)
+	err := a.db.QueryRowContext(ctx, GetUserByID, id).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.Version)
 	if err != nil {
-		return &user, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	return &user, nil
@@ -3,11 +3,13 @@ package repositories
 import (
 	"awesomeProject/internal/models"
 	_ "awesomeProject/pkg/database"
+	"context"
 	"database/sql"
 	"errors"
 	"regexp"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -43,22 +45,58 @@ var _ = Describe("AuthRepository", func() {
 
 	Context("Register user", func() {
 		It("should register user", func() {
-			mock.ExpectExec("INSERT INTO customer").
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
+				WithArgs(auth.Email).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery("INSERT INTO customer").
 				WithArgs(auth.Username, auth.Email, auth.Password, auth.Role).
-				WillReturnResult(sqlmock.NewResult(1, 1))
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			expectEvents(mock, models.EventUser, models.EventCreated, "1")
+			mock.ExpectCommit()
 
-			err = repo.Register(auth)
+			err = repo.Register(context.Background(), auth)
 			Expect(err).Should(BeNil())
 		})
 
+		It("should return ErrUserAlreadyExists when the email is taken", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
+				WithArgs(auth.Email).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err = repo.Register(context.Background(), auth)
+			Expect(errors.Is(err, ErrUserAlreadyExists)).To(BeTrue())
+		})
+
+		It("should return ErrUserAlreadyExists when a concurrent signup wins the unique index", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
+				WithArgs(auth.Email).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery("INSERT INTO customer").
+				WithArgs(auth.Username, auth.Email, auth.Password, auth.Role).
+				WillReturnError(&pq.Error{Code: "23505"})
+			mock.ExpectRollback()
+
+			err = repo.Register(context.Background(), auth)
+			Expect(errors.Is(err, ErrUserAlreadyExists)).To(BeTrue())
+		})
+
 		It("should return an error if there's a database error", func() {
-			mock.ExpectExec("INSERT INTO customer").
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
+				WithArgs(auth.Email).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery("INSERT INTO customer").
 				WithArgs(auth.Username, auth.Email, auth.Password, auth.Role).
 				WillReturnError(errors.New("database error"))
+			mock.ExpectRollback()
 
-			err = repo.Register(auth)
+			err = repo.Register(context.Background(), auth)
 			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).To(Equal("database error"))
+			Expect(err.Error()).To(Equal("failed to register user: database error"))
 		})
 	})
 
@@ -67,33 +105,33 @@ var _ = Describe("AuthRepository", func() {
 			rows := sqlmock.NewRows([]string{"id", "username", "email", "password", "role"}).
 				AddRow(1, "testuser", "testuser@example.com", "hashedpassword", "user")
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password, role FROM customer WHERE email = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByEmail)).
 				WithArgs(auth.Email).
 				WillReturnRows(rows)
 
-			user, err = repo.Login(auth)
+			user, err = repo.Login(context.Background(), auth)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(user.Email).To(Equal("testuser@example.com"))
 		})
 
 		It("should return an error if the user is not found", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password, role FROM customer WHERE email = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByEmail)).
 				WithArgs(auth.Email).
 				WillReturnError(sql.ErrNoRows)
 
-			_, err = repo.Login(auth)
+			_, err = repo.Login(context.Background(), auth)
 			Expect(err).Should(HaveOccurred())
-			Expect(errors.Is(err, sql.ErrNoRows)).To(BeTrue())
+			Expect(errors.Is(err, ErrUserNotFound)).To(BeTrue())
 		})
 
 		It("should return an error if there's a database error", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password, role FROM customer WHERE email = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByEmail)).
 				WithArgs(auth.Email).
 				WillReturnError(errors.New("database error"))
 
-			_, err = repo.Login(auth)
+			_, err = repo.Login(context.Background(), auth)
 			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).To(Equal("database error"))
+			Expect(err.Error()).To(Equal("failed to get user: database error"))
 		})
 	})
 })
@@ -0,0 +1,326 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+
+	"github.com/lib/pq"
+)
+
+// errBulkAborted rolls back the transaction of a dry run, or of an atomic
+// batch once one of its items failed. It never leaves the repository.
+var errBulkAborted = errors.New("bulk batch aborted")
+
+// runBulk runs write in one serializable transaction and records in result
+// whether it was committed. Items write fails are recorded in result, any
+// error it returns fails the whole batch. A dry run is always rolled back.
+// A transaction retried after a conflict starts over from the outcomes
+// result held before the first attempt.
+func runBulk(ctx context.Context, db database.Database, result *models.BulkResult, write func(tx database.Querier) error) error {
+	initial := result.Snapshot()
+
+	err := database.WithSerializableTx(ctx, db, func(tx database.Querier) error {
+		result.Restore(initial)
+
+		err := write(tx)
+		if err != nil {
+			return err
+		}
+
+		if result.Abort() || result.DryRun {
+			return errBulkAborted
+		}
+
+		return nil
+	})
+	if errors.Is(err, errBulkAborted) {
+		result.Finish(false)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	result.Finish(true)
+
+	return nil
+}
+
+// queryStrings returns the set of values of the single column query selects.
+func queryStrings(ctx context.Context, db database.Querier, query string, args ...interface{}) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]bool)
+
+	for rows.Next() {
+		var value string
+
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[value] = true
+	}
+
+	return values, rows.Err()
+}
+
+// failTaken fails the pending items whose name is already taken, either by a
+// live row or by an earlier item of the same batch.
+func failTaken(ctx context.Context, db database.Querier, result *models.BulkResult, query string, names []string, taken error) error {
+	existing, err := queryStrings(ctx, db, query, pq.Array(names))
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(names))
+
+	for i, name := range names {
+		if !result.OK(i) {
+			continue
+		}
+
+		if existing[name] || seen[name] {
+			result.Fail(i, taken)
+		}
+
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// failRenamedTaken fails the pending items of an update whose new name is
+// taken, either by another live row or by an earlier item of the same batch.
+// An item keeping the name of its own row passes.
+func failRenamedTaken(ctx context.Context, db database.Querier, result *models.BulkResult, query string, ids []int64, names []string, taken error) error {
+	var check []string
+	for _, i := range pending(result) {
+		check = append(check, names[i])
+	}
+
+	if len(check) == 0 {
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(check))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	owners := make(map[string][]string)
+
+	for rows.Next() {
+		var name, id string
+
+		err = rows.Scan(&name, &id)
+		if err != nil {
+			return err
+		}
+
+		owners[name] = append(owners[name], id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(names))
+
+	for i, name := range names {
+		if !result.OK(i) {
+			continue
+		}
+
+		if seen[name] || slices.ContainsFunc(owners[name], func(owner string) bool { return owner != strconv.FormatInt(ids[i], 10) }) {
+			result.Fail(i, taken)
+		}
+
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// parseBulkIDs parses the ids of the items of an update. Ids that are not
+// numbers fail their item with notFound.
+func parseBulkIDs(result *models.BulkResult, ids []string, notFound error) []int64 {
+	parsed := make([]int64, len(ids))
+
+	for i, id := range ids {
+		value, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || value <= 0 {
+			result.Fail(i, notFound)
+			continue
+		}
+
+		parsed[i] = value
+	}
+
+	failDuplicateIDs(result, parsed)
+
+	return parsed
+}
+
+// failDuplicateIDs fails the items repeating the id of an earlier item.
+func failDuplicateIDs(result *models.BulkResult, ids []int64) {
+	seen := make(map[int64]bool, len(ids))
+
+	for i, id := range ids {
+		if !result.OK(i) {
+			continue
+		}
+
+		if seen[id] {
+			result.Fail(i, ErrDuplicateItem)
+		}
+
+		seen[id] = true
+	}
+}
+
+// queryCreated runs a multi-row insert returning id and name, and maps each
+// name to the id of its new row.
+func queryCreated(ctx context.Context, db database.Querier, query string, args ...interface{}) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	created := make(map[string]string)
+
+	for rows.Next() {
+		var id, name string
+
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+
+		created[name] = id
+	}
+
+	return created, rows.Err()
+}
+
+// failMissing fails the pending items whose ids, looked up with query, do
+// not name a live row.
+func failMissing(ctx context.Context, db database.Querier, result *models.BulkResult, query string, ids [][]int64, notFound error) error {
+	var all []int64
+	for i, itemIDs := range ids {
+		if result.OK(i) {
+			all = append(all, itemIDs...)
+		}
+	}
+
+	if len(all) == 0 {
+		return nil
+	}
+
+	live, err := queryStrings(ctx, db, query, pq.Array(uniqueIDs(all)))
+	if err != nil {
+		return fmt.Errorf("failed to check rows exist: %w", err)
+	}
+
+	for i, itemIDs := range ids {
+		for _, id := range itemIDs {
+			if result.OK(i) && !live[strconv.FormatInt(id, 10)] {
+				result.Fail(i, notFound)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pending returns the indexes of the items that have not failed.
+func pending(result *models.BulkResult) []int {
+	indexes := make([]int, 0, len(result.Results))
+
+	for i := range result.Results {
+		if result.OK(i) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// pickIDs returns the id lists of the items at indexes.
+func pickIDs(ids [][]int64, indexes []int) [][]int64 {
+	picked := make([][]int64, len(indexes))
+	for j, i := range indexes {
+		picked[j] = ids[i]
+	}
+
+	return picked
+}
+
+// singleIDs wraps each id in a list of its own, for failMissing.
+func singleIDs(ids []int64) [][]int64 {
+	wrapped := make([][]int64, len(ids))
+	for i, id := range ids {
+		wrapped[i] = []int64{id}
+	}
+
+	return wrapped
+}
+
+// splitImport writes the items with an id through update and the others
+// through create, each as a batch of its own, and merges their outcomes into
+// result.
+func splitImport[T any](result *models.BulkResult, items []T, hasID func(T) bool, create, update func([]T, *models.BulkResult) error) error {
+	var creates, updates []int
+
+	for _, i := range pending(result) {
+		if hasID(items[i]) {
+			updates = append(updates, i)
+		} else {
+			creates = append(creates, i)
+		}
+	}
+
+	parts := []struct {
+		indexes []int
+		write   func([]T, *models.BulkResult) error
+	}{
+		{creates, create},
+		{updates, update},
+	}
+
+	for _, part := range parts {
+		if result.Abort() || len(part.indexes) == 0 {
+			continue
+		}
+
+		batch := make([]T, len(part.indexes))
+		for j, i := range part.indexes {
+			batch[j] = items[i]
+		}
+
+		sub := models.NewBulkResult(result.Mode, len(batch))
+
+		err := part.write(batch, sub)
+		if err != nil {
+			return err
+		}
+
+		result.Merge(part.indexes, sub)
+	}
+
+	return nil
+}
@@ -2,18 +2,34 @@ package repositories
 
 import (
 	"awesomeProject/internal/models"
+	"awesomeProject/pkg/apperrors"
 	"awesomeProject/pkg/database"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Categorer interface {
-	GetCategory(categoryID string) (*models.CategoryResponse, error)
-	UpdateCategory(category models.Category) error
-	CreateCategory(category models.Category) error
-	DeleteCategory(categoryID string) error
+	GetCategory(ctx context.Context, categoryID string) (*models.CategoryResponse, error)
+	ListCategories(ctx context.Context, filter models.CategoryFilter) (*models.CategoryPage, error)
+	GetCategoryTree(ctx context.Context) ([]*models.CategoryNode, error)
+	GetCategorySubtree(ctx context.Context, categoryID string) (*models.CategoryNode, error)
+	GetCategoryBreadcrumb(ctx context.Context, categoryID string) ([]models.CategoryResponse, error)
+	UpdateCategory(ctx context.Context, category models.Category) error
+	PatchCategory(ctx context.Context, categoryID string, patch models.CategoryPatch) error
+	MoveCategory(ctx context.Context, categoryID string, parentID *int64, version int64) error
+	CreateCategory(ctx context.Context, category models.Category) error
+	DeleteCategory(ctx context.Context, categoryID string, policy models.CategoryDeletePolicy, version int64) error
+	CreateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error
+	UpdateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error
+	DeleteCategories(ctx context.Context, ids []int64, policy models.CategoryDeletePolicy, result *models.BulkResult) error
+	ImportCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error
+	ExportCategories(ctx context.Context, fn func(models.CategoryResponse) error) error
 }
 
 type Category struct {
@@ -24,14 +40,14 @@ func NewCategory(db database.Database) Categorer {
 	return &Category{db: db}
 }
 
-func (c *Category) GetCategory(categoryID string) (*models.CategoryResponse, error) {
+func (c *Category) GetCategory(ctx context.Context, categoryID string) (*models.CategoryResponse, error) {
 	category := &models.CategoryResponse{}
 
-	err := c.db.QueryRow(GetCategoryByID, categoryID).
-		Scan(&category.Name, &category.ProductID, &category.CreatedAt, &category.UpdatedAt)
+	err := c.db.QueryRowContext(ctx, GetCategoryByID, categoryID).
+		Scan(&category.Name, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("category not found: %w", err)
+			return nil, ErrCategoryNotFound
 		}
 
 		return nil, fmt.Errorf("failed to get category: %w", err)
@@ -40,49 +56,519 @@ func (c *Category) GetCategory(categoryID string) (*models.CategoryResponse, err
 	return category, nil
 }
 
-func (c *Category) UpdateCategory(category models.Category) error {
-	_, err := c.db.Exec(UpdateCategory, category.ID, category.Name, category.ProductID, time.Now())
+func (c *Category) ListCategories(ctx context.Context, filter models.CategoryFilter) (*models.CategoryPage, error) {
+	var filters conditions
+
+	filters.add("deleted_at IS NULL")
+	filters.addCreatedRange(filter.ListOptions)
+
+	page, err := listPage(ctx, c.db, categoryList, filter.ListOptions, filters)
 	if err != nil {
-		return fmt.Errorf("failed to update category: %w", err)
+		return nil, fmt.Errorf("failed to list categories: %w", err)
 	}
 
-	return nil
+	return page, nil
+}
+
+// GetCategoryTree returns every category nested below its parent, starting
+// from the root categories.
+func (c *Category) GetCategoryTree(ctx context.Context) ([]*models.CategoryNode, error) {
+	categories, err := c.queryCategories(ctx, GetCategoryTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category tree: %w", err)
+	}
+
+	return buildCategoryTree(categories), nil
 }
 
-func (c *Category) CreateCategory(category models.Category) error {
-	exists, err := checkCategoryExists(category.Name, c.db)
+// GetCategorySubtree returns the category with all of its descendants.
+func (c *Category) GetCategorySubtree(ctx context.Context, categoryID string) (*models.CategoryNode, error) {
+	categories, err := c.queryCategories(ctx, GetCategorySubtree, categoryID)
 	if err != nil {
-		return fmt.Errorf("failed to check category exist: %w", err)
+		return nil, fmt.Errorf("failed to get category subtree: %w", err)
 	}
 
-	if exists {
-		return errors.New("category already exists")
+	if len(categories) == 0 {
+		return nil, ErrCategoryNotFound
 	}
 
-	_, err = c.db.Exec(CreateCategory, category.Name, category.ProductID)
+	// The subtree root comes first and its parent is not part of the result.
+	categories[0].ParentID = nil
+
+	return buildCategoryTree(categories)[0], nil
+}
+
+// GetCategoryBreadcrumb returns the ancestors of the category from the root
+// down, ending with the category itself.
+func (c *Category) GetCategoryBreadcrumb(ctx context.Context, categoryID string) ([]models.CategoryResponse, error) {
+	categories, err := c.queryCategories(ctx, GetCategoryAncestors, categoryID)
 	if err != nil {
-		return fmt.Errorf("failed to create category: %w", err)
+		return nil, fmt.Errorf("failed to get category breadcrumb: %w", err)
 	}
 
-	return nil
+	if len(categories) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	return categories, nil
+}
+
+func (c *Category) queryCategories(ctx context.Context, query string, args ...interface{}) ([]models.CategoryResponse, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.CategoryResponse
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// buildCategoryTree nests categories below their parents. Parents must come
+// before their children, categories whose parent is missing become roots.
+func buildCategoryTree(categories []models.CategoryResponse) []*models.CategoryNode {
+	nodes := make(map[string]*models.CategoryNode, len(categories))
+	roots := make([]*models.CategoryNode, 0)
+
+	for _, category := range categories {
+		node := &models.CategoryNode{CategoryResponse: category, Children: make([]*models.CategoryNode, 0)}
+		nodes[category.ID] = node
+
+		if category.ParentID != nil {
+			if parent, ok := nodes[strconv.FormatInt(*category.ParentID, 10)]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+
+		roots = append(roots, node)
+	}
+
+	return roots
+}
+
+// UpdateCategory renames the category. A non-zero category.Version must
+// match the stored row version, otherwise ErrVersionConflict is returned.
+// The name must not be taken by another category.
+func (c *Category) UpdateCategory(ctx context.Context, category models.Category) error {
+	return database.WithSerializableTx(ctx, c.db, func(tx database.Querier) error {
+		err := requireFree(ctx, tx, CheckCategoryNameTaken, category.Name, category.ID, ErrCategoryAlreadyExists)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, UpdateCategory, category.ID, category.Name, time.Now(), category.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, category.Version, CheckCategoryIDExists, category.ID, ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventCategory, models.EventUpdated, category.ID)
+	})
+}
+
+func (c *Category) CreateCategory(ctx context.Context, category models.Category) error {
+	return database.WithSerializableTx(ctx, c.db, func(tx database.Querier) error {
+		exists, err := checkCategoryExists(ctx, category.Name, tx)
+		if err != nil {
+			return fmt.Errorf("failed to check category exist: %w", err)
+		}
+
+		if exists {
+			return ErrCategoryAlreadyExists
+		}
+
+		if category.ParentID != nil {
+			err = requireParentCategory(ctx, tx, *category.ParentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		var id string
+
+		err = tx.QueryRowContext(ctx, CreateCategory, category.Name, category.ParentID).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to create category: %w", err)
+		}
+
+		return recordEvents(ctx, tx, models.EventCategory, models.EventCreated, id)
+	})
+}
+
+// PatchCategory writes only the fields named in patch.Fields. Changing the
+// parent is checked like MoveCategory. patch.Version must match the stored
+// row version, otherwise ErrVersionConflict is returned.
+func (c *Category) PatchCategory(ctx context.Context, categoryID string, patch models.CategoryPatch) error {
+	return database.WithSerializableTx(ctx, c.db, func(tx database.Querier) error {
+		var set assignments
+
+		if patch.Fields["name"] {
+			err := requireFree(ctx, tx, CheckCategoryNameTaken, patch.Name, categoryID, ErrCategoryAlreadyExists)
+			if err != nil {
+				return err
+			}
+
+			set.set("name", patch.Name)
+		}
+
+		if patch.Fields["parent_id"] {
+			if patch.ParentID != nil {
+				err := requireMovable(ctx, tx, categoryID, *patch.ParentID)
+				if err != nil {
+					return err
+				}
+			}
+
+			set.set("parent_id", patch.ParentID)
+		}
+
+		set.set("updated_at", time.Now())
+
+		query, args := set.update(PatchCategory, categoryID, patch.Version)
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to patch category: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, patch.Version, CheckCategoryIDExists, categoryID, ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventCategory, models.EventUpdated, categoryID)
+	})
+}
+
+// MoveCategory moves the category and its subtree below parentID, or to the
+// root when parentID is nil. Moving a category below one of its own
+// descendants is rejected with ErrCategoryCycle.
+func (c *Category) MoveCategory(ctx context.Context, categoryID string, parentID *int64, version int64) error {
+	return database.WithSerializableTx(ctx, c.db, func(tx database.Querier) error {
+		if parentID != nil {
+			err := requireMovable(ctx, tx, categoryID, *parentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, MoveCategory, categoryID, parentID, time.Now(), version)
+		if err != nil {
+			return fmt.Errorf("failed to move category: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, version, CheckCategoryIDExists, categoryID, ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventCategory, models.EventUpdated, categoryID)
+	})
+}
+
+// DeleteCategory moves the category to the trash and handles its children
+// according to policy. Cascading trashes the whole subtree. The version
+// guards the category itself, its children are changed whatever theirs.
+func (c *Category) DeleteCategory(ctx context.Context, categoryID string, policy models.CategoryDeletePolicy, version int64) error {
+	return database.WithTx(ctx, c.db, func(tx database.Querier) error {
+		return deleteCategory(ctx, tx, categoryID, policy, version, time.Now())
+	})
+}
+
+// CreateCategories creates the items of a bulk request in one transaction
+// with a single insert. Failed items are recorded in result.
+func (c *Category) CreateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	return runBulk(ctx, c.db, result, func(tx database.Querier) error {
+		return createCategories(ctx, tx, categories, result)
+	})
+}
+
+func createCategories(ctx context.Context, tx database.Querier, categories []models.Category, result *models.BulkResult) error {
+	names := make([]string, len(categories))
+	parentIDs := make([][]int64, len(categories))
+
+	for i, category := range categories {
+		names[i] = category.Name
+		if category.ParentID != nil {
+			parentIDs[i] = []int64{*category.ParentID}
+		}
+	}
+
+	err := failTaken(ctx, tx, result, ListLiveCategoryNames, names, ErrCategoryAlreadyExists)
+	if err != nil {
+		return fmt.Errorf("failed to check categories exist: %w", err)
+	}
+
+	err = failMissing(ctx, tx, result, ListLiveCategoryIDs, parentIDs, ErrParentCategoryNotFound)
+	if err != nil {
+		return err
+	}
+
+	indexes := pending(result)
+	if result.Abort() || len(indexes) == 0 {
+		return nil
+	}
+
+	insertNames := make([]string, len(indexes))
+	insertParents := make([]sql.NullInt64, len(indexes))
+
+	for j, i := range indexes {
+		insertNames[j] = names[i]
+		if parentID := categories[i].ParentID; parentID != nil {
+			insertParents[j] = sql.NullInt64{Int64: *parentID, Valid: true}
+		}
+	}
+
+	created, err := queryCreated(ctx, tx, CreateCategories, pq.Array(insertNames), pq.Array(insertParents))
+	if err != nil {
+		return fmt.Errorf("failed to create categories: %w", err)
+	}
+
+	createdIDs := make([]string, len(indexes))
+	for j, i := range indexes {
+		createdIDs[j] = created[names[i]]
+		result.Succeed(i, createdIDs[j])
+	}
+
+	return recordEvents(ctx, tx, models.EventCategory, models.EventCreated, createdIDs...)
+}
+
+// UpdateCategories renames the items of a bulk request in one statement.
+// Moving categories is left to MoveCategory. Failed items are recorded in
+// result.
+func (c *Category) UpdateCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	return runBulk(ctx, c.db, result, func(tx database.Querier) error {
+		return updateCategories(ctx, tx, categories, result)
+	})
+}
+
+func updateCategories(ctx context.Context, tx database.Querier, categories []models.Category, result *models.BulkResult) error {
+	ids := make([]string, len(categories))
+	names := make([]string, len(categories))
+
+	for i, category := range categories {
+		ids[i] = category.ID
+		names[i] = category.Name
+	}
+
+	parsed := parseBulkIDs(result, ids, ErrCategoryNotFound)
+
+	err := failMissing(ctx, tx, result, ListLiveCategoryIDs, singleIDs(parsed), ErrCategoryNotFound)
+	if err != nil {
+		return err
+	}
+
+	err = failRenamedTaken(ctx, tx, result, ListLiveCategoryNameOwners, parsed, names, ErrCategoryAlreadyExists)
+	if err != nil {
+		return fmt.Errorf("failed to check categories exist: %w", err)
+	}
+
+	indexes := pending(result)
+	if result.Abort() || len(indexes) == 0 {
+		return nil
+	}
+
+	updateIDs := make([]int64, len(indexes))
+	categoryIDs := make([]string, len(indexes))
+	updateNames := make([]string, len(indexes))
+
+	for j, i := range indexes {
+		updateIDs[j] = parsed[i]
+		categoryIDs[j] = strconv.FormatInt(parsed[i], 10)
+		updateNames[j] = categories[i].Name
+		result.Succeed(i, categoryIDs[j])
+	}
+
+	_, err = tx.ExecContext(ctx, UpdateCategories, pq.Array(updateIDs), pq.Array(updateNames), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update categories: %w", err)
+	}
+
+	return recordEvents(ctx, tx, models.EventCategory, models.EventUpdated, categoryIDs...)
+}
+
+// ImportCategories writes the rows of an imported file in one transaction.
+// Rows with an id rename that category, the others create a new one. As with
+// UpdateCategories, the parent of an existing category is left alone.
+func (c *Category) ImportCategories(ctx context.Context, categories []models.Category, result *models.BulkResult) error {
+	return runBulk(ctx, c.db, result, func(tx database.Querier) error {
+		hasID := func(category models.Category) bool { return category.ID != "" }
+
+		return splitImport(result, categories, hasID,
+			func(batch []models.Category, sub *models.BulkResult) error {
+				return createCategories(ctx, tx, batch, sub)
+			},
+			func(batch []models.Category, sub *models.BulkResult) error {
+				return updateCategories(ctx, tx, batch, sub)
+			})
+	})
+}
+
+// ExportCategories calls fn with every live category in id order, reading
+// rows one at a time.
+func (c *Category) ExportCategories(ctx context.Context, fn func(models.CategoryResponse) error) error {
+	return eachRow(ctx, c.db, ExportCategories, scanCategory, fn)
+}
+
+// DeleteCategories moves the categories of a bulk request to the trash one
+// by one, handling the children of each according to policy.
+func (c *Category) DeleteCategories(ctx context.Context, ids []int64, policy models.CategoryDeletePolicy, result *models.BulkResult) error {
+	return runBulk(ctx, c.db, result, func(tx database.Querier) error {
+		failDuplicateIDs(result, ids)
+
+		now := time.Now()
+
+		for _, i := range pending(result) {
+			id := strconv.FormatInt(ids[i], 10)
+
+			err := deleteCategory(ctx, tx, id, policy, 0, now)
+
+			var appErr *apperrors.Error
+			if errors.As(err, &appErr) {
+				result.Fail(i, err)
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			result.Succeed(i, id)
+		}
+
+		return nil
+	})
+}
+
+func deleteCategory(ctx context.Context, tx database.Querier, categoryID string, policy models.CategoryDeletePolicy, version int64, now time.Time) error {
+	switch policy {
+	case models.CategoryDeleteReject:
+		var hasChildren bool
+
+		err := tx.QueryRowContext(ctx, CheckCategoryHasChildren, categoryID).Scan(&hasChildren)
+		if err != nil {
+			return fmt.Errorf("failed to check category children: %w", err)
+		}
+
+		if hasChildren {
+			return ErrCategoryHasChildren
+		}
+	case models.CategoryDeleteCascade:
+		deleted, err := queryIDs(ctx, tx, DeleteCategoryDescendants, categoryID, now)
+		if err != nil {
+			return fmt.Errorf("failed to delete category descendants: %w", err)
+		}
+
+		err = recordEvents(ctx, tx, models.EventCategory, models.EventDeleted, deleted...)
+		if err != nil {
+			return err
+		}
+	case models.CategoryDeleteReparent:
+		moved, err := queryIDs(ctx, tx, ReparentCategoryChildren, categoryID, now)
+		if err != nil {
+			return fmt.Errorf("failed to reparent category children: %w", err)
+		}
+
+		err = recordEvents(ctx, tx, models.EventCategory, models.EventUpdated, moved...)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown category delete policy %q", policy)
+	}
+
+	result, err := tx.ExecContext(ctx, DeleteCategory, categoryID, now, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	err = requireVersion(ctx, tx, result, version, CheckCategoryIDExists, categoryID, ErrCategoryNotFound)
+	if err != nil {
+		return err
+	}
+
+	return recordEvents(ctx, tx, models.EventCategory, models.EventDeleted, categoryID)
 }
 
-func (c *Category) DeleteCategory(categoryID string) error {
-	_, err := c.db.Exec(DeleteCategory, categoryID)
+// requireMovable checks that parentID exists and is not the category itself
+// or one of its descendants.
+func requireMovable(ctx context.Context, db database.Querier, categoryID string, parentID int64) error {
+	err := requireParentCategory(ctx, db, parentID)
 	if err != nil {
 		return err
 	}
 
+	var cycle bool
+
+	err = db.QueryRowContext(ctx, CheckCategoryInSubtree, categoryID, parentID).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check category subtree: %w", err)
+	}
+
+	if cycle {
+		return ErrCategoryCycle
+	}
+
 	return nil
 }
 
-func checkCategoryExists(categoryName string, db database.Database) (bool, error) {
+func requireParentCategory(ctx context.Context, db database.Querier, parentID int64) error {
 	var exists bool
 
-	err := db.QueryRow(CheckCategoryExists, categoryName).Scan(&exists)
+	err := db.QueryRowContext(ctx, CheckCategoryIDExists, parentID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check parent category exist: %w", err)
+	}
+
+	if !exists {
+		return ErrParentCategoryNotFound
+	}
+
+	return nil
+}
+
+func checkCategoryExists(ctx context.Context, categoryName string, db database.Querier) (bool, error) {
+	var exists bool
+
+	err := db.QueryRowContext(ctx, CheckCategoryExists, categoryName).Scan(&exists)
 	if err != nil {
 		return exists, err
 	}
 
 	return exists, nil
 }
+
+var categoryList = listSpec[models.CategoryResponse]{
+	selectQuery: ListCategories,
+	countQuery:  CountCategories,
+	sortKeys: map[string]sortKey[models.CategoryResponse]{
+		"id":         {column: "id", value: func(c models.CategoryResponse) string { return c.ID }},
+		"name":       {column: "name", value: func(c models.CategoryResponse) string { return c.Name }},
+		"created_at": {column: "created_at", value: func(c models.CategoryResponse) string { return formatCursorTime(c.CreatedAt) }},
+		"updated_at": {column: "updated_at", value: func(c models.CategoryResponse) string { return formatCursorTime(c.UpdatedAt) }},
+	},
+	scan: scanCategory,
+}
+
+func scanCategory(rows *sql.Rows) (models.CategoryResponse, error) {
+	var category models.CategoryResponse
+
+	err := rows.Scan(&category.ID, &category.Name, &category.ParentID, &category.CreatedAt, &category.UpdatedAt)
+
+	return category, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"regexp"
@@ -31,7 +32,6 @@ var _ = Describe("CategoryRepository", func() {
 		category = &models.Category{
 			ID:        "1",
 			Name:      "test product",
-			ProductID: 1,
 			CreatedAt: time.Time{},
 			UpdatedAt: time.Time{},
 		}
@@ -48,52 +48,53 @@ var _ = Describe("CategoryRepository", func() {
 	Describe("GetCategory", func() {
 		It("should return category when found", func() {
 			now := time.Now()
-			rows := sqlmock.NewRows([]string{"name", "product_id", "created_at", "updated_at"}).
-				AddRow("Books", 1, now, now)
+			rows := sqlmock.NewRows([]string{"name", "parent_id", "created_at", "updated_at", "version"}).
+				AddRow("Books", 3, now, now, 2)
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, product_id, created_at, updated_at FROM category WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryByID)).
 				WithArgs(category.ID).
 				WillReturnRows(rows)
 
-			categoryResponse, err = repo.GetCategory(category.ID)
+			categoryResponse, err = repo.GetCategory(context.Background(), category.ID)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(categoryResponse).ShouldNot(BeNil())
 			Expect(categoryResponse.Name).Should(Equal("Books"))
-			Expect(categoryResponse.ProductID).Should(Equal(1))
+			Expect(*categoryResponse.ParentID).Should(Equal(int64(3)))
 			Expect(categoryResponse.CreatedAt).Should(Equal(now))
 			Expect(categoryResponse.UpdatedAt).Should(Equal(now))
+			Expect(categoryResponse.Version).Should(Equal(int64(2)))
 		})
 
 		It("should return error when category not found", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, product_id, created_at, updated_at FROM category WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryByID)).
 				WithArgs(category.ID).
 				WillReturnError(sql.ErrNoRows)
 
-			categoryResponse, err = repo.GetCategory(category.ID)
+			categoryResponse, err = repo.GetCategory(context.Background(), category.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(categoryResponse).Should(BeNil())
-			Expect(errors.Is(err, sql.ErrNoRows)).Should(BeTrue())
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
 		})
 
 		It("should return error on query failure", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, product_id, created_at, updated_at FROM category WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryByID)).
 				WithArgs(category.ID).WillReturnError(errors.New("query error"))
 
-			categoryResponse, err = repo.GetCategory(category.ID)
+			categoryResponse, err = repo.GetCategory(context.Background(), category.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(categoryResponse).Should(BeNil())
 			Expect(err.Error()).Should(ContainSubstring("failed to get category: query error"))
 		})
 
 		It("should return error on scan failure", func() {
-			rows := sqlmock.NewRows([]string{"name", "product_id", "created_at", "updated_at"}).
-				AddRow("test category", 1, "invalid time", time.Now())
+			rows := sqlmock.NewRows([]string{"name", "parent_id", "created_at", "updated_at", "version"}).
+				AddRow("test category", nil, "invalid time", time.Now(), 1)
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, product_id, created_at, updated_at FROM category WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryByID)).
 				WithArgs(category.ID).
 				WillReturnRows(rows)
 
-			categoryResponse, err = repo.GetCategory(category.ID)
+			categoryResponse, err = repo.GetCategory(context.Background(), category.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(categoryResponse).Should(BeNil())
 			Expect(err.Error()).Should(ContainSubstring("failed to get category: sql: Scan error"))
@@ -102,97 +103,676 @@ var _ = Describe("CategoryRepository", func() {
 
 	Describe("Update Category", func() {
 		It("should update category successfully", func() {
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE category SET name = $2, product_id = $3, updated_at = $4 WHERE id = $1")).
-				WithArgs(category.ID, category.Name, category.ProductID, sqlmock.AnyArg()).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateCategory)).
+				WithArgs(category.ID, category.Name, sqlmock.AnyArg(), int64(0)).
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, category.ID)
+			mock.ExpectCommit()
 
-			err := repo.UpdateCategory(*category)
+			err := repo.UpdateCategory(context.Background(), *category)
 			Expect(err).Should(BeNil())
 		})
 
 		It("should return error when update fails", func() {
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE category SET name = $2, product_id = $3, updated_at = $4 WHERE id = $1")).
-				WithArgs(category.ID, category.Name, category.ProductID, sqlmock.AnyArg()).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateCategory)).
+				WithArgs(category.ID, category.Name, sqlmock.AnyArg(), int64(0)).
 				WillReturnError(errors.New("update error"))
+			mock.ExpectRollback()
 
-			err := repo.UpdateCategory(*category)
+			err := repo.UpdateCategory(context.Background(), *category)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to update category: update error"))
 		})
 
+		It("should return a version conflict when the category was modified", func() {
+			category.Version = 3
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateCategory)).
+				WithArgs(category.ID, category.Name, sqlmock.AnyArg(), int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.UpdateCategory(context.Background(), *category)
+			Expect(errors.Is(err, ErrVersionConflict)).Should(BeTrue())
+		})
+
+		It("should return not found when a versioned category is missing", func() {
+			category.Version = 3
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateCategory)).
+				WithArgs(category.ID, category.Name, sqlmock.AnyArg(), int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.UpdateCategory(context.Background(), *category)
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+
 		It("should return error when category ID is missing", func() {
 			category.ID = ""
 
-			err := repo.UpdateCategory(*category)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, "").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.UpdateCategory(context.Background(), *category)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to update category:"))
 		})
+
+		It("should return ErrCategoryAlreadyExists when another category has the name", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs(category.Name, category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.UpdateCategory(context.Background(), *category)
+			Expect(errors.Is(err, ErrCategoryAlreadyExists)).Should(BeTrue())
+		})
 	})
 
 	Describe("Create Category", func() {
 		It("should create category successfully", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM category WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
 				WithArgs(category.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO category (name, product_id) VALUES ($1, $2)")).
-				WithArgs(category.Name, category.ProductID).
-				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateCategory)).
+				WithArgs(category.Name, nil).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			expectEvents(mock, models.EventCategory, models.EventCreated, "1")
+			mock.ExpectCommit()
 
-			err := repo.CreateCategory(*category)
+			err := repo.CreateCategory(context.Background(), *category)
 			Expect(err).Should(BeNil())
 		})
 
 		It("should return error when category already exists", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM category WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
 				WithArgs(category.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
 
-			err := repo.CreateCategory(*category)
+			err := repo.CreateCategory(context.Background(), *category)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("category already exists"))
 		})
 
 		It("should return error when checking category existence fails", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM category WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
 				WithArgs(category.Name).WillReturnError(errors.New("db error"))
+			mock.ExpectRollback()
 
-			err := repo.CreateCategory(*category)
+			err := repo.CreateCategory(context.Background(), *category)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to check category exist: db error"))
 		})
 
+		It("should create category below an existing parent", func() {
+			parentID := int64(2)
+			category.ParentID = &parentID
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
+				WithArgs(category.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateCategory)).
+				WithArgs(category.Name, parentID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+			expectEvents(mock, models.EventCategory, models.EventCreated, "3")
+			mock.ExpectCommit()
+
+			err := repo.CreateCategory(context.Background(), *category)
+			Expect(err).Should(BeNil())
+		})
+
+		It("should return error when the parent does not exist", func() {
+			parentID := int64(2)
+			category.ParentID = &parentID
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
+				WithArgs(category.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.CreateCategory(context.Background(), *category)
+			Expect(errors.Is(err, ErrParentCategoryNotFound)).Should(BeTrue())
+		})
+
 		It("should return error when creating category fails", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM category WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryExists)).
 				WithArgs(category.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-			mock.ExpectExec(regexp.QuoteMeta(
-				"INSERT INTO category (name, product_id) VALUES ($1, $2)")).
-				WithArgs(category.Name, category.ProductID).
+			mock.ExpectQuery(regexp.QuoteMeta(CreateCategory)).
+				WithArgs(category.Name, nil).
 				WillReturnError(errors.New("insert error"))
+			mock.ExpectRollback()
 
-			err := repo.CreateCategory(*category)
+			err := repo.CreateCategory(context.Background(), *category)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to create category: insert error"))
 		})
 	})
 
+	Describe("GetCategoryTree", func() {
+		columns := []string{"id", "name", "parent_id", "created_at", "updated_at"}
+
+		It("should nest categories below their parents", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryTree)).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("1", "Books", nil, now, now).
+					AddRow("2", "Music", nil, now, now).
+					AddRow("3", "Fiction", 1, now, now).
+					AddRow("4", "Fantasy", 3, now, now))
+
+			tree, err := repo.GetCategoryTree(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tree).Should(HaveLen(2))
+			Expect(tree[0].Name).Should(Equal("Books"))
+			Expect(tree[0].Children).Should(HaveLen(1))
+			Expect(tree[0].Children[0].Name).Should(Equal("Fiction"))
+			Expect(tree[0].Children[0].Children[0].Name).Should(Equal("Fantasy"))
+			Expect(tree[1].Children).Should(BeEmpty())
+		})
+
+		It("should return an empty tree without categories", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryTree)).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			tree, err := repo.GetCategoryTree(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tree).ShouldNot(BeNil())
+			Expect(tree).Should(BeEmpty())
+		})
+
+		It("should return error on query failure", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryTree)).
+				WillReturnError(errors.New("query error"))
+
+			_, err := repo.GetCategoryTree(context.Background())
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to get category tree: query error"))
+		})
+	})
+
+	Describe("GetCategorySubtree", func() {
+		columns := []string{"id", "name", "parent_id", "created_at", "updated_at"}
+
+		It("should return the category with its descendants", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategorySubtree)).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("3", "Fiction", 1, now, now).
+					AddRow("4", "Fantasy", 3, now, now).
+					AddRow("5", "Crime", 3, now, now))
+
+			subtree, err := repo.GetCategorySubtree(context.Background(), "3")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(subtree.ID).Should(Equal("3"))
+			Expect(subtree.Children).Should(HaveLen(2))
+		})
+
+		It("should return error when category not found", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategorySubtree)).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			_, err := repo.GetCategorySubtree(context.Background(), "3")
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("GetCategoryBreadcrumb", func() {
+		columns := []string{"id", "name", "parent_id", "created_at", "updated_at"}
+
+		It("should return the ancestors from the root down", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryAncestors)).
+				WithArgs("4").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("1", "Books", nil, now, now).
+					AddRow("3", "Fiction", 1, now, now).
+					AddRow("4", "Fantasy", 3, now, now))
+
+			breadcrumb, err := repo.GetCategoryBreadcrumb(context.Background(), "4")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(breadcrumb).Should(HaveLen(3))
+			Expect(breadcrumb[0].ID).Should(Equal("1"))
+			Expect(breadcrumb[2].ID).Should(Equal("4"))
+		})
+
+		It("should return error when category not found", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetCategoryAncestors)).
+				WithArgs("4").
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			_, err := repo.GetCategoryBreadcrumb(context.Background(), "4")
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("PatchCategory", func() {
+		parentID := int64(4)
+
+		It("should check the new parent and write only the changed columns", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryInSubtree)).
+				WithArgs(category.ID, parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE category SET parent_id = $2, updated_at = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)")).
+				WithArgs(category.ID, &parentID, sqlmock.AnyArg(), int64(2)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.PatchCategory(context.Background(), category.ID, models.CategoryPatch{
+				Name:     "Books",
+				ParentID: &parentID,
+				Fields:   models.PatchFields{"parent_id": true},
+				Version:  2,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should rename without checking the parent", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryNameTaken)).
+				WithArgs("Novels", category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE category SET name = $2, updated_at = $3, version = version + 1 WHERE id = $1")).
+				WithArgs(category.ID, "Novels", sqlmock.AnyArg(), int64(2)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.PatchCategory(context.Background(), category.ID, models.CategoryPatch{
+				Name:     "Novels",
+				ParentID: &parentID,
+				Fields:   models.PatchFields{"name": true},
+				Version:  2,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should reject moving a category below its own descendant", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryInSubtree)).
+				WithArgs(category.ID, parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.PatchCategory(context.Background(), category.ID, models.CategoryPatch{
+				ParentID: &parentID,
+				Fields:   models.PatchFields{"parent_id": true},
+				Version:  2,
+			})
+			Expect(errors.Is(err, ErrCategoryCycle)).Should(BeTrue())
+		})
+	})
+
+	Describe("MoveCategory", func() {
+		parentID := int64(4)
+
+		It("should move the category below the new parent", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryInSubtree)).
+				WithArgs(category.ID, parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(MoveCategory)).
+				WithArgs(category.ID, parentID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.MoveCategory(context.Background(), category.ID, &parentID, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should move the category to the root", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(MoveCategory)).
+				WithArgs(category.ID, nil, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.MoveCategory(context.Background(), category.ID, nil, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should reject moving a category below its own descendant", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryInSubtree)).
+				WithArgs(category.ID, parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.MoveCategory(context.Background(), category.ID, &parentID, 0)
+			Expect(errors.Is(err, ErrCategoryCycle)).Should(BeTrue())
+		})
+
+		It("should return error when the parent does not exist", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(parentID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.MoveCategory(context.Background(), category.ID, &parentID, 0)
+			Expect(errors.Is(err, ErrParentCategoryNotFound)).Should(BeTrue())
+		})
+
+		It("should return error when category not found", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(MoveCategory)).
+				WithArgs(category.ID, nil, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.MoveCategory(context.Background(), category.ID, nil, 0)
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+	})
+
 	Describe("Delete Category", func() {
-		It("should delete category successfully", func() {
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM category WHERE id = $1")).
-				WithArgs(category.ID).
+		It("should delete a category without children", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryHasChildren)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(0)).
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, category.ID)
+			mock.ExpectCommit()
 
-			err := repo.DeleteCategory(category.ID)
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteReject, 0)
 			Expect(err).Should(BeNil())
 		})
 
+		It("should reject deleting a category with children", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryHasChildren)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteReject, 0)
+			Expect(errors.Is(err, ErrCategoryHasChildren)).Should(BeTrue())
+		})
+
+		It("should delete the whole subtree on cascade", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteCategoryDescendants)).
+				WithArgs(category.ID, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2").AddRow("3"))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, "2", "3")
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteCascade, 0)
+			Expect(err).Should(BeNil())
+		})
+
+		It("should move the children up on reparent", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ReparentCategoryChildren)).
+				WithArgs(category.ID, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2").AddRow("3"))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, "2", "3")
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, category.ID)
+			mock.ExpectCommit()
+
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteReparent, 0)
+			Expect(err).Should(BeNil())
+		})
+
+		It("should return error when category not found", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteCategoryDescendants)).
+				WithArgs(category.ID, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteCascade, 0)
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+
+		It("should roll back when the category version does not match", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteCategoryDescendants)).
+				WithArgs(category.ID, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2").AddRow("3"))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, "2", "3")
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(5)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteCascade, 5)
+			Expect(errors.Is(err, ErrVersionConflict)).Should(BeTrue())
+		})
+
 		It("should return error when deletion fails", func() {
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM category WHERE id = $1")).
-				WithArgs(category.ID).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryHasChildren)).
+				WithArgs(category.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs(category.ID, sqlmock.AnyArg(), int64(0)).
 				WillReturnError(errors.New("delete error"))
+			mock.ExpectRollback()
 
-			err := repo.DeleteCategory(category.ID)
+			err := repo.DeleteCategory(context.Background(), category.ID, models.CategoryDeleteReject, 0)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("delete error"))
 		})
 	})
+
+	Describe("CreateCategories", func() {
+		It("should create every category with one insert", func() {
+			parentID := int64(1)
+			categories := []models.Category{{Name: "Books"}, {Name: "Fantasy", ParentID: &parentID}}
+			result := models.NewBulkResult(models.BulkAtomic, len(categories))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryNames)).
+				WithArgs(`{"Books","Fantasy"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateCategories)).
+				WithArgs(`{"Books","Fantasy"}`, "{NULL,1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("5", "Books").AddRow("6", "Fantasy"))
+			expectEvents(mock, models.EventCategory, models.EventCreated, "5", "6")
+			mock.ExpectCommit()
+
+			err := repo.CreateCategories(context.Background(), categories, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Succeeded).Should(Equal(2))
+			Expect(result.Results[1].ID).Should(Equal("6"))
+		})
+
+		It("should fail items whose parent does not exist", func() {
+			parentID := int64(9)
+			categories := []models.Category{{Name: "Books"}, {Name: "Fantasy", ParentID: &parentID}}
+			result := models.NewBulkResult(models.BulkAtomic, len(categories))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{9}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectRollback()
+
+			err := repo.CreateCategories(context.Background(), categories, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Results[1].Code).Should(Equal("parent_category_not_found"))
+		})
+	})
+
+	Describe("UpdateCategories", func() {
+		It("should rename every category with one statement", func() {
+			categories := []models.Category{{ID: "1", Name: "Books"}, {ID: "2", Name: "Music"}}
+			result := models.NewBulkResult(models.BulkAtomic, len(categories))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1,2}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryNameOwners)).
+				WithArgs(`{"Books","Music"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("Books", "1"))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateCategories)).
+				WithArgs("{1,2}", `{"Books","Music"}`, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			expectEvents(mock, models.EventCategory, models.EventUpdated, "1", "2")
+			mock.ExpectCommit()
+
+			err := repo.UpdateCategories(context.Background(), categories, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Succeeded).Should(Equal(2))
+		})
+		It("should fail a rename to the name of another category", func() {
+			categories := []models.Category{{ID: "1", Name: "Music"}}
+			result := models.NewBulkResult(models.BulkAtomic, len(categories))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryNameOwners)).
+				WithArgs(`{"Music"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("Music", "2"))
+			mock.ExpectRollback()
+
+			err := repo.UpdateCategories(context.Background(), categories, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Results[0].Code).Should(Equal("category_already_exists"))
+		})
+	})
+
+	Describe("DeleteCategories", func() {
+		It("should record per-item failures of a partial batch", func() {
+			result := models.NewBulkResult(models.BulkPartial, 2)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryHasChildren)).
+				WithArgs("1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryHasChildren)).
+				WithArgs("2").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteCategory)).
+				WithArgs("2", sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventDeleted, "2")
+			mock.ExpectCommit()
+
+			err := repo.DeleteCategories(context.Background(), []int64{1, 2}, models.CategoryDeleteReject, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Results[0].Code).Should(Equal("category_has_children"))
+			Expect(result.Results[1].ID).Should(Equal("2"))
+			Expect(result.Succeeded).Should(Equal(1))
+		})
+
+		It("should return error when a statement fails", func() {
+			result := models.NewBulkResult(models.BulkPartial, 1)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ReparentCategoryChildren)).
+				WithArgs("1", sqlmock.AnyArg()).
+				WillReturnError(errors.New("db error"))
+			mock.ExpectRollback()
+
+			err := repo.DeleteCategories(context.Background(), []int64{1}, models.CategoryDeleteReparent, result)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to reparent category children: db error"))
+		})
+	})
+
+	Describe("ImportCategories", func() {
+		It("should create new categories and rename existing ones", func() {
+			categories := []models.Category{{Name: "Books"}, {ID: "3", Name: "Music"}}
+			result := models.NewBulkResult(models.BulkPartial, len(categories))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryNames)).
+				WithArgs(`{"Books"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateCategories)).
+				WithArgs(`{"Books"}`, "{NULL}").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("5", "Books"))
+			expectEvents(mock, models.EventCategory, models.EventCreated, "5")
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{3}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectCommit()
+
+			err := repo.ImportCategories(context.Background(), categories, result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Succeeded).Should(Equal(1))
+			Expect(result.Results[0].ID).Should(Equal("5"))
+			Expect(result.Results[1].Code).Should(Equal("category_not_found"))
+		})
+	})
+
+	Describe("ExportCategories", func() {
+		It("should call fn with every row", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(ExportCategories)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id", "created_at", "updated_at"}).
+					AddRow("1", "Books", nil, time.Time{}, time.Time{}).
+					AddRow("2", "Fantasy", 1, time.Time{}, time.Time{}))
+
+			var names []string
+
+			err := repo.ExportCategories(context.Background(), func(category models.CategoryResponse) error {
+				names = append(names, category.Name)
+				return nil
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(names).Should(Equal([]string{"Books", "Fantasy"}))
+		})
+	})
 })
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/database"
+)
+
+var (
+	ErrProductNotFound      = apperrors.NotFound("product_not_found", "product not found")
+	ErrProductAlreadyExists = apperrors.Conflict("product_already_exists", "product already exists")
+
+	ErrCategoryNotFound       = apperrors.NotFound("category_not_found", "category not found")
+	ErrCategoryAlreadyExists  = apperrors.Conflict("category_already_exists", "category already exists")
+	ErrParentCategoryNotFound = apperrors.NotFound("parent_category_not_found", "parent category not found")
+	ErrCategoryCycle          = apperrors.Conflict("category_cycle", "category cannot be moved below itself")
+	ErrCategoryHasChildren    = apperrors.Conflict("category_has_children", "category has child categories")
+
+	ErrUserNotFound      = apperrors.NotFound("user_not_found", "user not found")
+	ErrUserAlreadyExists = apperrors.Conflict("user_already_exists", "user already exists")
+
+	ErrSessionNotFound     = apperrors.NotFound("session_not_found", "session not found")
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperrors.Unauthorized("refresh_token_reused", "refresh token reused, session revoked")
+
+	ErrVersionConflict = apperrors.PreconditionFailed("version_conflict", "resource was modified by another request")
+
+	ErrDuplicateItem = apperrors.Conflict("duplicate_item", "item repeats an earlier item of the batch")
+
+	ErrJobNotFound = apperrors.NotFound("job_not_found", "job not found")
+	// ErrJobLost is returned to a worker whose job was claimed by another
+	// worker after its lease ran out.
+	ErrJobLost = apperrors.Conflict("job_lost", "job was claimed by another worker")
+
+	ErrWebhookNotFound = apperrors.NotFound("webhook_not_found", "webhook subscription not found")
+	// ErrDeliveryLost is returned to a dispatcher whose delivery was claimed
+	// by another dispatcher after its lease ran out.
+	ErrDeliveryLost = apperrors.Conflict("delivery_lost", "webhook delivery was claimed by another dispatcher")
+
+	ErrTrashKindNotFound = apperrors.NotFound("trash_kind_not_found", "unknown trash kind")
+
+	ErrInvalidSort   = apperrors.BadRequest("invalid_sort", "invalid sort column")
+	ErrInvalidCursor = apperrors.BadRequest("invalid_cursor", "invalid cursor")
+)
+
+// requireAffected returns notFound when a write matched no rows.
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// requireVersion is requireAffected for writes guarded by the row version,
+// where version 0 means unguarded. When a guarded write matched nothing,
+// existsQuery tells a missing row from one that changed since it was read.
+func requireVersion(ctx context.Context, db database.Querier, result sql.Result, version int64, existsQuery, id string, notFound error) error {
+	err := requireAffected(result, notFound)
+	if version == 0 || !errors.Is(err, notFound) {
+		return err
+	}
+
+	var exists bool
+
+	err = db.QueryRowContext(ctx, existsQuery, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check row exists: %w", err)
+	}
+
+	if exists {
+		return ErrVersionConflict
+	}
+
+	return notFound
+}
+
+// requireFree returns taken when takenQuery finds a live row other than id
+// with the unique key, e.g. the name a row is renamed to.
+func requireFree(ctx context.Context, db database.Querier, takenQuery, key, id string, taken error) error {
+	var exists bool
+
+	err := db.QueryRowContext(ctx, takenQuery, key, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check key is free: %w", err)
+	}
+
+	if exists {
+		return taken
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether err comes from a unique index. The
+// existence checks before a write can still race with a concurrent write,
+// the index catches what they miss.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+)
+
+type JobRepository interface {
+	CreateJob(ctx context.Context, job *models.Job) error
+	GetJob(ctx context.Context, id, userID string) (*models.Job, error)
+	ClaimJob(ctx context.Context, lease time.Duration) (*models.Job, error)
+	UpdateJobProgress(ctx context.Context, job *models.Job, progress int) error
+	CompleteJob(ctx context.Context, job *models.Job, result json.RawMessage) error
+	RetryJob(ctx context.Context, job *models.Job, runAt time.Time, message string) error
+	FailJob(ctx context.Context, job *models.Job, message string) error
+}
+
+type Job struct {
+	db database.Database
+}
+
+func NewJob(db database.Database) JobRepository {
+	return &Job{db: db}
+}
+
+// CreateJob queues job and fills in the columns the database sets.
+func (j *Job) CreateJob(ctx context.Context, job *models.Job) error {
+	payload := job.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+
+	var createdBy sql.NullString
+	if job.CreatedBy != "" {
+		createdBy = sql.NullString{String: job.CreatedBy, Valid: true}
+	}
+
+	// JSON is sent as text, lib/pq would send []byte as bytea.
+	err := j.db.QueryRowContext(ctx, CreateJob, job.Kind, string(payload), job.MaxAttempts, createdBy).
+		Scan(&job.ID, &job.Status, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob returns a job created by userID. Jobs of other users are not found.
+func (j *Job) GetJob(ctx context.Context, id, userID string) (*models.Job, error) {
+	var (
+		job          models.Job
+		result       []byte
+		errorMessage sql.NullString
+	)
+
+	err := j.db.QueryRowContext(ctx, GetJob, id, userID).
+		Scan(&job.ID, &job.Kind, &job.Status, &result, &errorMessage, &job.Progress, &job.Attempts,
+			&job.MaxAttempts, &job.RunAt, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	job.Result = result
+	job.Error = errorMessage.String
+
+	return &job, nil
+}
+
+// ClaimJob marks the next due job as running and returns it, or nil when no
+// job is due. A running job whose lock is older than lease is claimed again,
+// its worker is presumed dead.
+func (j *Job) ClaimJob(ctx context.Context, lease time.Duration) (*models.Job, error) {
+	job := models.Job{Status: models.JobRunning}
+
+	err := j.db.QueryRowContext(ctx, ClaimJob, lease.Seconds()).
+		Scan(&job.ID, &job.Kind, &job.Payload, &job.Attempts, &job.MaxAttempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// UpdateJobProgress records progress and renews the lease of a running job.
+func (j *Job) UpdateJobProgress(ctx context.Context, job *models.Job, progress int) error {
+	result, err := j.db.ExecContext(ctx, UpdateJobProgress, job.ID, job.Attempts, progress)
+	if err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+
+	return requireAffected(result, ErrJobLost)
+}
+
+func (j *Job) CompleteJob(ctx context.Context, job *models.Job, result json.RawMessage) error {
+	var value sql.NullString
+	if len(result) > 0 {
+		value = sql.NullString{String: string(result), Valid: true}
+	}
+
+	res, err := j.db.ExecContext(ctx, CompleteJob, job.ID, job.Attempts, value)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	return requireAffected(res, ErrJobLost)
+}
+
+// RetryJob queues a job whose attempt failed again, due at runAt.
+func (j *Job) RetryJob(ctx context.Context, job *models.Job, runAt time.Time, message string) error {
+	result, err := j.db.ExecContext(ctx, RetryJob, job.ID, job.Attempts, message, runAt)
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+
+	return requireAffected(result, ErrJobLost)
+}
+
+// FailJob dead-letters a job. It is kept with its error and never runs
+// again.
+func (j *Job) FailJob(ctx context.Context, job *models.Job, message string) error {
+	result, err := j.db.ExecContext(ctx, FailJob, job.ID, job.Attempts, message)
+	if err != nil {
+		return fmt.Errorf("failed to fail job: %w", err)
+	}
+
+	return requireAffected(result, ErrJobLost)
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"awesomeProject/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Job Repository", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		repo JobRepository
+		job  *models.Job
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		repo = NewJob(db)
+		job = &models.Job{ID: "7", Kind: "products.export", Attempts: 2, MaxAttempts: 5}
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("CreateJob", func() {
+		It("should queue the job with an empty payload as an object", func() {
+			now := time.Now()
+			job = &models.Job{Kind: "products.export", MaxAttempts: 5, CreatedBy: "1"}
+
+			mock.ExpectQuery(regexp.QuoteMeta(CreateJob)).
+				WithArgs("products.export", "{}", 5, "1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "status", "run_at", "created_at", "updated_at"}).
+					AddRow("7", "queued", now, now, now))
+
+			err = repo.CreateJob(context.Background(), job)
+			Expect(err).Should(BeNil())
+			Expect(job.ID).Should(Equal("7"))
+			Expect(job.Status).Should(Equal(models.JobQueued))
+		})
+	})
+
+	Describe("GetJob", func() {
+		It("should return the job with its result", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(GetJob)).
+				WithArgs("7", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "status", "result", "error", "progress", "attempts",
+					"max_attempts", "run_at", "created_at", "updated_at", "finished_at"}).
+					AddRow("7", "products.export", "succeeded", []byte(`{"rows":2}`), nil, 100, 1, 5, now, now, now, now))
+
+			found, err := repo.GetJob(context.Background(), "7", "1")
+			Expect(err).Should(BeNil())
+			Expect(found.Status).Should(Equal(models.JobSucceeded))
+			Expect(string(found.Result)).Should(Equal(`{"rows":2}`))
+			Expect(found.Error).Should(BeEmpty())
+			Expect(found.FinishedAt).ShouldNot(BeNil())
+		})
+		It("should return ErrJobNotFound for a job of another user", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetJob)).
+				WithArgs("7", "2").
+				WillReturnError(sql.ErrNoRows)
+
+			_, err = repo.GetJob(context.Background(), "7", "2")
+			Expect(errors.Is(err, ErrJobNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("ClaimJob", func() {
+		It("should return the claimed job", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(ClaimJob)).
+				WithArgs(float64(300)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "payload", "attempts", "max_attempts"}).
+					AddRow("7", "products.export", []byte(`{"format":"csv"}`), 1, 5))
+
+			claimed, err := repo.ClaimJob(context.Background(), 5*time.Minute)
+			Expect(err).Should(BeNil())
+			Expect(claimed.Status).Should(Equal(models.JobRunning))
+			Expect(claimed.Attempts).Should(Equal(1))
+			Expect(string(claimed.Payload)).Should(Equal(`{"format":"csv"}`))
+		})
+		It("should return nil when no job is due", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(ClaimJob)).
+				WithArgs(float64(300)).
+				WillReturnError(sql.ErrNoRows)
+
+			claimed, err := repo.ClaimJob(context.Background(), 5*time.Minute)
+			Expect(err).Should(BeNil())
+			Expect(claimed).Should(BeNil())
+		})
+	})
+
+	Describe("UpdateJobProgress", func() {
+		It("should return ErrJobLost once another worker claimed the job", func() {
+			mock.ExpectExec(regexp.QuoteMeta(UpdateJobProgress)).
+				WithArgs("7", 2, 50).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err = repo.UpdateJobProgress(context.Background(), job, 50)
+			Expect(errors.Is(err, ErrJobLost)).Should(BeTrue())
+		})
+	})
+
+	Describe("CompleteJob", func() {
+		It("should store the result as text", func() {
+			mock.ExpectExec(regexp.QuoteMeta(CompleteJob)).
+				WithArgs("7", 2, `{"rows":2}`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = repo.CompleteJob(context.Background(), job, json.RawMessage(`{"rows":2}`))
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Describe("RetryJob", func() {
+		It("should queue the job again at runAt", func() {
+			runAt := time.Now().Add(time.Minute)
+
+			mock.ExpectExec(regexp.QuoteMeta(RetryJob)).
+				WithArgs("7", 2, "boom", runAt).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = repo.RetryJob(context.Background(), job, runAt, "boom")
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Describe("FailJob", func() {
+		It("should dead-letter the job", func() {
+			mock.ExpectExec(regexp.QuoteMeta(FailJob)).
+				WithArgs("7", 2, "boom").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = repo.FailJob(context.Background(), job, "boom")
+			Expect(err).Should(BeNil())
+		})
+	})
+})
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// sortKey maps a whitelisted sort name to its column and to the value of that
+// column on a row, which is what the keyset cursor resumes from.
+type sortKey[T any] struct {
+	column string
+	value  func(T) string
+}
+
+// listSpec describes one listable table. Every table is paginated on
+// (sort column, id), so "id" must always be a sort key.
+type listSpec[T any] struct {
+	selectQuery string
+	countQuery  string
+	sortKeys    map[string]sortKey[T]
+	scan        func(rows *sql.Rows) (T, error)
+}
+
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// conditions accumulates WHERE clauses and their positional arguments.
+// Clauses use %d in place of the placeholder number.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+func (c *conditions) add(clause string, args ...interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i, arg := range args {
+		c.args = append(c.args, arg)
+		placeholders[i] = len(c.args)
+	}
+
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, placeholders...))
+}
+
+func (c *conditions) addCreatedRange(options models.ListOptions) {
+	if options.CreatedAfter != nil {
+		c.add("created_at >= $%d", *options.CreatedAfter)
+	}
+
+	if options.CreatedBefore != nil {
+		c.add("created_at < $%d", *options.CreatedBefore)
+	}
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+func listPage[T any](ctx context.Context, db database.Querier, spec listSpec[T], options models.ListOptions, filters conditions) (*models.Page[T], error) {
+	sortName, descending := strings.CutPrefix(options.Sort, "-")
+	if sortName == "" {
+		sortName = "id"
+	}
+
+	key, ok := spec.sortKeys[sortName]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	var total int64
+
+	err := db.QueryRowContext(ctx, spec.countQuery+filters.where(), filters.args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	direction, operator := "ASC", ">"
+	if descending {
+		direction, operator = "DESC", "<"
+	}
+
+	if options.Cursor != "" {
+		after, err := decodeCursor(options.Cursor)
+		if err != nil || after.Sort != options.Sort {
+			return nil, ErrInvalidCursor
+		}
+
+		filters.add(fmt.Sprintf("(%s, id) %s ($%%d, $%%d)", key.column, operator), after.Value, after.ID)
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY %s %s, id %s LIMIT %d",
+		spec.selectQuery, filters.where(), key.column, direction, direction, limit+1)
+
+	rows, err := db.QueryContext(ctx, query, filters.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rows: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]T, 0, limit+1)
+
+	for rows.Next() {
+		item, err := spec.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rows: %w", err)
+	}
+
+	page := &models.Page[T]{Meta: models.PageMeta{Total: total, Limit: limit}}
+
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[limit-1]
+
+		page.Meta.NextCursor = encodeCursor(cursor{
+			Sort:  options.Sort,
+			Value: key.value(last),
+			ID:    spec.sortKeys["id"].value(last),
+		})
+	}
+
+	page.Data = items
+
+	return page, nil
+}
+
+func encodeCursor(c cursor) string {
+	// Marshalling a struct of strings cannot fail.
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, err
+	}
+
+	err = json.Unmarshal(data, &c)
+
+	return c, err
+}
+
+func formatCursorTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// eachRow runs query and calls fn with every row it returns, scanned one at
+// a time. It stops at the first error fn returns.
+func eachRow[T any](ctx context.Context, db database.Querier, query string, scan func(*sql.Rows) (T, error), fn func(T) error) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row, err := scan(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+
+	"github.com/lib/pq"
+)
+
+// recordEvents adds an event for action on each of the resources ids to the
+// outbox. It must run in the transaction of the write it announces, so that
+// an event exists exactly when the write was committed.
+func recordEvents(ctx context.Context, tx database.Querier, resource, action string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, InsertEvents, models.EventType(resource, action), pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to record %s events: %w", models.EventType(resource, action), err)
+	}
+
+	return nil
+}
+
+// queryIDs returns the ids a statement returns, in order.
+func queryIDs(ctx context.Context, db database.Querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
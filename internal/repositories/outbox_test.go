@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"awesomeProject/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// expectEvents expects the outbox insert announcing action on the resources
+// ids.
+func expectEvents(mock sqlmock.Sqlmock, resource, action string, ids ...string) {
+	mock.ExpectExec(regexp.QuoteMeta(InsertEvents)).
+		WithArgs(models.EventType(resource, action), `{"`+strings.Join(ids, `","`)+`"}`).
+		WillReturnResult(sqlmock.NewResult(0, int64(len(ids))))
+}
+
+var _ = Describe("recordEvents", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	It("should add one event per id", func() {
+		expectEvents(mock, models.EventProduct, models.EventDeleted, "1", "2")
+
+		err = recordEvents(context.Background(), db, models.EventProduct, models.EventDeleted, "1", "2")
+		Expect(err).Should(BeNil())
+	})
+
+	It("should write nothing without ids", func() {
+		err = recordEvents(context.Background(), db, models.EventProduct, models.EventDeleted)
+		Expect(err).Should(BeNil())
+	})
+})
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// assignments accumulates the SET list of an UPDATE that writes only the
+// columns a PATCH changed. The row id is always $1.
+type assignments struct {
+	columns []string
+	args    []interface{}
+}
+
+func (a *assignments) set(column string, value interface{}) {
+	a.args = append(a.args, value)
+	a.columns = append(a.columns, fmt.Sprintf("%s = $%d", column, len(a.args)+1))
+}
+
+// update returns the statement writing the assignments to the live row id
+// and its arguments. prefix is the start of the statement up to the SET
+// list, one of the Patch queries. Like every other write it bumps the row version
+// and, for a non-zero version, only matches that version.
+func (a *assignments) update(prefix, id string, version int64) (string, []interface{}) {
+	args := make([]interface{}, 0, len(a.args)+2)
+	args = append(args, id)
+	args = append(args, a.args...)
+	args = append(args, version)
+
+	columns := make([]string, 0, len(a.columns)+1)
+	columns = append(columns, a.columns...)
+	columns = append(columns, "version = version + 1")
+
+	query := fmt.Sprintf("%s%s WHERE id = $1 AND deleted_at IS NULL AND ($%d = 0 OR version = $%d)",
+		prefix, strings.Join(columns, ", "), len(args), len(args))
+
+	return query, args
+}
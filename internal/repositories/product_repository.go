@@ -3,17 +3,33 @@ package repositories
 import (
 	"awesomeProject/internal/models"
 	"awesomeProject/pkg/database"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/lib/pq"
 )
 
 type ProductRepository interface {
-	GetProduct(productID string) (*models.ProductResponse, error)
-	UpdateProduct(product *models.Product) error
-	CreateProduct(product *models.Product) error
-	DeleteProduct(id string) error
+	GetProduct(ctx context.Context, productID string) (*models.ProductResponse, error)
+	ListProducts(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error)
+	SearchProducts(ctx context.Context, query string, options models.ListOptions) (*models.ProductSearchPage, error)
+	ListCategoryProducts(ctx context.Context, categoryID string, options models.ListOptions) (*models.ProductPage, error)
+	UpdateProduct(ctx context.Context, product *models.Product) error
+	PatchProduct(ctx context.Context, productID string, patch models.ProductPatch) error
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []int64, version int64) error
+	CreateProduct(ctx context.Context, product *models.Product) error
+	DeleteProduct(ctx context.Context, id string, version int64) error
+	CreateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error
+	UpdateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error
+	DeleteProducts(ctx context.Context, ids []int64, result *models.BulkResult) error
+	ImportProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error
+	ExportProducts(ctx context.Context, fn func(models.ProductResponse) error) error
 }
 
 type Product struct {
@@ -24,14 +40,14 @@ func NewProduct(db database.Database) ProductRepository {
 	return &Product{db: db}
 }
 
-func (p *Product) GetProduct(productID string) (*models.ProductResponse, error) {
+func (p *Product) GetProduct(ctx context.Context, productID string) (*models.ProductResponse, error) {
 	product := &models.ProductResponse{}
 
-	err := p.db.QueryRow(GetProduct, productID).
-		Scan(&product.Name, &product.CategoryID, &product.CreatedAt, &product.UpdatedAt)
+	err := p.db.QueryRowContext(ctx, GetProduct, productID).
+		Scan(&product.Name, pq.Array(&product.CategoryIDs), &product.CreatedAt, &product.UpdatedAt, &product.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("product not found: %w", err)
+			return nil, ErrProductNotFound
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
@@ -39,50 +55,593 @@ func (p *Product) GetProduct(productID string) (*models.ProductResponse, error)
 	return product, nil
 }
 
-func (p *Product) UpdateProduct(product *models.Product) error {
-	_, err := p.db.Exec(UpdateProduct, product.ID, product.Name, product.CategoryID, time.Now())
+func (p *Product) ListProducts(ctx context.Context, filter models.ProductFilter) (*models.ProductPage, error) {
+	var filters conditions
+
+	filters.add("deleted_at IS NULL")
+	if filter.CategoryID != 0 {
+		filters.add("id IN (SELECT product_id FROM product_categories WHERE category_id = $%d)", filter.CategoryID)
+	}
+	filters.addCreatedRange(filter.ListOptions)
+
+	page, err := listPage(ctx, p.db, productList, filter.ListOptions, filters)
 	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
 
-	return nil
+	return page, nil
 }
 
-func (p *Product) CreateProduct(product *models.Product) error {
+// SearchProducts ranks products by full-text match on every term as a prefix,
+// so partially typed words match, and falls back to trigram similarity on
+// the name to tolerate typos. Results are ordered by rank and paginated on
+// (rank, id), so options.Sort must be empty.
+func (p *Product) SearchProducts(ctx context.Context, query string, options models.ListOptions) (*models.ProductSearchPage, error) {
+	if options.Sort != "" {
+		return nil, ErrInvalidSort
+	}
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	page := &models.ProductSearchPage{
+		Data: []models.ProductSearchResult{},
+		Meta: models.PageMeta{Limit: limit},
+	}
+
+	searchQuery, args := SearchProducts, []interface{}{}
+
+	if options.Cursor != "" {
+		after, err := decodeSearchCursor(options.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		searchQuery, args = SearchProductsAfter, []interface{}{after.Value, after.ID}
+	}
+
+	tsQuery := prefixTSQuery(query)
+	if tsQuery == "" {
+		return page, nil
+	}
+
+	err := p.db.QueryRowContext(ctx, CountProductSearch, tsQuery, query).Scan(&page.Meta.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
 
-	exists, err := checkProductExists(product.Name, p.db)
+	rows, err := p.db.QueryContext(ctx, searchQuery, append([]interface{}{tsQuery, query, limit + 1}, args...)...)
 	if err != nil {
-		return fmt.Errorf("failed to check product exist: %w", err)
+		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
+	defer rows.Close()
 
-	if exists {
-		return errors.New("product already exists")
+	for rows.Next() {
+		var result models.ProductSearchResult
+
+		err = rows.Scan(&result.ID, &result.Name, pq.Array(&result.CategoryIDs), &result.CreatedAt, &result.UpdatedAt,
+			&result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+
+		page.Data = append(page.Data, result)
 	}
 
-	_, err = p.db.Exec(CreateProduct, product.Name, product.CategoryID)
+	err = rows.Err()
 	if err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
+		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
 
-	return nil
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		last := page.Data[limit-1]
+
+		page.Meta.NextCursor = encodeCursor(cursor{
+			Sort:  searchCursorSort,
+			Value: strconv.FormatFloat(last.Rank, 'g', -1, 64),
+			ID:    last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// searchCursorSort marks cursors of search results, so that a cursor of a
+// list endpoint is not taken for one.
+const searchCursorSort = "rank"
+
+// decodeSearchCursor decodes a search cursor and checks that its rank and id
+// are numbers, as the database would otherwise fail on them.
+func decodeSearchCursor(encoded string) (cursor, error) {
+	after, err := decodeCursor(encoded)
+	if err != nil || after.Sort != searchCursorSort {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	_, err = strconv.ParseFloat(after.Value, 64)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	_, err = strconv.ParseInt(after.ID, 10, 64)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	return after, nil
+}
+
+// ListCategoryProducts lists the products in a category, or returns
+// ErrCategoryNotFound so an unknown category is not mistaken for an empty one.
+func (p *Product) ListCategoryProducts(ctx context.Context, categoryID string, options models.ListOptions) (*models.ProductPage, error) {
+	var exists bool
+
+	err := p.db.QueryRowContext(ctx, CheckCategoryIDExists, categoryID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check category exist: %w", err)
+	}
+
+	if !exists {
+		return nil, ErrCategoryNotFound
+	}
+
+	var filters conditions
+
+	filters.add("deleted_at IS NULL")
+	filters.add("id IN (SELECT product_id FROM product_categories WHERE category_id = $%d)", categoryID)
+	filters.addCreatedRange(options)
+
+	page, err := listPage(ctx, p.db, productList, options, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list category products: %w", err)
+	}
+
+	return page, nil
+}
+
+// UpdateProduct replaces the product's name and categories. A non-zero
+// product.Version must match the stored row version, otherwise
+// ErrVersionConflict is returned. The name must not be taken by another
+// product.
+func (p *Product) UpdateProduct(ctx context.Context, product *models.Product) error {
+	return database.WithSerializableTx(ctx, p.db, func(tx database.Querier) error {
+		err := requireFree(ctx, tx, CheckProductNameTaken, product.Name, product.ID, ErrProductAlreadyExists)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, UpdateProduct, product.ID, product.Name, time.Now(), product.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, product.Version, CheckProductIDExists, product.ID, ErrProductNotFound)
+		if err != nil {
+			return err
+		}
+
+		err = replaceProductCategories(ctx, tx, product.ID, product.CategoryIDs)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventUpdated, product.ID)
+	})
+}
+
+// PatchProduct writes only the fields named in patch.Fields. patch.Version
+// must match the stored row version, otherwise ErrVersionConflict is
+// returned.
+func (p *Product) PatchProduct(ctx context.Context, productID string, patch models.ProductPatch) error {
+	return database.WithSerializableTx(ctx, p.db, func(tx database.Querier) error {
+		var set assignments
+
+		if patch.Fields["name"] {
+			err := requireFree(ctx, tx, CheckProductNameTaken, patch.Name, productID, ErrProductAlreadyExists)
+			if err != nil {
+				return err
+			}
+
+			set.set("name", patch.Name)
+		}
+
+		set.set("updated_at", time.Now())
+
+		query, args := set.update(PatchProduct, productID, patch.Version)
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to patch product: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, patch.Version, CheckProductIDExists, productID, ErrProductNotFound)
+		if err != nil {
+			return err
+		}
+
+		if patch.Fields["category_ids"] {
+			err = replaceProductCategories(ctx, tx, productID, patch.CategoryIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventUpdated, productID)
+	})
 }
 
-func (p *Product) DeleteProduct(id string) error {
-	_, err := p.db.Exec(DeleteProduct, id)
+// SetProductCategories replaces every category the product belongs to. The
+// categories are part of the product, so its row version is bumped.
+func (p *Product) SetProductCategories(ctx context.Context, productID string, categoryIDs []int64, version int64) error {
+	return database.WithTx(ctx, p.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, TouchProduct, productID, time.Now(), version)
+		if err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, version, CheckProductIDExists, productID, ErrProductNotFound)
+		if err != nil {
+			return err
+		}
+
+		err = replaceProductCategories(ctx, tx, productID, categoryIDs)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventUpdated, productID)
+	})
+}
+
+func (p *Product) CreateProduct(ctx context.Context, product *models.Product) error {
+	return database.WithSerializableTx(ctx, p.db, func(tx database.Querier) error {
+		exists, err := checkProductExists(ctx, product.Name, tx)
+		if err != nil {
+			return fmt.Errorf("failed to check product exist: %w", err)
+		}
+
+		if exists {
+			return ErrProductAlreadyExists
+		}
+
+		err = tx.QueryRowContext(ctx, CreateProduct, product.Name).Scan(&product.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create product: %w", err)
+		}
+
+		err = replaceProductCategories(ctx, tx, product.ID, product.CategoryIDs)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventCreated, product.ID)
+	})
+}
+
+// DeleteProduct moves the product to the trash. Its categories are kept so
+// that restoring it brings them back.
+func (p *Product) DeleteProduct(ctx context.Context, id string, version int64) error {
+	return database.WithTx(ctx, p.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, DeleteProduct, id, time.Now(), version)
+		if err != nil {
+			return fmt.Errorf("failed to delete product: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, version, CheckProductIDExists, id, ErrProductNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventDeleted, id)
+	})
+}
+
+// CreateProducts creates the items of a bulk request in one transaction,
+// with one insert for the products and one for their categories. Failed
+// items are recorded in result.
+func (p *Product) CreateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	return runBulk(ctx, p.db, result, func(tx database.Querier) error {
+		return createProducts(ctx, tx, products, result)
+	})
+}
+
+func createProducts(ctx context.Context, tx database.Querier, products []models.Product, result *models.BulkResult) error {
+	names := make([]string, len(products))
+	categoryIDs := make([][]int64, len(products))
+
+	for i, product := range products {
+		names[i] = product.Name
+		categoryIDs[i] = product.CategoryIDs
+	}
+
+	err := failTaken(ctx, tx, result, ListLiveProductNames, names, ErrProductAlreadyExists)
+	if err != nil {
+		return fmt.Errorf("failed to check products exist: %w", err)
+	}
+
+	err = failMissing(ctx, tx, result, ListLiveCategoryIDs, categoryIDs, ErrCategoryNotFound)
 	if err != nil {
 		return err
 	}
 
+	indexes := pending(result)
+	if result.Abort() || len(indexes) == 0 {
+		return nil
+	}
+
+	insert := make([]string, len(indexes))
+	for j, i := range indexes {
+		insert[j] = names[i]
+	}
+
+	created, err := queryCreated(ctx, tx, CreateProducts, pq.Array(insert))
+	if err != nil {
+		return fmt.Errorf("failed to create products: %w", err)
+	}
+
+	productIDs := make([]string, len(indexes))
+	for j, i := range indexes {
+		productIDs[j] = created[names[i]]
+		result.Succeed(i, productIDs[j])
+	}
+
+	err = addBulkProductCategories(ctx, tx, productIDs, pickIDs(categoryIDs, indexes))
+	if err != nil {
+		return err
+	}
+
+	return recordEvents(ctx, tx, models.EventProduct, models.EventCreated, productIDs...)
+}
+
+// UpdateProducts renames the items of a bulk request and replaces their
+// categories in one transaction. Failed items are recorded in result.
+func (p *Product) UpdateProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	return runBulk(ctx, p.db, result, func(tx database.Querier) error {
+		return updateProducts(ctx, tx, products, result)
+	})
+}
+
+func updateProducts(ctx context.Context, tx database.Querier, products []models.Product, result *models.BulkResult) error {
+	ids := make([]string, len(products))
+	categoryIDs := make([][]int64, len(products))
+
+	for i, product := range products {
+		ids[i] = product.ID
+		categoryIDs[i] = product.CategoryIDs
+	}
+
+	parsed := parseBulkIDs(result, ids, ErrProductNotFound)
+
+	err := failMissing(ctx, tx, result, ListLiveProductIDs, singleIDs(parsed), ErrProductNotFound)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(products))
+	for i, product := range products {
+		names[i] = product.Name
+	}
+
+	err = failRenamedTaken(ctx, tx, result, ListLiveProductNameOwners, parsed, names, ErrProductAlreadyExists)
+	if err != nil {
+		return fmt.Errorf("failed to check products exist: %w", err)
+	}
+
+	err = failMissing(ctx, tx, result, ListLiveCategoryIDs, categoryIDs, ErrCategoryNotFound)
+	if err != nil {
+		return err
+	}
+
+	indexes := pending(result)
+	if result.Abort() || len(indexes) == 0 {
+		return nil
+	}
+
+	updateIDs := make([]int64, len(indexes))
+	productIDs := make([]string, len(indexes))
+	updateNames := make([]string, len(indexes))
+
+	for j, i := range indexes {
+		updateIDs[j] = parsed[i]
+		productIDs[j] = strconv.FormatInt(parsed[i], 10)
+		updateNames[j] = products[i].Name
+		result.Succeed(i, productIDs[j])
+	}
+
+	_, err = tx.ExecContext(ctx, UpdateProducts, pq.Array(updateIDs), pq.Array(updateNames), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update products: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, DeleteBulkProductCategories, pq.Array(updateIDs))
+	if err != nil {
+		return fmt.Errorf("failed to clear product categories: %w", err)
+	}
+
+	err = addBulkProductCategories(ctx, tx, productIDs, pickIDs(categoryIDs, indexes))
+	if err != nil {
+		return err
+	}
+
+	return recordEvents(ctx, tx, models.EventProduct, models.EventUpdated, productIDs...)
+}
+
+// ImportProducts writes the rows of an imported file in one transaction.
+// Rows with an id update that product, the others create a new one.
+func (p *Product) ImportProducts(ctx context.Context, products []models.Product, result *models.BulkResult) error {
+	return runBulk(ctx, p.db, result, func(tx database.Querier) error {
+		hasID := func(product models.Product) bool { return product.ID != "" }
+
+		return splitImport(result, products, hasID,
+			func(batch []models.Product, sub *models.BulkResult) error {
+				return createProducts(ctx, tx, batch, sub)
+			},
+			func(batch []models.Product, sub *models.BulkResult) error {
+				return updateProducts(ctx, tx, batch, sub)
+			})
+	})
+}
+
+// ExportProducts calls fn with every live product in id order. Rows are
+// read one at a time so that an export never holds the catalog in memory.
+func (p *Product) ExportProducts(ctx context.Context, fn func(models.ProductResponse) error) error {
+	return eachRow(ctx, p.db, ExportProducts, productList.scan, fn)
+}
+
+// DeleteProducts moves the products of a bulk request to the trash in one
+// statement. Ids that are not live products fail their item.
+func (p *Product) DeleteProducts(ctx context.Context, ids []int64, result *models.BulkResult) error {
+	return runBulk(ctx, p.db, result, func(tx database.Querier) error {
+		failDuplicateIDs(result, ids)
+
+		indexes := pending(result)
+		if result.Abort() || len(indexes) == 0 {
+			return nil
+		}
+
+		deleteIDs := make([]int64, len(indexes))
+		for j, i := range indexes {
+			deleteIDs[j] = ids[i]
+		}
+
+		deleted, err := queryStrings(ctx, tx, DeleteProducts, pq.Array(deleteIDs), time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to delete products: %w", err)
+		}
+
+		var deletedIDs []string
+
+		for _, i := range indexes {
+			id := strconv.FormatInt(ids[i], 10)
+			if !deleted[id] {
+				result.Fail(i, ErrProductNotFound)
+				continue
+			}
+
+			result.Succeed(i, id)
+			deletedIDs = append(deletedIDs, id)
+		}
+
+		return recordEvents(ctx, tx, models.EventProduct, models.EventDeleted, deletedIDs...)
+	})
+}
+
+// addBulkProductCategories links each product to its categories with a
+// single insert of two parallel arrays.
+func addBulkProductCategories(ctx context.Context, tx database.Querier, productIDs []string, categoryIDs [][]int64) error {
+	var products, categories []string
+
+	for i, productID := range productIDs {
+		for _, categoryID := range uniqueIDs(categoryIDs[i]) {
+			products = append(products, productID)
+			categories = append(categories, strconv.FormatInt(categoryID, 10))
+		}
+	}
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, AddBulkProductCategories, pq.Array(products), pq.Array(categories))
+	if err != nil {
+		return fmt.Errorf("failed to add product categories: %w", err)
+	}
+
 	return nil
 }
 
-func checkProductExists(categoryName string, db database.Database) (bool, error) {
+func checkProductExists(ctx context.Context, categoryName string, db database.Querier) (bool, error) {
 	var exists bool
 
-	err := db.QueryRow(CheckProductExists, categoryName).Scan(&exists)
+	err := db.QueryRowContext(ctx, CheckProductExists, categoryName).Scan(&exists)
 	if err != nil {
 		return exists, err
 	}
 
 	return exists, nil
 }
+
+// replaceProductCategories swaps the product's categories for categoryIDs,
+// returning ErrCategoryNotFound if any of them does not exist.
+func replaceProductCategories(ctx context.Context, tx database.Querier, productID string, categoryIDs []int64) error {
+	categoryIDs = uniqueIDs(categoryIDs)
+
+	var found int
+
+	err := tx.QueryRowContext(ctx, CountCategoriesByID, pq.Array(categoryIDs)).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to check categories exist: %w", err)
+	}
+
+	if found != len(categoryIDs) {
+		return ErrCategoryNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, DeleteProductCategories, productID)
+	if err != nil {
+		return fmt.Errorf("failed to clear product categories: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, AddProductCategories, productID, pq.Array(categoryIDs))
+	if err != nil {
+		return fmt.Errorf("failed to add product categories: %w", err)
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	unique := make([]int64, 0, len(ids))
+
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	return unique
+}
+
+var productList = listSpec[models.ProductResponse]{
+	selectQuery: ListProducts,
+	countQuery:  CountProducts,
+	sortKeys: map[string]sortKey[models.ProductResponse]{
+		"id":         {column: "id", value: func(p models.ProductResponse) string { return p.ID }},
+		"name":       {column: "name", value: func(p models.ProductResponse) string { return p.Name }},
+		"created_at": {column: "created_at", value: func(p models.ProductResponse) string { return formatCursorTime(p.CreatedAt) }},
+		"updated_at": {column: "updated_at", value: func(p models.ProductResponse) string { return formatCursorTime(p.UpdatedAt) }},
+	},
+	scan: func(rows *sql.Rows) (models.ProductResponse, error) {
+		var product models.ProductResponse
+
+		err := rows.Scan(&product.ID, &product.Name, pq.Array(&product.CategoryIDs), &product.CreatedAt, &product.UpdatedAt)
+
+		return product, err
+	},
+}
+
+// prefixTSQuery turns free text into a to_tsquery expression that requires
+// every word as a prefix, e.g. "lap sta" becomes "lap:* & sta:*". Anything
+// other than letters and digits is dropped so user input cannot inject
+// tsquery operators.
+func prefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"regexp"
@@ -9,6 +10,7 @@ import (
 	"awesomeProject/internal/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -30,11 +32,11 @@ var _ = Describe("Product Repository", func() {
 
 		repo = NewProduct(db)
 		product = &models.Product{
-			ID:         "1",
-			Name:       "test product",
-			CategoryID: 1,
-			CreatedAt:  time.Time{},
-			UpdatedAt:  time.Time{},
+			ID:          "1",
+			Name:        "test product",
+			CategoryIDs: []int64{1, 2},
+			CreatedAt:   time.Time{},
+			UpdatedAt:   time.Time{},
 		}
 
 		productResponse = &models.ProductResponse{}
@@ -44,150 +46,926 @@ var _ = Describe("Product Repository", func() {
 		db.Close()
 	})
 
+	expectCategoriesReplaced := func(productID string) {
+		mock.ExpectQuery(regexp.QuoteMeta(CountCategoriesByID)).
+			WithArgs("{1,2}").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec(regexp.QuoteMeta(DeleteProductCategories)).
+			WithArgs(productID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(AddProductCategories)).
+			WithArgs(productID, "{1,2}").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+
 	Describe("Get Product", func() {
 		It("should return product, nil", func() {
-			rows := sqlmock.NewRows([]string{"name", "category_id", "created_at", "updated_at"}).
-				AddRow("test product", 1, time.Time{}, time.Time{})
+			rows := sqlmock.NewRows([]string{"name", "category_ids", "created_at", "updated_at", "version"}).
+				AddRow("test product", "{1,2}", time.Time{}, time.Time{}, 4)
 
-			mock.ExpectQuery(regexp.QuoteMeta(
-				"SELECT name, category_id, created_at, updated_at FROM products WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetProduct)).
 				WithArgs(product.ID).WillReturnRows(rows)
 
-			productResponse, err = repo.GetProduct(product.ID)
+			productResponse, err = repo.GetProduct(context.Background(), product.ID)
 			Expect(err).Should(BeNil())
 			Expect(productResponse).Should(Equal(&models.ProductResponse{
-				Name:       "test product",
-				CategoryID: 1,
-				CreatedAt:  time.Time{},
-				UpdatedAt:  time.Time{},
+				Name:        "test product",
+				CategoryIDs: []int64{1, 2},
+				CreatedAt:   time.Time{},
+				UpdatedAt:   time.Time{},
+				Version:     4,
 			}))
 		})
 		It("should return error when product not found", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, category_id, created_at, updated_at FROM products WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetProduct)).
 				WithArgs(product.ID).
 				WillReturnError(sql.ErrNoRows)
 
-			productResponse, err := repo.GetProduct(product.ID)
+			productResponse, err := repo.GetProduct(context.Background(), product.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(productResponse).Should(BeNil())
-			Expect(errors.Is(err, sql.ErrNoRows)).Should(BeTrue())
+			Expect(errors.Is(err, ErrProductNotFound)).Should(BeTrue())
 		})
 		It("should return error on query failure", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, category_id, created_at, updated_at FROM products WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetProduct)).
 				WithArgs(product.ID).WillReturnError(errors.New("query error"))
 
-			productResponse, err = repo.GetProduct(product.ID)
+			productResponse, err = repo.GetProduct(context.Background(), product.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(productResponse).Should(BeNil())
 			Expect(err.Error()).Should(ContainSubstring("query error"))
 		})
 		It("should return error on scan failure", func() {
-			rows := sqlmock.NewRows([]string{"name", "category_id", "created_at", "updated_at"}).
-				AddRow("test product", 1, "invalid time", time.Now())
+			rows := sqlmock.NewRows([]string{"name", "category_ids", "created_at", "updated_at", "version"}).
+				AddRow("test product", "{1}", "invalid time", time.Now(), 1)
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT name, category_id, created_at, updated_at FROM products WHERE id = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetProduct)).
 				WithArgs(product.ID).WillReturnRows(rows)
 
-			productResponse, err = repo.GetProduct(product.ID)
+			productResponse, err = repo.GetProduct(context.Background(), product.ID)
 			Expect(err).Should(HaveOccurred())
 			Expect(productResponse).Should(BeNil())
 			Expect(err.Error()).Should(ContainSubstring("sql: Scan error"))
 		})
 	})
 
+	Describe("ListProducts", func() {
+		It("should apply the category and created-at filters", func() {
+			after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			where := " WHERE deleted_at IS NULL AND id IN (SELECT product_id FROM product_categories WHERE category_id = $1) AND created_at >= $2"
+
+			mock.ExpectQuery(regexp.QuoteMeta(CountProducts+where)).
+				WithArgs(1, after).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(regexp.QuoteMeta(ListProducts+where+" ORDER BY name ASC, id ASC LIMIT 21")).
+				WithArgs(1, after).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at"}).
+					AddRow("1", "test product", "{1}", after, after))
+
+			page, err := repo.ListProducts(context.Background(), models.ProductFilter{
+				ListOptions: models.ListOptions{Sort: "name", CreatedAfter: &after},
+				CategoryID:  1,
+			})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(HaveLen(1))
+			Expect(page.Data[0].ID).Should(Equal("1"))
+			Expect(page.Data[0].CategoryIDs).Should(Equal([]int64{1}))
+			Expect(page.Meta).Should(Equal(models.PageMeta{Total: 1, Limit: DefaultPageLimit}))
+		})
+
+		It("should cap the page size", func() {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products")).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectQuery(regexp.QuoteMeta("ORDER BY id ASC, id ASC LIMIT 101")).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at"}))
+
+			page, err := repo.ListProducts(context.Background(), models.ProductFilter{
+				ListOptions: models.ListOptions{Limit: 500},
+			})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(BeEmpty())
+			Expect(page.Meta.Limit).Should(Equal(MaxPageLimit))
+		})
+	})
+
+	Describe("ListCategoryProducts", func() {
+		It("should list the products in the category", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(CountProducts + " WHERE deleted_at IS NULL AND id IN (SELECT product_id FROM product_categories WHERE category_id = $1)")).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE category_id = $1) ORDER BY id ASC, id ASC LIMIT 21")).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at"}).
+					AddRow("1", "test product", "{2,3}", time.Time{}, time.Time{}))
+
+			page, err := repo.ListCategoryProducts(context.Background(), "3", models.ListOptions{})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(HaveLen(1))
+			Expect(page.Data[0].CategoryIDs).Should(Equal([]int64{2, 3}))
+		})
+
+		It("should return ErrCategoryNotFound for an unknown category", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(CheckCategoryIDExists)).
+				WithArgs("3").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+			_, err := repo.ListCategoryProducts(context.Background(), "3", models.ListOptions{})
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("SearchProducts", func() {
+		It("should search with prefix terms and fuzzy fallback", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(CountProductSearch)).
+				WithArgs("lap:* & pro:*", "Lap-Pro").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(regexp.QuoteMeta(SearchProducts)).
+				WithArgs("lap:* & pro:*", "Lap-Pro", 11).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at", "rank", "snippet"}).
+					AddRow("1", "Laptop Pro", "{1}", now, now, 0.75, "<mark>Laptop</mark> <mark>Pro</mark>"))
+
+			page, err := repo.SearchProducts(context.Background(), "Lap-Pro", models.ListOptions{Limit: 10})
+			Expect(err).Should(BeNil())
+			Expect(page.Meta.Total).Should(Equal(int64(1)))
+			Expect(page.Data).Should(HaveLen(1))
+			Expect(page.Data[0].Name).Should(Equal("Laptop Pro"))
+			Expect(page.Data[0].Rank).Should(Equal(0.75))
+			Expect(page.Data[0].Snippet).Should(Equal("<mark>Laptop</mark> <mark>Pro</mark>"))
+			Expect(page.Meta.NextCursor).Should(BeEmpty())
+		})
+
+		It("should resume after the rank and id of the cursor", func() {
+			now := time.Now()
+			columns := []string{"id", "name", "category_ids", "created_at", "updated_at", "rank", "snippet"}
+
+			mock.ExpectQuery(regexp.QuoteMeta(CountProductSearch)).
+				WithArgs("laptop:*", "laptop").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			mock.ExpectQuery(regexp.QuoteMeta(SearchProducts)).
+				WithArgs("laptop:*", "laptop", 2).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("1", "Laptop", "{}", now, now, 0.9, "<mark>Laptop</mark>").
+					AddRow("2", "Laptop Pro", "{}", now, now, 0.5, "<mark>Laptop</mark> Pro"))
+
+			page, err := repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Limit: 1})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(HaveLen(1))
+			Expect(page.Meta.NextCursor).ShouldNot(BeEmpty())
+
+			mock.ExpectQuery(regexp.QuoteMeta(CountProductSearch)).
+				WithArgs("laptop:*", "laptop").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			mock.ExpectQuery(regexp.QuoteMeta(SearchProductsAfter)).
+				WithArgs("laptop:*", "laptop", 2, "0.9", "1").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("2", "Laptop Pro", "{}", now, now, 0.5, "<mark>Laptop</mark> Pro"))
+
+			page, err = repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Limit: 1, Cursor: page.Meta.NextCursor})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(HaveLen(1))
+			Expect(page.Data[0].ID).Should(Equal("2"))
+			Expect(page.Meta.NextCursor).Should(BeEmpty())
+		})
+
+		It("should reject a cursor of a list endpoint", func() {
+			listCursor := encodeCursor(cursor{Sort: "name", Value: "Laptop", ID: "1"})
+
+			_, err := repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Cursor: listCursor})
+			Expect(errors.Is(err, ErrInvalidCursor)).Should(BeTrue())
+		})
+
+		It("should reject a cursor with a malformed rank", func() {
+			badCursor := encodeCursor(cursor{Sort: "rank", Value: "high", ID: "1"})
+
+			_, err := repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Cursor: badCursor})
+			Expect(errors.Is(err, ErrInvalidCursor)).Should(BeTrue())
+		})
+
+		It("should reject a sort", func() {
+			_, err := repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Sort: "name"})
+			Expect(errors.Is(err, ErrInvalidSort)).Should(BeTrue())
+		})
+
+		It("should not query when the input has no searchable terms", func() {
+			page, err := repo.SearchProducts(context.Background(), "&|!", models.ListOptions{Limit: 10})
+			Expect(err).Should(BeNil())
+			Expect(page.Data).Should(BeEmpty())
+		})
+
+		It("should return error on query failure", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(CountProductSearch)).
+				WillReturnError(errors.New("query error"))
+
+			page, err := repo.SearchProducts(context.Background(), "laptop", models.ListOptions{Limit: 10})
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to count products: query error"))
+			Expect(page).Should(BeNil())
+		})
+	})
+
 	Describe("UpdateProduct", func() {
-		It("should update product successfully", func() {
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET name = $2, category_id = $3, updated_at = $4 WHERE id = $1")).
-				WithArgs(product.ID, product.Name, product.CategoryID, sqlmock.AnyArg()).
+		It("should update product and replace its categories", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProduct)).
+				WithArgs(product.ID, product.Name, sqlmock.AnyArg(), int64(0)).
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			expectCategoriesReplaced(product.ID)
+			expectEvents(mock, models.EventProduct, models.EventUpdated, product.ID)
+			mock.ExpectCommit()
 
-			err := repo.UpdateProduct(product)
+			err := repo.UpdateProduct(context.Background(), product)
 			Expect(err).Should(BeNil())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
 		})
 
 		It("should return error when update fails", func() {
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET name = $2, category_id = $3, updated_at = $4 WHERE id = $1")).
-				WithArgs(product.ID, product.Name, product.CategoryID, sqlmock.AnyArg()).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProduct)).
+				WithArgs(product.ID, product.Name, sqlmock.AnyArg(), int64(0)).
 				WillReturnError(errors.New("update error"))
+			mock.ExpectRollback()
 
-			err := repo.UpdateProduct(product)
+			err := repo.UpdateProduct(context.Background(), product)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to update product: update error"))
 		})
 
-		It("should return error when product ID is missing", func() {
-			product.ID = ""
+		It("should return ErrProductNotFound when no row matches", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProduct)).
+				WithArgs(product.ID, product.Name, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.UpdateProduct(context.Background(), product)
+			Expect(errors.Is(err, ErrProductNotFound)).Should(BeTrue())
+		})
+
+		It("should return ErrVersionConflict when the product was modified", func() {
+			product.Version = 2
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProduct)).
+				WithArgs(product.ID, product.Name, sqlmock.AnyArg(), int64(2)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductIDExists)).
+				WithArgs(product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
 
-			err := repo.UpdateProduct(product)
-			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).Should(ContainSubstring("failed to update product:"))
+			err := repo.UpdateProduct(context.Background(), product)
+			Expect(errors.Is(err, ErrVersionConflict)).Should(BeTrue())
+		})
+
+		It("should roll back when a category does not exist", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProduct)).
+				WithArgs(product.ID, product.Name, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(CountCategoriesByID)).
+				WithArgs("{1,2}").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectRollback()
+
+			err := repo.UpdateProduct(context.Background(), product)
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should return ErrProductAlreadyExists when another product has the name", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs(product.Name, product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.UpdateProduct(context.Background(), product)
+			Expect(errors.Is(err, ErrProductAlreadyExists)).Should(BeTrue())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+	})
+
+	Describe("PatchProduct", func() {
+		It("should write only the changed name", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs("Desktop", product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET name = $2, updated_at = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)")).
+				WithArgs(product.ID, "Desktop", sqlmock.AnyArg(), int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventUpdated, product.ID)
+			mock.ExpectCommit()
+
+			err := repo.PatchProduct(context.Background(), product.ID, models.ProductPatch{
+				Name:    "Desktop",
+				Fields:  models.PatchFields{"name": true},
+				Version: 3,
+			})
+			Expect(err).Should(BeNil())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should replace the categories when they changed", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET updated_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)")).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectCategoriesReplaced(product.ID)
+			expectEvents(mock, models.EventProduct, models.EventUpdated, product.ID)
+			mock.ExpectCommit()
+
+			err := repo.PatchProduct(context.Background(), product.ID, models.ProductPatch{
+				CategoryIDs: []int64{1, 2},
+				Fields:      models.PatchFields{"category_ids": true},
+				Version:     3,
+			})
+			Expect(err).Should(BeNil())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should return ErrVersionConflict when the product was modified", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductNameTaken)).
+				WithArgs("Desktop", product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE products SET")).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductIDExists)).
+				WithArgs(product.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.PatchProduct(context.Background(), product.ID, models.ProductPatch{
+				Name:    "Desktop",
+				Fields:  models.PatchFields{"name": true},
+				Version: 3,
+			})
+			Expect(errors.Is(err, ErrVersionConflict)).Should(BeTrue())
+		})
+	})
+
+	Describe("SetProductCategories", func() {
+		It("should replace the product categories, ignoring duplicates", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(TouchProduct)).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectCategoriesReplaced(product.ID)
+			expectEvents(mock, models.EventProduct, models.EventUpdated, product.ID)
+			mock.ExpectCommit()
+
+			err := repo.SetProductCategories(context.Background(), product.ID, []int64{1, 2, 1}, 0)
+			Expect(err).Should(BeNil())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should return ErrProductNotFound for an unknown product", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(TouchProduct)).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.SetProductCategories(context.Background(), product.ID, []int64{1}, 0)
+			Expect(errors.Is(err, ErrProductNotFound)).Should(BeTrue())
 		})
 	})
 
 	Describe("CreateProduct", func() {
-		It("should create product successfully", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM products WHERE name = $1)")).
+		It("should create product with its categories", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
 				WithArgs(product.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProduct)).
+				WithArgs(product.Name).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+			expectCategoriesReplaced("7")
+			expectEvents(mock, models.EventProduct, models.EventCreated, "7")
+			mock.ExpectCommit()
+
+			err := repo.CreateProduct(context.Background(), product)
+			Expect(err).Should(BeNil())
+			Expect(product.ID).Should(Equal("7"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
 
-			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products (name, category_id) VALUES ($1, $2)")).
-				WithArgs(product.Name, product.CategoryID).
-				WillReturnResult(sqlmock.NewResult(1, 1))
+		It("should retry when a concurrent create conflicts", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
+				WithArgs(product.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProduct)).
+				WithArgs(product.Name).
+				WillReturnError(&pq.Error{Code: "40001"})
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
+				WithArgs(product.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
 
-			err := repo.CreateProduct(product)
-			Expect(err).Should(BeNil())
+			err := repo.CreateProduct(context.Background(), product)
+			Expect(errors.Is(err, ErrProductAlreadyExists)).Should(BeTrue())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
 		})
 
 		It("should return error when product already exists", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM products WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
 				WithArgs(product.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
 
-			err := repo.CreateProduct(product)
+			err := repo.CreateProduct(context.Background(), product)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("product already exists"))
 		})
 
 		It("should return error when checking product existence fails", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM products WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
 				WithArgs(product.Name).WillReturnError(errors.New("db error"))
+			mock.ExpectRollback()
 
-			err := repo.CreateProduct(product)
+			err := repo.CreateProduct(context.Background(), product)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to check product exist: db error"))
 		})
 
 		It("should return error when creating product fails", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM products WHERE name = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
 				WithArgs(product.Name).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
-
-			mock.ExpectExec(regexp.QuoteMeta(
-				"INSERT INTO products (name, category_id) VALUES ($1, $2)")).
-				WithArgs(product.Name, product.CategoryID).
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProduct)).
+				WithArgs(product.Name).
 				WillReturnError(errors.New("insert error"))
+			mock.ExpectRollback()
 
-			err := repo.CreateProduct(product)
+			err := repo.CreateProduct(context.Background(), product)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to create product: insert error"))
 		})
 	})
 
+	Describe("CreateProducts", func() {
+		var products []models.Product
+
+		BeforeEach(func() {
+			products = []models.Product{
+				{Name: "Hobbit", CategoryIDs: []int64{1}},
+				{Name: "Dune", CategoryIDs: []int64{1, 2}},
+			}
+		})
+
+		It("should create every product with two statements", func() {
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WithArgs(`{"Hobbit","Dune"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1,2}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProducts)).
+				WithArgs(`{"Hobbit","Dune"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("7", "Hobbit").AddRow("8", "Dune"))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WithArgs(`{"7","8","8"}`, `{"1","1","2"}`).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+			expectEvents(mock, models.EventProduct, models.EventCreated, "7", "8")
+			mock.ExpectCommit()
+
+			err := repo.CreateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeTrue())
+			Expect(result.Succeeded).Should(Equal(2))
+			Expect(result.Results[0].ID).Should(Equal("7"))
+			Expect(result.Results[1].ID).Should(Equal("8"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should roll back an atomic batch when a name is taken", func() {
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Dune"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectRollback()
+
+			err := repo.CreateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Succeeded).Should(Equal(0))
+			Expect(result.Results[1].Code).Should(Equal("product_already_exists"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should create the other items of a partial batch", func() {
+			products[1].Name = "Hobbit"
+			result := models.NewBulkResult(models.BulkPartial, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectCommit()
+
+			err := repo.CreateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeTrue())
+			Expect(result.Results[0].Code).Should(Equal("category_not_found"))
+			Expect(result.Results[1].Code).Should(Equal("product_already_exists"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should return error when the insert fails", func() {
+			result := models.NewBulkResult(models.BulkPartial, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProducts)).
+				WillReturnError(errors.New("insert error"))
+			mock.ExpectRollback()
+
+			err := repo.CreateProducts(context.Background(), products, result)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to create products: insert error"))
+		})
+	})
+
+	Describe("UpdateProducts", func() {
+		It("should update every product and replace their categories", func() {
+			products := []models.Product{{ID: "1", Name: "Hobbit", CategoryIDs: []int64{2}}}
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WithArgs("{1}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNameOwners)).
+				WithArgs(`{"Hobbit"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("Hobbit", "1"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WithArgs("{2}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProducts)).
+				WithArgs("{1}", `{"Hobbit"}`, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteBulkProductCategories)).
+				WithArgs("{1}").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WithArgs(`{"1"}`, `{"2"}`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventUpdated, "1")
+			mock.ExpectCommit()
+
+			err := repo.UpdateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Succeeded).Should(Equal(1))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should fail unknown and repeated ids", func() {
+			products := []models.Product{
+				{ID: "abc", Name: "Hobbit", CategoryIDs: []int64{1}},
+				{ID: "9", Name: "Dune", CategoryIDs: []int64{1}},
+				{ID: "9", Name: "Emma", CategoryIDs: []int64{1}},
+			}
+			result := models.NewBulkResult(models.BulkPartial, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WithArgs("{9}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectCommit()
+
+			err := repo.UpdateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Results[0].Code).Should(Equal("product_not_found"))
+			Expect(result.Results[1].Code).Should(Equal("product_not_found"))
+			Expect(result.Results[2].Code).Should(Equal("duplicate_item"))
+			Expect(result.Succeeded).Should(Equal(0))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should fail renames to a name another product has", func() {
+			products := []models.Product{
+				{ID: "1", Name: "Dune", CategoryIDs: []int64{1}},
+				{ID: "2", Name: "Emma", CategoryIDs: []int64{1}},
+				{ID: "3", Name: "Emma", CategoryIDs: []int64{1}},
+			}
+			result := models.NewBulkResult(models.BulkPartial, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WithArgs("{1,2,3}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2").AddRow("3"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNameOwners)).
+				WithArgs(`{"Dune","Emma","Emma"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("Dune", "4").AddRow("Emma", "2"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProducts)).
+				WithArgs("{2}", `{"Emma"}`, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventUpdated, "2")
+			mock.ExpectCommit()
+
+			err := repo.UpdateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Results[0].Code).Should(Equal("product_already_exists"))
+			Expect(result.Results[1].Code).Should(BeEmpty())
+			Expect(result.Results[2].Code).Should(Equal("product_already_exists"))
+			Expect(result.Succeeded).Should(Equal(1))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should start over from the original result after a conflict", func() {
+			products := []models.Product{{ID: "1", Name: "Hobbit", CategoryIDs: []int64{1}}, {ID: "2", Name: "Dune", CategoryIDs: []int64{1}}}
+			result := models.NewBulkResult(models.BulkPartial, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNameOwners)).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("Hobbit", "9"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnError(&pq.Error{Code: "40001"})
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNameOwners)).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProducts)).
+				WithArgs("{1,2}", `{"Hobbit","Dune"}`, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			expectEvents(mock, models.EventProduct, models.EventUpdated, "1", "2")
+			mock.ExpectCommit()
+
+			err := repo.UpdateProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeTrue())
+			Expect(result.Failed).Should(Equal(0))
+			Expect(result.Succeeded).Should(Equal(2))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+	})
+
+	Describe("DeleteProducts", func() {
+		It("should delete every product with one statement", func() {
+			result := models.NewBulkResult(models.BulkAtomic, 2)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteProducts)).
+				WithArgs("{1,2}", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			expectEvents(mock, models.EventProduct, models.EventDeleted, "1", "2")
+			mock.ExpectCommit()
+
+			err := repo.DeleteProducts(context.Background(), []int64{1, 2}, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Succeeded).Should(Equal(2))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should roll back an atomic batch when a product is missing", func() {
+			result := models.NewBulkResult(models.BulkAtomic, 2)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteProducts)).
+				WithArgs("{1,2}", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			expectEvents(mock, models.EventProduct, models.EventDeleted, "1")
+			mock.ExpectRollback()
+
+			err := repo.DeleteProducts(context.Background(), []int64{1, 2}, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Results[1].Code).Should(Equal("product_not_found"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should keep the deleted products of a partial batch", func() {
+			result := models.NewBulkResult(models.BulkPartial, 3)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(DeleteProducts)).
+				WithArgs("{1,2}", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			expectEvents(mock, models.EventProduct, models.EventDeleted, "1")
+			mock.ExpectCommit()
+
+			err := repo.DeleteProducts(context.Background(), []int64{1, 2, 1}, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeTrue())
+			Expect(result.Succeeded).Should(Equal(1))
+			Expect(result.Results[2].Code).Should(Equal("duplicate_item"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+	})
+
+	Describe("ImportProducts", func() {
+		It("should create rows without an id and update the others in one transaction", func() {
+			products := []models.Product{
+				{Name: "Hobbit", CategoryIDs: []int64{1}},
+				{ID: "4", Name: "Dune", CategoryIDs: []int64{1}},
+			}
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WithArgs(`{"Hobbit"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProducts)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("7", "Hobbit"))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WithArgs(`{"7"}`, `{"1"}`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventCreated, "7")
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductIDs)).
+				WithArgs("{4}").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("4"))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNameOwners)).
+				WithArgs(`{"Dune"}`).
+				WillReturnRows(sqlmock.NewRows([]string{"name", "id"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateProducts)).
+				WithArgs("{4}", `{"Dune"}`, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(DeleteBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WithArgs(`{"4"}`, `{"1"}`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventUpdated, "4")
+			mock.ExpectCommit()
+
+			err := repo.ImportProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeTrue())
+			Expect(result.Results[0].ID).Should(Equal("7"))
+			Expect(result.Results[1].ID).Should(Equal("4"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should skip the updates of an atomic import once a create failed", func() {
+			products := []models.Product{
+				{Name: "Hobbit", CategoryIDs: []int64{1}},
+				{ID: "4", Name: "Dune", CategoryIDs: []int64{1}},
+			}
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Hobbit"))
+			mock.ExpectRollback()
+
+			err := repo.ImportProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Failed).Should(Equal(1))
+			Expect(result.Results[0].Code).Should(Equal("product_already_exists"))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should roll back a dry run and drop the new ids", func() {
+			products := []models.Product{{Name: "Hobbit", CategoryIDs: []int64{1}}}
+			result := models.NewBulkResult(models.BulkAtomic, len(products))
+			result.DryRun = true
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveProductNames)).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+			mock.ExpectQuery(regexp.QuoteMeta(ListLiveCategoryIDs)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+			mock.ExpectQuery(regexp.QuoteMeta(CreateProducts)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("7", "Hobbit"))
+			mock.ExpectExec(regexp.QuoteMeta(AddBulkProductCategories)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventCreated, "7")
+			mock.ExpectRollback()
+
+			err := repo.ImportProducts(context.Background(), products, result)
+			Expect(err).Should(BeNil())
+			Expect(result.Committed).Should(BeFalse())
+			Expect(result.Succeeded).Should(Equal(1))
+			Expect(result.Results[0].ID).Should(BeEmpty())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+	})
+
+	Describe("ExportProducts", func() {
+		It("should call fn with every row", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(ExportProducts)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at"}).
+					AddRow("1", "Hobbit", "{1,2}", time.Time{}, time.Time{}).
+					AddRow("2", "Dune", "{}", time.Time{}, time.Time{}))
+
+			var exported []models.ProductResponse
+
+			err := repo.ExportProducts(context.Background(), func(product models.ProductResponse) error {
+				exported = append(exported, product)
+				return nil
+			})
+			Expect(err).Should(BeNil())
+			Expect(exported).Should(HaveLen(2))
+			Expect(exported[0].CategoryIDs).Should(Equal([]int64{1, 2}))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should stop at the first error fn returns", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(ExportProducts)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_ids", "created_at", "updated_at"}).
+					AddRow("1", "Hobbit", "{1}", time.Time{}, time.Time{}).
+					AddRow("2", "Dune", "{1}", time.Time{}, time.Time{}))
+
+			calls := 0
+
+			err := repo.ExportProducts(context.Background(), func(models.ProductResponse) error {
+				calls++
+				return errors.New("client gone")
+			})
+			Expect(err).Should(MatchError("client gone"))
+			Expect(calls).Should(Equal(1))
+		})
+	})
+
 	Describe("DeleteProduct", func() {
 		It("should delete product successfully", func() {
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM products WHERE id = $1")).
-				WithArgs(product.ID).
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteProduct)).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(0)).
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			expectEvents(mock, models.EventProduct, models.EventDeleted, product.ID)
+			mock.ExpectCommit()
 
-			err := repo.DeleteProduct(product.ID)
+			err := repo.DeleteProduct(context.Background(), product.ID, 0)
 			Expect(err).Should(BeNil())
 		})
 
 		It("should return error when deletion fails", func() {
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM products WHERE id = $1")).
-				WithArgs(product.ID).
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteProduct)).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(0)).
 				WillReturnError(errors.New("delete error"))
+			mock.ExpectRollback()
 
-			err := repo.DeleteProduct(product.ID)
+			err := repo.DeleteProduct(context.Background(), product.ID, 0)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("delete error"))
 		})
+
+		It("should return ErrProductNotFound when no row matches", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteProduct)).
+				WithArgs(product.ID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.DeleteProduct(context.Background(), product.ID, 0)
+			Expect(errors.Is(err, ErrProductNotFound)).Should(BeTrue())
+		})
 	})
 })
@@ -1,21 +1,192 @@
 package repositories
 
+// productCategoryIDs selects the live categories of the products row in
+// scope, as an empty array for uncategorized products.
+const productCategoryIDs = "ARRAY(SELECT pc.category_id FROM product_categories pc JOIN category c ON c.id = pc.category_id WHERE pc.product_id = products.id AND c.deleted_at IS NULL ORDER BY pc.category_id)"
+
+// productSearchMatches selects the live products matching a search with their
+// rank, so that pages of results can resume after a (rank, id) pair.
+const productSearchMatches = "SELECT id, name, " + productCategoryIDs + " AS category_ids, created_at, updated_at, ts_rank(search_vector, to_tsquery('english', $1)) + similarity(name, $2) AS rank, ts_headline('english', name, to_tsquery('english', $1), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet FROM products WHERE deleted_at IS NULL AND (search_vector @@ to_tsquery('english', $1) OR name % $2)"
+
+// categoryDescendants is a recursive CTE named subtree holding the live
+// category with id $1 and every live category below it, with the distance
+// from $1 as depth.
+const categoryDescendants = "WITH RECURSIVE subtree AS (" +
+	"SELECT id, name, parent_id, created_at, updated_at, 0 AS depth FROM category WHERE id = $1 AND deleted_at IS NULL " +
+	"UNION ALL " +
+	"SELECT c.id, c.name, c.parent_id, c.created_at, c.updated_at, subtree.depth + 1 FROM category c JOIN subtree ON c.parent_id = subtree.id WHERE c.deleted_at IS NULL) "
+
+const (
+	GetCategoryTree = "WITH RECURSIVE tree AS (" +
+		"SELECT id, name, parent_id, created_at, updated_at, 0 AS depth FROM category WHERE parent_id IS NULL AND deleted_at IS NULL " +
+		"UNION ALL " +
+		"SELECT c.id, c.name, c.parent_id, c.created_at, c.updated_at, tree.depth + 1 FROM category c JOIN tree ON c.parent_id = tree.id WHERE c.deleted_at IS NULL) " +
+		"SELECT id, name, parent_id, created_at, updated_at FROM tree ORDER BY depth, name, id"
+	GetCategorySubtree = categoryDescendants +
+		"SELECT id, name, parent_id, created_at, updated_at FROM subtree ORDER BY depth, name, id"
+	GetCategoryAncestors = "WITH RECURSIVE ancestors AS (" +
+		"SELECT id, name, parent_id, created_at, updated_at, 0 AS depth FROM category WHERE id = $1 AND deleted_at IS NULL " +
+		"UNION ALL " +
+		"SELECT c.id, c.name, c.parent_id, c.created_at, c.updated_at, ancestors.depth + 1 FROM category c JOIN ancestors ON c.id = ancestors.parent_id WHERE c.deleted_at IS NULL) " +
+		"SELECT id, name, parent_id, created_at, updated_at FROM ancestors ORDER BY depth DESC"
+	CheckCategoryInSubtree    = categoryDescendants + "SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)"
+	DeleteCategoryDescendants = categoryDescendants + "UPDATE category SET deleted_at = $2, version = version + 1 WHERE id IN (SELECT id FROM subtree WHERE depth > 0) RETURNING id"
+)
+
+const (
+	GetCategoryByID          = "SELECT name, parent_id, created_at, updated_at, version FROM category WHERE id = $1 AND deleted_at IS NULL"
+	UpdateCategory           = "UPDATE category SET name = $2, updated_at = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)"
+	MoveCategory             = "UPDATE category SET parent_id = $2, updated_at = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)"
+	CreateCategory           = "INSERT INTO category (name, parent_id) VALUES ($1, $2) RETURNING id"
+	CheckCategoryHasChildren = "SELECT EXISTS (SELECT 1 FROM category WHERE parent_id = $1 AND deleted_at IS NULL)"
+	ReparentCategoryChildren = "UPDATE category SET parent_id = (SELECT parent_id FROM category WHERE id = $1), updated_at = $2, version = version + 1 WHERE parent_id = $1 AND deleted_at IS NULL RETURNING id"
+	CheckCategoryExists      = "SELECT EXISTS (SELECT 1 FROM category WHERE name = $1 AND deleted_at IS NULL)"
+	CheckCategoryNameTaken   = "SELECT EXISTS (SELECT 1 FROM category WHERE name = $1 AND id <> $2 AND deleted_at IS NULL)"
+	DeleteCategory           = "UPDATE category SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"
+	CheckCategoryIDExists    = "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND deleted_at IS NULL)"
+	CountCategoriesByID      = "SELECT COUNT(*) FROM category WHERE id = ANY($1) AND deleted_at IS NULL"
+	ListCategories           = "SELECT id, name, parent_id, created_at, updated_at FROM category"
+	CountCategories          = "SELECT COUNT(*) FROM category"
+	GetProduct               = "SELECT name, " + productCategoryIDs + ", created_at, updated_at, version FROM products WHERE id = $1 AND deleted_at IS NULL"
+	UpdateProduct            = "UPDATE products SET name = $2, updated_at = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)"
+	TouchProduct             = "UPDATE products SET updated_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"
+	CreateProduct            = "INSERT INTO products (name) VALUES ($1) RETURNING id"
+	CheckProductIDExists     = "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)"
+	CheckProductExists       = "SELECT EXISTS (SELECT 1 FROM products WHERE name = $1 AND deleted_at IS NULL)"
+	CheckProductNameTaken    = "SELECT EXISTS (SELECT 1 FROM products WHERE name = $1 AND id <> $2 AND deleted_at IS NULL)"
+	DeleteProduct            = "UPDATE products SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"
+	ListProducts             = "SELECT id, name, " + productCategoryIDs + ", created_at, updated_at FROM products"
+	CountProducts            = "SELECT COUNT(*) FROM products"
+	SearchProducts           = "SELECT id, name, category_ids, created_at, updated_at, rank, snippet FROM (" + productSearchMatches + ") AS matches ORDER BY rank DESC, id LIMIT $3"
+	SearchProductsAfter      = "SELECT id, name, category_ids, created_at, updated_at, rank, snippet FROM (" + productSearchMatches + ") AS matches WHERE rank < $4 OR (rank = $4 AND id > $5) ORDER BY rank DESC, id LIMIT $3"
+	CountProductSearch       = "SELECT COUNT(*) FROM products WHERE deleted_at IS NULL AND (search_vector @@ to_tsquery('english', $1) OR name % $2)"
+	DeleteProductCategories  = "DELETE FROM product_categories WHERE product_id = $1"
+	AddProductCategories     = "INSERT INTO product_categories (product_id, category_id) SELECT $1, unnest($2::int[])"
+	AddCustomer              = "INSERT INTO customer (username, email, password, role) VALUES ($1, $2, $3, $4) RETURNING id"
+	GetUserByEmail           = "SELECT id, username, email, password, role FROM customer WHERE lower(email) = lower($1) AND deleted_at IS NULL"
+	GetUserByID              = "SELECT id, username, email, role, version FROM customer WHERE id = $1 AND deleted_at IS NULL"
+	GetUserByUsername        = "SELECT id, username, email, role, version FROM customer WHERE username = $1 AND deleted_at IS NULL"
+	GetAllUsers              = "SELECT id, username, email, role, created_at FROM customer"
+	CountUsers               = "SELECT COUNT(*) FROM customer"
+	UpdateUser               = "UPDATE customer SET username = $2, email = $3, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)"
+	UpdateUserRole           = "UPDATE customer SET role = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"
+	DeleteUser               = "UPDATE customer SET deleted_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"
+	CheckUserIDExists        = "SELECT EXISTS (SELECT 1 FROM customer WHERE id = $1 AND deleted_at IS NULL)"
+	CheckUserExists          = "SELECT EXISTS (SELECT 1 FROM customer WHERE lower(email) = lower($1) AND deleted_at IS NULL)"
+	CheckUserEmailTaken      = "SELECT EXISTS (SELECT 1 FROM customer WHERE lower(email) = lower($1) AND id <> $2 AND deleted_at IS NULL)"
+	CreateSession            = "INSERT INTO sessions (id, customer_id, user_agent, ip_address, expires_at) VALUES ($1, $2, $3, $4, $5)"
+	CreateRefreshToken       = "INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)"
+	UseRefreshToken          = "UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL RETURNING session_id"
+	GetRefreshToken          = "SELECT session_id FROM refresh_tokens WHERE token_hash = $1"
+	GetActiveSession         = "SELECT id, customer_id, user_agent, ip_address, created_at, last_used_at, expires_at FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()"
+	TouchSession             = "UPDATE sessions SET last_used_at = NOW() WHERE id = $1"
+	IsSessionActive          = "SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())"
+	GetActiveSessions        = "SELECT id, user_agent, ip_address, created_at, last_used_at, expires_at FROM sessions WHERE customer_id = $1 AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_used_at DESC"
+	RevokeSession            = "UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND customer_id = $2 AND revoked_at IS NULL"
+	RevokeUserSessions       = "UPDATE sessions SET revoked_at = NOW() WHERE customer_id = $1 AND revoked_at IS NULL"
+	RevokeSessionByID        = "UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL"
+	GetRolePermissions       = "SELECT permission FROM role_permissions WHERE role = $1"
+)
+
+// Patches write the columns a PATCH changed, the SET list is appended to
+// these by assignments.update.
+const (
+	PatchProduct  = "UPDATE products SET "
+	PatchCategory = "UPDATE category SET "
+	PatchUser     = "UPDATE customer SET "
+)
+
+// Trashed rows are listed with the list queries above filtered on deleted_at,
+// restored only if no live row took their name or email in the meantime and
+// purged for good.
+const (
+	ListTrashedProducts    = "SELECT id, name, deleted_at FROM products"
+	ListTrashedCategories  = "SELECT id, name, deleted_at FROM category"
+	ListTrashedUsers       = "SELECT id, username, deleted_at FROM customer"
+	GetTrashedProductName  = "SELECT name FROM products WHERE id = $1 AND deleted_at IS NOT NULL"
+	GetTrashedCategoryName = "SELECT name FROM category WHERE id = $1 AND deleted_at IS NOT NULL"
+	GetTrashedUserEmail    = "SELECT email FROM customer WHERE id = $1 AND deleted_at IS NOT NULL"
+	RestoreProduct         = "UPDATE products SET deleted_at = NULL, updated_at = NOW(), version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL"
+	// A category whose parent is still in the trash is restored to the root.
+	RestoreCategory        = "UPDATE category SET deleted_at = NULL, updated_at = NOW(), version = version + 1, parent_id = CASE WHEN EXISTS (SELECT 1 FROM category p WHERE p.id = category.parent_id AND p.deleted_at IS NULL) THEN parent_id END WHERE id = $1 AND deleted_at IS NOT NULL"
+	RestoreUser            = "UPDATE customer SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL"
+	PurgeProduct           = "DELETE FROM products WHERE id = $1 AND deleted_at IS NOT NULL"
+	PurgeCategory          = "DELETE FROM category WHERE id = $1 AND deleted_at IS NOT NULL"
+	PurgeUser              = "DELETE FROM customer WHERE id = $1 AND deleted_at IS NOT NULL"
+	PurgeExpiredProducts   = "DELETE FROM products WHERE deleted_at < $1 RETURNING id"
+	PurgeExpiredCategories = "DELETE FROM category WHERE deleted_at < $1 RETURNING id"
+	PurgeExpiredUsers      = "DELETE FROM customer WHERE deleted_at < $1 RETURNING id"
+)
+
+// Bulk writes take their items as parallel arrays and write them with one
+// multi-row statement each.
+const (
+	ListLiveProductNames        = "SELECT name FROM products WHERE name = ANY($1) AND deleted_at IS NULL"
+	ListLiveProductNameOwners   = "SELECT name, id FROM products WHERE name = ANY($1) AND deleted_at IS NULL"
+	ListLiveProductIDs          = "SELECT id FROM products WHERE id = ANY($1) AND deleted_at IS NULL"
+	CreateProducts              = "INSERT INTO products (name) SELECT unnest($1::text[]) RETURNING id, name"
+	UpdateProducts              = "UPDATE products AS p SET name = v.name, updated_at = $3, version = p.version + 1 FROM unnest($1::int[], $2::text[]) AS v(id, name) WHERE p.id = v.id AND p.deleted_at IS NULL"
+	DeleteProducts              = "UPDATE products SET deleted_at = $2, version = version + 1 WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id"
+	DeleteBulkProductCategories = "DELETE FROM product_categories WHERE product_id = ANY($1)"
+	AddBulkProductCategories    = "INSERT INTO product_categories (product_id, category_id) SELECT * FROM unnest($1::int[], $2::int[])"
+	ListLiveCategoryNames       = "SELECT name FROM category WHERE name = ANY($1) AND deleted_at IS NULL"
+	ListLiveCategoryNameOwners  = "SELECT name, id FROM category WHERE name = ANY($1) AND deleted_at IS NULL"
+	ListLiveCategoryIDs         = "SELECT id FROM category WHERE id = ANY($1) AND deleted_at IS NULL"
+	CreateCategories            = "INSERT INTO category (name, parent_id) SELECT * FROM unnest($1::text[], $2::int[]) RETURNING id, name"
+	UpdateCategories            = "UPDATE category AS c SET name = v.name, updated_at = $3, version = c.version + 1 FROM unnest($1::int[], $2::text[]) AS v(id, name) WHERE c.id = v.id AND c.deleted_at IS NULL"
+)
+
+// Exports stream every live row in id order.
+const (
+	ExportProducts   = ListProducts + " WHERE deleted_at IS NULL ORDER BY id"
+	ExportCategories = ListCategories + " WHERE deleted_at IS NULL ORDER BY id"
+)
+
+// Jobs are claimed with SKIP LOCKED so that workers never wait on each
+// other. Every write of a running job is fenced by its attempt, a worker
+// whose lease ran out and whose job was claimed again changes nothing.
 const (
-	GetCategoryByID     = "SELECT name, product_id, created_at, updated_at FROM category WHERE id = $1"
-	UpdateCategory      = "UPDATE category SET name = $2, product_id = $3, updated_at = $4 WHERE id = $1"
-	CreateCategory      = "INSERT INTO category (name, product_id) VALUES ($1, $2)"
-	CheckCategoryExists = "SELECT EXISTS (SELECT 1 FROM category WHERE name = $1)"
-	DeleteCategory      = "DELETE FROM category WHERE id = $1"
-	GetProduct          = "SELECT name, category_id, created_at, updated_at FROM products WHERE id = $1"
-	UpdateProduct       = "UPDATE products SET name = $2, category_id = $3, updated_at = $4 WHERE id = $1"
-	CreateProduct       = "INSERT INTO products (name, category_id) VALUES ($1, $2)"
-	CheckProductExists  = "SELECT EXISTS (SELECT 1 FROM products WHERE name = $1)"
-	DeleteProduct       = "DELETE FROM products WHERE id = $1"
-	AddCustomer         = "INSERT INTO customer (username, email, password, role) VALUES ($1, $2, $3, $4)"
-	GetUserByEmail      = "SELECT id, username, email, password, role FROM customer WHERE email = $1"
-	GetUserByUsername   = "SELECT username, email, role FROM customer WHERE username = $1"
-	GetAllUsers         = "SELECT email, role FROM customer"
-	UpdateUser          = "UPDATE customer SET username = $2, email = $3, role = $4 WHERE id = $1"
-	DeleteUser          = "DELETE FROM customer WHERE id = $1"
-	CheckUserExists     = "SELECT EXISTS (SELECT 1 FROM customer WHERE email = $1)"
+	CreateJob         = "INSERT INTO jobs (kind, payload, max_attempts, created_by) VALUES ($1, $2, $3, $4) RETURNING id, status, run_at, created_at, updated_at"
+	GetJob            = "SELECT id, kind, status, result, error, progress, attempts, max_attempts, run_at, created_at, updated_at, finished_at FROM jobs WHERE id = $1 AND created_by = $2"
+	ClaimJob          = "UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW() WHERE id = (SELECT id FROM jobs WHERE (status = 'queued' AND run_at <= NOW()) OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $1)) ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING id, kind, payload, attempts, max_attempts"
+	UpdateJobProgress = "UPDATE jobs SET progress = $3, locked_at = NOW(), updated_at = NOW() WHERE id = $1 AND attempts = $2 AND status = 'running'"
+	CompleteJob       = "UPDATE jobs SET status = 'succeeded', result = $3, error = NULL, progress = 100, locked_at = NULL, updated_at = NOW(), finished_at = NOW() WHERE id = $1 AND attempts = $2 AND status = 'running'"
+	RetryJob          = "UPDATE jobs SET status = 'queued', error = $3, run_at = $4, progress = 0, locked_at = NULL, updated_at = NOW() WHERE id = $1 AND attempts = $2 AND status = 'running'"
+	FailJob           = "UPDATE jobs SET status = 'dead', error = $3, locked_at = NULL, updated_at = NOW(), finished_at = NOW() WHERE id = $1 AND attempts = $2 AND status = 'running'"
+)
+
+// Writes announce themselves by adding events to the outbox in their own
+// transaction. The dispatcher fans every event out to a delivery per
+// matching webhook subscription, and claims due deliveries with SKIP LOCKED.
+// Claiming pushes next_attempt_at out by the lease, so a delivery whose
+// dispatcher died is picked up again.
+const (
+	InsertEvents            = "INSERT INTO outbox_events (type, resource_id) SELECT $1, unnest($2::text[])"
+	CreateWebhook           = "INSERT INTO webhook_subscriptions (url, secret, event_types, created_by) VALUES ($1, $2, $3, $4) RETURNING id, active, created_at, updated_at"
+	ListWebhooks            = "SELECT id, url, event_types, active, created_at, updated_at, disabled_at FROM webhook_subscriptions ORDER BY id"
+	GetWebhook              = "SELECT id, url, secret, event_types, active, created_at, updated_at, disabled_at FROM webhook_subscriptions WHERE id = $1"
+	DisableWebhook          = "UPDATE webhook_subscriptions SET active = FALSE, updated_at = NOW(), disabled_at = COALESCE(disabled_at, NOW()) WHERE id = $1"
+	CancelWebhookDeliveries = "UPDATE webhook_deliveries SET status = 'cancelled' WHERE subscription_id = $1 AND status = 'pending'"
+	DispatchEvents          = "WITH events AS (UPDATE outbox_events SET dispatched_at = NOW() WHERE id IN (SELECT id FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING id, type) " +
+		"INSERT INTO webhook_deliveries (subscription_id, event_id) SELECT s.id, e.id FROM events e JOIN webhook_subscriptions s ON s.active AND (cardinality(s.event_types) = 0 OR e.type = ANY(s.event_types))"
+	ClaimWebhookDeliveries = "WITH claimed AS (UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2) WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW() ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING id, subscription_id, event_id, attempts) " +
+		"SELECT c.id, c.attempts, s.id, s.url, s.secret, e.id, e.type, e.resource_id, e.created_at FROM claimed c JOIN webhook_subscriptions s ON s.id = c.subscription_id JOIN outbox_events e ON e.id = c.event_id ORDER BY c.id"
+	CompleteWebhookDelivery = "UPDATE webhook_deliveries SET status = 'delivered', response_status = $3, last_error = NULL, delivered_at = NOW() WHERE id = $1 AND attempts = $2 AND status = 'pending'"
+	RetryWebhookDelivery    = "UPDATE webhook_deliveries SET response_status = $3, last_error = $4, next_attempt_at = $5 WHERE id = $1 AND attempts = $2 AND status = 'pending'"
+	FailWebhookDelivery     = "UPDATE webhook_deliveries SET status = 'dead', response_status = $3, last_error = $4 WHERE id = $1 AND attempts = $2 AND status = 'pending'"
+	// Deliveries go with their event, so events still being delivered are
+	// kept.
+	DeleteDispatchedEvents = "DELETE FROM outbox_events e WHERE e.dispatched_at < $1 AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id AND d.status = 'pending')"
+)
+
+// Rate limits are token buckets refilled for the time since they were last
+// touched. Refilling and taking a token is one statement, so concurrent
+// requests of a key cannot both take its last token.
+const (
+	refilledRateLimitTokens = "LEAST($2::double precision, rate_limits.tokens + EXTRACT(EPOCH FROM NOW() - rate_limits.updated_at) * $3::double precision)"
+
+	TakeRateLimitToken = "INSERT INTO rate_limits (key, tokens, allowed, updated_at) VALUES ($1, $2::double precision - 1, TRUE, NOW()) " +
+		"ON CONFLICT (key) DO UPDATE SET tokens = " + refilledRateLimitTokens + " - (" + refilledRateLimitTokens + " >= 1)::int, " +
+		"allowed = " + refilledRateLimitTokens + " >= 1, updated_at = NOW() RETURNING tokens, allowed"
+	DeleteIdleRateLimits = "DELETE FROM rate_limits WHERE updated_at < $1"
 )
@@ -0,0 +1,139 @@
+package repositories
+
+import "strings"
+
+// queryNames names the statements of the repositories after the constants
+// they are declared as, so that metrics and traces group by statement
+// rather than by its text.
+var queryNames = map[string]string{
+	GetCategoryTree:             "GetCategoryTree",
+	GetCategorySubtree:          "GetCategorySubtree",
+	GetCategoryAncestors:        "GetCategoryAncestors",
+	CheckCategoryInSubtree:      "CheckCategoryInSubtree",
+	DeleteCategoryDescendants:   "DeleteCategoryDescendants",
+	GetCategoryByID:             "GetCategoryByID",
+	UpdateCategory:              "UpdateCategory",
+	MoveCategory:                "MoveCategory",
+	CreateCategory:              "CreateCategory",
+	CheckCategoryHasChildren:    "CheckCategoryHasChildren",
+	ReparentCategoryChildren:    "ReparentCategoryChildren",
+	CheckCategoryExists:         "CheckCategoryExists",
+	CheckCategoryNameTaken:      "CheckCategoryNameTaken",
+	DeleteCategory:              "DeleteCategory",
+	CheckCategoryIDExists:       "CheckCategoryIDExists",
+	CountCategoriesByID:         "CountCategoriesByID",
+	ListCategories:              "ListCategories",
+	CountCategories:             "CountCategories",
+	GetProduct:                  "GetProduct",
+	UpdateProduct:               "UpdateProduct",
+	TouchProduct:                "TouchProduct",
+	CreateProduct:               "CreateProduct",
+	CheckProductIDExists:        "CheckProductIDExists",
+	CheckProductExists:          "CheckProductExists",
+	CheckProductNameTaken:       "CheckProductNameTaken",
+	DeleteProduct:               "DeleteProduct",
+	ListProducts:                "ListProducts",
+	CountProducts:               "CountProducts",
+	SearchProducts:              "SearchProducts",
+	SearchProductsAfter:         "SearchProductsAfter",
+	CountProductSearch:          "CountProductSearch",
+	DeleteProductCategories:     "DeleteProductCategories",
+	AddProductCategories:        "AddProductCategories",
+	AddCustomer:                 "AddCustomer",
+	GetUserByEmail:              "GetUserByEmail",
+	GetUserByID:                 "GetUserByID",
+	GetUserByUsername:           "GetUserByUsername",
+	GetAllUsers:                 "GetAllUsers",
+	CountUsers:                  "CountUsers",
+	UpdateUser:                  "UpdateUser",
+	UpdateUserRole:              "UpdateUserRole",
+	DeleteUser:                  "DeleteUser",
+	CheckUserIDExists:           "CheckUserIDExists",
+	CheckUserExists:             "CheckUserExists",
+	CheckUserEmailTaken:         "CheckUserEmailTaken",
+	CreateSession:               "CreateSession",
+	CreateRefreshToken:          "CreateRefreshToken",
+	UseRefreshToken:             "UseRefreshToken",
+	GetRefreshToken:             "GetRefreshToken",
+	GetActiveSession:            "GetActiveSession",
+	TouchSession:                "TouchSession",
+	IsSessionActive:             "IsSessionActive",
+	GetActiveSessions:           "GetActiveSessions",
+	RevokeSession:               "RevokeSession",
+	RevokeUserSessions:          "RevokeUserSessions",
+	RevokeSessionByID:           "RevokeSessionByID",
+	GetRolePermissions:          "GetRolePermissions",
+	PatchProduct:                "PatchProduct",
+	PatchCategory:               "PatchCategory",
+	PatchUser:                   "PatchUser",
+	ListTrashedProducts:         "ListTrashedProducts",
+	ListTrashedCategories:       "ListTrashedCategories",
+	ListTrashedUsers:            "ListTrashedUsers",
+	GetTrashedProductName:       "GetTrashedProductName",
+	GetTrashedCategoryName:      "GetTrashedCategoryName",
+	GetTrashedUserEmail:         "GetTrashedUserEmail",
+	RestoreProduct:              "RestoreProduct",
+	RestoreCategory:             "RestoreCategory",
+	RestoreUser:                 "RestoreUser",
+	PurgeProduct:                "PurgeProduct",
+	PurgeCategory:               "PurgeCategory",
+	PurgeUser:                   "PurgeUser",
+	PurgeExpiredProducts:        "PurgeExpiredProducts",
+	PurgeExpiredCategories:      "PurgeExpiredCategories",
+	PurgeExpiredUsers:           "PurgeExpiredUsers",
+	ListLiveProductNames:        "ListLiveProductNames",
+	ListLiveProductNameOwners:   "ListLiveProductNameOwners",
+	ListLiveProductIDs:          "ListLiveProductIDs",
+	CreateProducts:              "CreateProducts",
+	UpdateProducts:              "UpdateProducts",
+	DeleteProducts:              "DeleteProducts",
+	DeleteBulkProductCategories: "DeleteBulkProductCategories",
+	AddBulkProductCategories:    "AddBulkProductCategories",
+	ListLiveCategoryNames:       "ListLiveCategoryNames",
+	ListLiveCategoryNameOwners:  "ListLiveCategoryNameOwners",
+	ListLiveCategoryIDs:         "ListLiveCategoryIDs",
+	CreateCategories:            "CreateCategories",
+	UpdateCategories:            "UpdateCategories",
+	ExportProducts:              "ExportProducts",
+	ExportCategories:            "ExportCategories",
+	CreateJob:                   "CreateJob",
+	GetJob:                      "GetJob",
+	ClaimJob:                    "ClaimJob",
+	UpdateJobProgress:           "UpdateJobProgress",
+	CompleteJob:                 "CompleteJob",
+	RetryJob:                    "RetryJob",
+	FailJob:                     "FailJob",
+	InsertEvents:                "InsertEvents",
+	CreateWebhook:               "CreateWebhook",
+	ListWebhooks:                "ListWebhooks",
+	GetWebhook:                  "GetWebhook",
+	DisableWebhook:              "DisableWebhook",
+	CancelWebhookDeliveries:     "CancelWebhookDeliveries",
+	DispatchEvents:              "DispatchEvents",
+	ClaimWebhookDeliveries:      "ClaimWebhookDeliveries",
+	CompleteWebhookDelivery:     "CompleteWebhookDelivery",
+	RetryWebhookDelivery:        "RetryWebhookDelivery",
+	FailWebhookDelivery:         "FailWebhookDelivery",
+	DeleteDispatchedEvents:      "DeleteDispatchedEvents",
+	TakeRateLimitToken:          "TakeRateLimitToken",
+	DeleteIdleRateLimits:        "DeleteIdleRateLimits",
+}
+
+// QueryName returns the name of query. Statements built at run time, like
+// the filtered lists and the patches, are named after the declared query
+// they start with. Anything else is named other.
+func QueryName(query string) string {
+	if name, ok := queryNames[query]; ok {
+		return name
+	}
+
+	name, longest := "other", 0
+
+	for prefix, prefixName := range queryNames {
+		if len(prefix) > longest && strings.HasPrefix(query, prefix) {
+			name, longest = prefixName, len(prefix)
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QueryName", func() {
+	It("should name a declared query after its constant", func() {
+		Expect(QueryName(ClaimJob)).Should(Equal("ClaimJob"))
+	})
+
+	It("should name a filtered list after the query it starts with", func() {
+		Expect(QueryName(ListProducts + " WHERE deleted_at IS NULL ORDER BY name LIMIT $1")).Should(Equal("ListProducts"))
+	})
+
+	It("should name a patch after its table", func() {
+		var set assignments
+		set.set("name", "Dune")
+
+		query, _ := set.update(PatchProduct, "1", 0)
+		Expect(QueryName(query)).Should(Equal("PatchProduct"))
+	})
+
+	It("should name an unknown statement other", func() {
+		Expect(QueryName("SELECT 1")).Should(Equal("other"))
+	})
+})
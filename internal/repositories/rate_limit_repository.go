@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"awesomeProject/pkg/database"
+)
+
+type RateLimitRepository interface {
+	TakeToken(ctx context.Context, key string, capacity, rate float64) (float64, bool, error)
+	DeleteIdleRateLimits(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RateLimit struct {
+	db database.Database
+}
+
+func NewRateLimit(db database.Database) RateLimitRepository {
+	return &RateLimit{db: db}
+}
+
+// TakeToken takes a token from the bucket of key, which holds up to capacity
+// tokens and refills at rate tokens per second. A new bucket starts full. It
+// returns the tokens left and whether one was taken.
+func (l *RateLimit) TakeToken(ctx context.Context, key string, capacity, rate float64) (float64, bool, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := l.db.QueryRowContext(ctx, TakeRateLimitToken, key, capacity, rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return tokens, allowed, nil
+}
+
+// DeleteIdleRateLimits deletes the buckets not touched since before and
+// returns how many there were.
+func (l *RateLimit) DeleteIdleRateLimits(ctx context.Context, before time.Time) (int64, error) {
+	result, err := l.db.ExecContext(ctx, DeleteIdleRateLimits, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limits: %w", err)
+	}
+
+	return result.RowsAffected()
+}
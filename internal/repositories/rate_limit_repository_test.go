@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate Limit Repository", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		repo RateLimitRepository
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		repo = NewRateLimit(db)
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("TakeToken", func() {
+		It("should return the tokens left and whether one was taken", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(TakeRateLimitToken)).
+				WithArgs("auth:ip:10.0.0.1", 10.0, 0.5).
+				WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.25, false))
+
+			tokens, allowed, err := repo.TakeToken(context.Background(), "auth:ip:10.0.0.1", 10, 0.5)
+			Expect(err).Should(BeNil())
+			Expect(tokens).Should(Equal(0.25))
+			Expect(allowed).Should(BeFalse())
+		})
+		It("should return error when the statement fails", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(TakeRateLimitToken)).
+				WillReturnError(errors.New("connection reset"))
+
+			_, _, err = repo.TakeToken(context.Background(), "auth:ip:10.0.0.1", 10, 0.5)
+			Expect(err).Should(MatchError(ContainSubstring("failed to take rate limit token")))
+		})
+	})
+
+	Describe("DeleteIdleRateLimits", func() {
+		It("should return the number of deleted buckets", func() {
+			before := time.Now().Add(-time.Minute)
+
+			mock.ExpectExec(regexp.QuoteMeta(DeleteIdleRateLimits)).
+				WithArgs(before).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+
+			deleted, err := repo.DeleteIdleRateLimits(context.Background(), before)
+			Expect(err).Should(BeNil())
+			Expect(deleted).Should(Equal(int64(3)))
+		})
+	})
+})
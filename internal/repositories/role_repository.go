@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"awesomeProject/pkg/database"
+)
+
+type RoleRepository interface {
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+}
+
+type Role struct {
+	db database.Database
+}
+
+func NewRole(db database.Database) RoleRepository {
+	return &Role{db: db}
+}
+
+func (r *Role) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	var permissions []string
+
+	rows, err := r.db.QueryContext(ctx, GetRolePermissions, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+
+		err = rows.Scan(&permission)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Role Repository", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		repo RoleRepository
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		repo = NewRole(db)
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	Describe("GetRolePermissions", func() {
+		It("should return role permissions", func() {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT permission FROM role_permissions WHERE role = $1")).
+				WithArgs("editor").
+				WillReturnRows(sqlmock.NewRows([]string{"permission"}).
+					AddRow("products:write").
+					AddRow("categories:write"))
+
+			permissions, err := repo.GetRolePermissions(context.Background(), "editor")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(permissions).Should(Equal([]string{"products:write", "categories:write"}))
+		})
+		It("should return error on query failure", func() {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT permission FROM role_permissions WHERE role = $1")).
+				WithArgs("editor").
+				WillReturnError(errors.New("query error"))
+
+			permissions, err := repo.GetRolePermissions(context.Background(), "editor")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to get role permissions: query error"))
+			Expect(permissions).Should(BeNil())
+		})
+	})
+})
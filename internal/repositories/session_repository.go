@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session, refreshTokenHash string) error
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string) (*models.Session, error)
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	GetActiveSessions(ctx context.Context, userID string) ([]models.SessionResponse, error)
+	RevokeSession(ctx context.Context, sessionID, userID string) error
+	RevokeSessionByRefreshToken(ctx context.Context, tokenHash string) error
+}
+
+type Session struct {
+	db database.Database
+}
+
+func NewSession(db database.Database) SessionRepository {
+	return &Session{db: db}
+}
+
+func (s *Session) CreateSession(ctx context.Context, session *models.Session, refreshTokenHash string) error {
+	return database.WithTx(ctx, s.db, func(tx database.Querier) error {
+		_, err := tx.ExecContext(ctx, CreateSession, session.ID, session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, CreateRefreshToken, refreshTokenHash, session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create refresh token: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RotateRefreshToken consumes oldHash and registers newHash for the same
+// session. Presenting a token that was already consumed means it leaked, so
+// the whole session is revoked.
+func (s *Session) RotateRefreshToken(ctx context.Context, oldHash, newHash string) (*models.Session, error) {
+	session := &models.Session{}
+
+	err := database.WithTx(ctx, s.db, func(tx database.Querier) error {
+		var sessionID string
+
+		err := tx.QueryRowContext(ctx, UseRefreshToken, oldHash).Scan(&sessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefreshTokenReused
+		}
+		if err != nil {
+			return fmt.Errorf("failed to use refresh token: %w", err)
+		}
+
+		err = tx.QueryRowContext(ctx, GetActiveSession, sessionID).
+			Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+				&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrSessionNotFound
+			}
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, CreateRefreshToken, newHash, session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create refresh token: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, TouchSession, session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// The revocation must outlive the rolled back rotation.
+		err = revokeSessionByRefreshToken(ctx, oldHash, s.db)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *Session) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+
+	err := s.db.QueryRowContext(ctx, IsSessionActive, sessionID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
+
+func (s *Session) GetActiveSessions(ctx context.Context, userID string) ([]models.SessionResponse, error) {
+	var sessions []models.SessionResponse
+
+	rows, err := s.db.QueryContext(ctx, GetActiveSessions, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session models.SessionResponse
+
+		err = rows.Scan(&session.ID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (s *Session) RevokeSession(ctx context.Context, sessionID, userID string) error {
+	result, err := s.db.ExecContext(ctx, RevokeSession, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *Session) RevokeSessionByRefreshToken(ctx context.Context, tokenHash string) error {
+	return revokeSessionByRefreshToken(ctx, tokenHash, s.db)
+}
+
+func revokeSessionByRefreshToken(ctx context.Context, tokenHash string, db database.Querier) error {
+	var sessionID string
+
+	err := db.QueryRowContext(ctx, GetRefreshToken, tokenHash).Scan(&sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidRefreshToken
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, RevokeSessionByID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"awesomeProject/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Repository", func() {
+	var (
+		db      *sql.DB
+		mock    sqlmock.Sqlmock
+		repo    SessionRepository
+		session *models.Session
+		err     error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		repo = NewSession(db)
+		session = &models.Session{
+			ID:        "session",
+			UserID:    "1",
+			UserAgent: "agent",
+			IPAddress: "127.0.0.1",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("CreateSession", func() {
+		It("should create session and refresh token", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(CreateSession)).
+				WithArgs(session.ID, session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(regexp.QuoteMeta(CreateRefreshToken)).
+				WithArgs("hash", session.ID).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			err = repo.CreateSession(context.Background(), session, "hash")
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Describe("RotateRefreshToken", func() {
+		It("should consume the old token and store the new one", func() {
+			now := time.Now()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(UseRefreshToken)).
+				WithArgs("old").
+				WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow("session"))
+			mock.ExpectQuery(regexp.QuoteMeta(GetActiveSession)).
+				WithArgs("session").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at"}).
+					AddRow("session", "1", "agent", "127.0.0.1", now, now, now))
+			mock.ExpectExec(regexp.QuoteMeta(CreateRefreshToken)).
+				WithArgs("new", "session").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(regexp.QuoteMeta(TouchSession)).
+				WithArgs("session").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			rotated, err := repo.RotateRefreshToken(context.Background(), "old", "new")
+			Expect(err).Should(BeNil())
+			Expect(rotated.UserID).Should(Equal("1"))
+		})
+
+		It("should revoke the session when a used token is presented", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(UseRefreshToken)).
+				WithArgs("old").
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
+			mock.ExpectQuery(regexp.QuoteMeta(GetRefreshToken)).
+				WithArgs("old").
+				WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow("session"))
+			mock.ExpectExec(regexp.QuoteMeta(RevokeSessionByID)).
+				WithArgs("session").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			_, err = repo.RotateRefreshToken(context.Background(), "old", "new")
+			Expect(errors.Is(err, ErrRefreshTokenReused)).Should(BeTrue())
+		})
+
+		It("should return error for an unknown token", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(UseRefreshToken)).
+				WithArgs("old").
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
+			mock.ExpectQuery(regexp.QuoteMeta(GetRefreshToken)).
+				WithArgs("old").
+				WillReturnError(sql.ErrNoRows)
+
+			_, err = repo.RotateRefreshToken(context.Background(), "old", "new")
+			Expect(errors.Is(err, ErrInvalidRefreshToken)).Should(BeTrue())
+		})
+
+		It("should return error when the session was revoked", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(UseRefreshToken)).
+				WithArgs("old").
+				WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow("session"))
+			mock.ExpectQuery(regexp.QuoteMeta(GetActiveSession)).
+				WithArgs("session").
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
+
+			_, err = repo.RotateRefreshToken(context.Background(), "old", "new")
+			Expect(errors.Is(err, ErrSessionNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("IsSessionActive", func() {
+		It("should return the session state", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(IsSessionActive)).
+				WithArgs("session").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+			active, err := repo.IsSessionActive(context.Background(), "session")
+			Expect(err).Should(BeNil())
+			Expect(active).Should(BeTrue())
+		})
+	})
+
+	Describe("GetActiveSessions", func() {
+		It("should return the user sessions", func() {
+			now := time.Now()
+			mock.ExpectQuery(regexp.QuoteMeta(GetActiveSessions)).
+				WithArgs("1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at"}).
+					AddRow("a", "agent", "127.0.0.1", now, now, now).
+					AddRow("b", "agent", "127.0.0.1", now, now, now))
+
+			sessions, err := repo.GetActiveSessions(context.Background(), "1")
+			Expect(err).Should(BeNil())
+			Expect(sessions).Should(HaveLen(2))
+		})
+	})
+
+	Describe("RevokeSession", func() {
+		It("should revoke the session", func() {
+			mock.ExpectExec(regexp.QuoteMeta(RevokeSession)).
+				WithArgs("session", "1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			Expect(repo.RevokeSession(context.Background(), "session", "1")).Should(Succeed())
+		})
+
+		It("should return error when nothing was revoked", func() {
+			mock.ExpectExec(regexp.QuoteMeta(RevokeSession)).
+				WithArgs("session", "2").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err = repo.RevokeSession(context.Background(), "session", "2")
+			Expect(errors.Is(err, ErrSessionNotFound)).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+)
+
+type TrashRepository interface {
+	ListTrash(ctx context.Context, kind models.TrashKind, options models.ListOptions) (*models.TrashPage, error)
+	RestoreTrash(ctx context.Context, kind models.TrashKind, id string) error
+	PurgeTrash(ctx context.Context, kind models.TrashKind, id string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Trash struct {
+	db database.Database
+}
+
+func NewTrash(db database.Database) TrashRepository {
+	return &Trash{db: db}
+}
+
+// trashSpec describes the trash of one table. Trashed rows keep their unique
+// key, name or email, which must still be free when they are restored.
+type trashSpec struct {
+	// resource names the rows in events.
+	resource      string
+	list          listSpec[models.TrashedItem]
+	getKey        string
+	checkKey      string
+	restore       string
+	purge         string
+	purgeExpired  string
+	notFound      error
+	alreadyExists error
+}
+
+// trashKinds is the order in which expired rows are purged.
+var trashKinds = []models.TrashKind{models.TrashProducts, models.TrashCategories, models.TrashUsers}
+
+var trashSpecs = map[models.TrashKind]trashSpec{
+	models.TrashProducts: {
+		resource:      models.EventProduct,
+		list:          trashList(ListTrashedProducts, CountProducts, "name"),
+		getKey:        GetTrashedProductName,
+		checkKey:      CheckProductExists,
+		restore:       RestoreProduct,
+		purge:         PurgeProduct,
+		purgeExpired:  PurgeExpiredProducts,
+		notFound:      ErrProductNotFound,
+		alreadyExists: ErrProductAlreadyExists,
+	},
+	models.TrashCategories: {
+		resource:      models.EventCategory,
+		list:          trashList(ListTrashedCategories, CountCategories, "name"),
+		getKey:        GetTrashedCategoryName,
+		checkKey:      CheckCategoryExists,
+		restore:       RestoreCategory,
+		purge:         PurgeCategory,
+		purgeExpired:  PurgeExpiredCategories,
+		notFound:      ErrCategoryNotFound,
+		alreadyExists: ErrCategoryAlreadyExists,
+	},
+	models.TrashUsers: {
+		resource:      models.EventUser,
+		list:          trashList(ListTrashedUsers, CountUsers, "username"),
+		getKey:        GetTrashedUserEmail,
+		checkKey:      CheckUserExists,
+		restore:       RestoreUser,
+		purge:         PurgeUser,
+		purgeExpired:  PurgeExpiredUsers,
+		notFound:      ErrUserNotFound,
+		alreadyExists: ErrUserAlreadyExists,
+	},
+}
+
+func trashList(selectQuery, countQuery, nameColumn string) listSpec[models.TrashedItem] {
+	return listSpec[models.TrashedItem]{
+		selectQuery: selectQuery,
+		countQuery:  countQuery,
+		sortKeys: map[string]sortKey[models.TrashedItem]{
+			"id":         {column: "id", value: func(t models.TrashedItem) string { return t.ID }},
+			"name":       {column: nameColumn, value: func(t models.TrashedItem) string { return t.Name }},
+			"deleted_at": {column: "deleted_at", value: func(t models.TrashedItem) string { return formatCursorTime(t.DeletedAt) }},
+		},
+		scan: func(rows *sql.Rows) (models.TrashedItem, error) {
+			var item models.TrashedItem
+
+			err := rows.Scan(&item.ID, &item.Name, &item.DeletedAt)
+
+			return item, err
+		},
+	}
+}
+
+func (t *Trash) ListTrash(ctx context.Context, kind models.TrashKind, options models.ListOptions) (*models.TrashPage, error) {
+	spec, ok := trashSpecs[kind]
+	if !ok {
+		return nil, ErrTrashKindNotFound
+	}
+
+	var filters conditions
+
+	filters.add("deleted_at IS NOT NULL")
+	filters.addCreatedRange(options)
+
+	page, err := listPage(ctx, t.db, spec.list, options, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed %s: %w", kind, err)
+	}
+
+	return page, nil
+}
+
+// RestoreTrash brings a trashed row back, unless a live row has taken its
+// name or email since it was deleted.
+func (t *Trash) RestoreTrash(ctx context.Context, kind models.TrashKind, id string) error {
+	spec, ok := trashSpecs[kind]
+	if !ok {
+		return ErrTrashKindNotFound
+	}
+
+	return database.WithSerializableTx(ctx, t.db, func(tx database.Querier) error {
+		var key string
+
+		err := tx.QueryRowContext(ctx, spec.getKey, id).Scan(&key)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return spec.notFound
+			}
+
+			return fmt.Errorf("failed to get trashed %s: %w", kind, err)
+		}
+
+		var taken bool
+
+		err = tx.QueryRowContext(ctx, spec.checkKey, key).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("failed to check %s exist: %w", kind, err)
+		}
+
+		if taken {
+			return spec.alreadyExists
+		}
+
+		result, err := tx.ExecContext(ctx, spec.restore, id)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return spec.alreadyExists
+			}
+			return fmt.Errorf("failed to restore %s: %w", kind, err)
+		}
+
+		err = requireAffected(result, spec.notFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, spec.resource, models.EventRestored, id)
+	})
+}
+
+// PurgeTrash deletes a trashed row for good. Live rows cannot be purged.
+func (t *Trash) PurgeTrash(ctx context.Context, kind models.TrashKind, id string) error {
+	spec, ok := trashSpecs[kind]
+	if !ok {
+		return ErrTrashKindNotFound
+	}
+
+	return database.WithTx(ctx, t.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, spec.purge, id)
+		if err != nil {
+			return fmt.Errorf("failed to purge %s: %w", kind, err)
+		}
+
+		err = requireAffected(result, spec.notFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, spec.resource, models.EventPurged, id)
+	})
+}
+
+// PurgeExpired deletes every row trashed before the given time and returns
+// how many were deleted.
+func (t *Trash) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	for _, kind := range trashKinds {
+		spec := trashSpecs[kind]
+
+		var ids []string
+
+		err := database.WithTx(ctx, t.db, func(tx database.Querier) error {
+			var err error
+
+			ids, err = queryIDs(ctx, tx, spec.purgeExpired, before)
+			if err != nil {
+				return fmt.Errorf("failed to purge expired %s: %w", kind, err)
+			}
+
+			return recordEvents(ctx, tx, spec.resource, models.EventPurged, ids...)
+		})
+		if err != nil {
+			return purged, err
+		}
+
+		purged += int64(len(ids))
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"awesomeProject/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trash Repository", func() {
+	var (
+		mock sqlmock.Sqlmock
+		db   *sql.DB
+		repo TrashRepository
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		repo = NewTrash(db)
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("ListTrash", func() {
+		It("should list only trashed rows", func() {
+			deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			mock.ExpectQuery(regexp.QuoteMeta(CountUsers + " WHERE deleted_at IS NOT NULL")).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery(regexp.QuoteMeta(ListTrashedUsers + " WHERE deleted_at IS NOT NULL ORDER BY username ASC, id ASC LIMIT 21")).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "deleted_at"}).AddRow("7", "alice", deletedAt))
+
+			page, err := repo.ListTrash(context.Background(), models.TrashUsers, models.ListOptions{Sort: "name"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(page.Data).Should(Equal([]models.TrashedItem{{ID: "7", Name: "alice", DeletedAt: deletedAt}}))
+			Expect(page.Meta.Total).Should(Equal(int64(1)))
+		})
+
+		It("should return error on an unknown kind", func() {
+			_, err := repo.ListTrash(context.Background(), "orders", models.ListOptions{})
+			Expect(errors.Is(err, ErrTrashKindNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("RestoreTrash", func() {
+		It("should restore a trashed product", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(GetTrashedProductName)).
+				WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Laptop"))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckProductExists)).
+				WithArgs("Laptop").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(RestoreProduct)).
+				WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventProduct, models.EventRestored, "1")
+			mock.ExpectCommit()
+
+			err := repo.RestoreTrash(context.Background(), models.TrashProducts, "1")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should return not found when the row is not in the trash", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(GetTrashedCategoryName)).
+				WithArgs("1").WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
+
+			err := repo.RestoreTrash(context.Background(), models.TrashCategories, "1")
+			Expect(errors.Is(err, ErrCategoryNotFound)).Should(BeTrue())
+		})
+
+		It("should return conflict when a live row took the email", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(GetTrashedUserEmail)).
+				WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("alice@example.com"))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
+				WithArgs("alice@example.com").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.RestoreTrash(context.Background(), models.TrashUsers, "1")
+			Expect(errors.Is(err, ErrUserAlreadyExists)).Should(BeTrue())
+		})
+	})
+
+	Describe("PurgeTrash", func() {
+		It("should delete a trashed row", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(PurgeCategory)).
+				WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventCategory, models.EventPurged, "1")
+			mock.ExpectCommit()
+
+			err := repo.PurgeTrash(context.Background(), models.TrashCategories, "1")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should return not found for a live or missing row", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(PurgeProduct)).
+				WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.PurgeTrash(context.Background(), models.TrashProducts, "1")
+			Expect(errors.Is(err, ErrProductNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("PurgeExpired", func() {
+		before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		It("should purge every kind and count the rows", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(PurgeExpiredProducts)).
+				WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			expectEvents(mock, models.EventProduct, models.EventPurged, "1", "2")
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(PurgeExpiredCategories)).
+				WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+			expectEvents(mock, models.EventCategory, models.EventPurged, "3")
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(PurgeExpiredUsers)).
+				WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectCommit()
+
+			purged, err := repo.PurgeExpired(context.Background(), before)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(purged).Should(Equal(int64(3)))
+		})
+
+		It("should stop at the first failure", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(PurgeExpiredProducts)).
+				WithArgs(before).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+			expectEvents(mock, models.EventProduct, models.EventPurged, "1", "2")
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(PurgeExpiredCategories)).
+				WithArgs(before).WillReturnError(errors.New("db error"))
+			mock.ExpectRollback()
+
+			purged, err := repo.PurgeExpired(context.Background(), before)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to purge expired categories: db error"))
+			Expect(purged).Should(Equal(int64(2)))
+		})
+	})
+})
@@ -1,20 +1,25 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"awesomeProject/internal/models"
 	"awesomeProject/pkg/database"
 )
 
 type UserRepository interface {
-	GetUserByUsername(id string) (*models.UserResponse, error)
-	GetAllUsers() ([]models.UserResponse, error)
-	UpdateUser(user *models.User) error
-	CreateUser(user *models.User) error
-	DeleteUser(id string) error
+	GetUserByUsername(ctx context.Context, id string) (*models.UserResponse, error)
+	GetUserByID(ctx context.Context, id string) (*models.UserResponse, error)
+	GetAllUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	UpdateUserRole(ctx context.Context, id, role string, version int64) error
+	PatchUser(ctx context.Context, userID string, patch models.UserPatch) error
+	CreateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id string, version int64) error
 }
 
 type UserRepositoryImpl struct {
@@ -27,13 +32,13 @@ func NewUserRepository(db database.Database) UserRepository {
 	}
 }
 
-func (u *UserRepositoryImpl) GetUserByUsername(name string) (*models.UserResponse, error) {
+func (u *UserRepositoryImpl) GetUserByUsername(ctx context.Context, name string) (*models.UserResponse, error) {
 	var userResponse models.UserResponse
 
-	err := u.db.QueryRow(GetUserByUsername, name).Scan(&userResponse.Username, &userResponse.Email, &userResponse.Role)
+	err := u.db.QueryRowContext(ctx, GetUserByUsername, name).Scan(&userResponse.ID, &userResponse.Username, &userResponse.Email, &userResponse.Role, &userResponse.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -41,69 +46,171 @@ func (u *UserRepositoryImpl) GetUserByUsername(name string) (*models.UserRespons
 	return &userResponse, nil
 }
 
-func (u *UserRepositoryImpl) GetAllUsers() ([]models.UserResponse, error) {
-	var users []models.UserResponse
+// GetUserByID returns the user with id, the identifier tokens carry. Unlike
+// the username it never changes.
+func (u *UserRepositoryImpl) GetUserByID(ctx context.Context, id string) (*models.UserResponse, error) {
+	var userResponse models.UserResponse
+
+	err := u.db.QueryRowContext(ctx, GetUserByID, id).Scan(&userResponse.ID, &userResponse.Username, &userResponse.Email, &userResponse.Role, &userResponse.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &userResponse, nil
+}
+
+func (u *UserRepositoryImpl) GetAllUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error) {
+	var filters conditions
+
+	filters.add("deleted_at IS NULL")
+	if filter.Role != "" {
+		filters.add("role = $%d", filter.Role)
+	}
+	filters.addCreatedRange(filter.ListOptions)
 
-	rows, err := u.db.Query(GetAllUsers)
+	page, err := listPage(ctx, u.db, userList, filter.ListOptions, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all users: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var user models.UserResponse
+	return page, nil
+}
 
-		err = rows.Scan(&user.Email, &user.Role)
+func (u *UserRepositoryImpl) UpdateUser(ctx context.Context, user *models.User) error {
+	return database.WithSerializableTx(ctx, u.db, func(tx database.Querier) error {
+		err := requireFree(ctx, tx, CheckUserEmailTaken, user.Email, user.ID, ErrUserAlreadyExists)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return err
 		}
 
-		users = append(users, user)
-	}
+		result, err := tx.ExecContext(ctx, UpdateUser, user.ID, user.Username, user.Email, user.Version)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, user.Version, CheckUserIDExists, user.ID, ErrUserNotFound)
+		if err != nil {
+			return err
+		}
 
-	return users, nil
+		return recordEvents(ctx, tx, models.EventUser, models.EventUpdated, user.ID)
+	})
 }
 
-func (u *UserRepositoryImpl) UpdateUser(user *models.User) error {
-	_, err := u.db.Exec(UpdateUser, user.ID, user.Username, user.Email, user.Role)
-	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
-	}
+// UpdateUserRole changes the role of the user with id. The new role is in
+// the tokens issued from the next refresh on.
+func (u *UserRepositoryImpl) UpdateUserRole(ctx context.Context, id, role string, version int64) error {
+	return database.WithTx(ctx, u.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, UpdateUserRole, id, role, version)
+		if err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
 
-	return nil
+		err = requireVersion(ctx, tx, result, version, CheckUserIDExists, id, ErrUserNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventUser, models.EventUpdated, id)
+	})
 }
 
-func (u *UserRepositoryImpl) CreateUser(user *models.User) error {
-	exists, err := checkUserExists(user.Email, u.db)
-	if err != nil {
-		return fmt.Errorf("failed to check user existence: %w", err)
-	}
+// PatchUser writes only the fields named in patch.Fields. patch.Version must
+// match the stored row version, otherwise ErrVersionConflict is returned.
+func (u *UserRepositoryImpl) PatchUser(ctx context.Context, userID string, patch models.UserPatch) error {
+	var set assignments
 
-	if exists {
-		return fmt.Errorf("user already exists")
+	if patch.Fields["username"] {
+		set.set("username", patch.Username)
 	}
 
-	_, err = u.db.Exec(AddCustomer, user.Username, user.Email, user.Password, user.Role)
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+	if patch.Fields["email"] {
+		set.set("email", patch.Email)
 	}
 
-	return nil
+	query, args := set.update(PatchUser, userID, patch.Version)
+
+	return database.WithSerializableTx(ctx, u.db, func(tx database.Querier) error {
+		if patch.Fields["email"] {
+			err := requireFree(ctx, tx, CheckUserEmailTaken, patch.Email, userID, ErrUserAlreadyExists)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to patch user: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, patch.Version, CheckUserIDExists, userID, ErrUserNotFound)
+		if err != nil {
+			return err
+		}
+
+		return recordEvents(ctx, tx, models.EventUser, models.EventUpdated, userID)
+	})
 }
 
-func (u *UserRepositoryImpl) DeleteUser(id string) error {
-	_, err := u.db.Exec(DeleteUser, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
-	}
+func (u *UserRepositoryImpl) CreateUser(ctx context.Context, user *models.User) error {
+	return database.WithSerializableTx(ctx, u.db, func(tx database.Querier) error {
+		exists, err := checkUserExists(ctx, user.Email, tx)
+		if err != nil {
+			return fmt.Errorf("failed to check user existence: %w", err)
+		}
 
-	return nil
+		if exists {
+			return ErrUserAlreadyExists
+		}
+
+		err = tx.QueryRowContext(ctx, AddCustomer, user.Username, user.Email, user.Password, user.Role).Scan(&user.ID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		return recordEvents(ctx, tx, models.EventUser, models.EventCreated, user.ID)
+	})
 }
 
-func checkUserExists(userEmail string, db database.Database) (bool, error) {
+// DeleteUser moves the user to the trash and revokes their sessions, so
+// tokens issued before the deletion stop working.
+func (u *UserRepositoryImpl) DeleteUser(ctx context.Context, id string, version int64) error {
+	return database.WithTx(ctx, u.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, DeleteUser, id, time.Now(), version)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		err = requireVersion(ctx, tx, result, version, CheckUserIDExists, id, ErrUserNotFound)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, RevokeUserSessions, id)
+		if err != nil {
+			return fmt.Errorf("failed to revoke user sessions: %w", err)
+		}
+
+		return recordEvents(ctx, tx, models.EventUser, models.EventDeleted, id)
+	})
+}
+
+func checkUserExists(ctx context.Context, userEmail string, db database.Querier) (bool, error) {
 	var exists bool
 
-	err := db.QueryRow(CheckUserExists, userEmail).Scan(&exists)
+	err := db.QueryRowContext(ctx, CheckUserExists, userEmail).Scan(&exists)
 	if err != nil {
 		return exists, fmt.Errorf("error checking if user exists: %w", err)
 	}
@@ -111,8 +218,24 @@ func checkUserExists(userEmail string, db database.Database) (bool, error) {
 	return exists, nil
 }
 
-func getUserByEmail(email string, db database.Database) (*models.UserResponse, error) {
-	var userResponse models.UserResponse
-
-	return &userResponse, nil
+var userList = listSpec[models.UserResponse]{
+	selectQuery: GetAllUsers,
+	countQuery:  CountUsers,
+	sortKeys: map[string]sortKey[models.UserResponse]{
+		"id":         {column: "id", value: func(u models.UserResponse) string { return u.ID }},
+		"username":   {column: "username", value: func(u models.UserResponse) string { return u.Username }},
+		"email":      {column: "email", value: func(u models.UserResponse) string { return u.Email }},
+		"created_at": {column: "created_at", value: func(u models.UserResponse) string { return formatCursorTime(*u.CreatedAt) }},
+	},
+	scan: func(rows *sql.Rows) (models.UserResponse, error) {
+		var (
+			user      models.UserResponse
+			createdAt time.Time
+		)
+
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &createdAt)
+		user.CreatedAt = &createdAt
+
+		return user, err
+	},
 }
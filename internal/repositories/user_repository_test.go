@@ -2,10 +2,12 @@ package repositories
 
 import (
 	"awesomeProject/internal/models"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 
@@ -20,7 +22,7 @@ var _ = Describe("User Repository", func() {
 		repo         UserRepository
 		user         *models.User
 		userResponse *models.UserResponse
-		users        []models.UserResponse
+		users        *models.UserPage
 		err          error
 	)
 
@@ -38,44 +40,67 @@ var _ = Describe("User Repository", func() {
 		}
 
 		userResponse = &models.UserResponse{}
-		users = []models.UserResponse{}
+		users = nil
 	})
 
 	AfterEach(func() {
 		db.Close()
 	})
 
+	Describe("GetUserByID", func() {
+		It("should return the user with its version", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByID)).
+				WithArgs("1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "version"}).
+					AddRow("1", "renamed", "test@example.com", "user", 4))
+
+			userResponse, err = repo.GetUserByID(context.Background(), "1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(userResponse.Username).Should(Equal("renamed"))
+			Expect(userResponse.Version).Should(Equal(int64(4)))
+		})
+		It("should return ErrUserNotFound when user not found", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByID)).
+				WithArgs("1").
+				WillReturnError(sql.ErrNoRows)
+
+			_, err = repo.GetUserByID(context.Background(), "1")
+			Expect(errors.Is(err, ErrUserNotFound)).Should(BeTrue())
+		})
+	})
+
 	Describe("GetUserByUsername", func() {
 		It("should return user response successfully", func() {
-			rows := sqlmock.NewRows([]string{"username", "email", "role"}).
-				AddRow("username test", "test@example.com", "admin")
+			rows := sqlmock.NewRows([]string{"id", "username", "email", "role", "version"}).
+				AddRow("1", "username test", "test@example.com", "admin", 3)
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT username, email, role FROM customer WHERE username = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByUsername)).
 				WithArgs(user.Username).
 				WillReturnRows(rows)
 
-			userResponse, err = repo.GetUserByUsername(user.Username)
+			userResponse, err = repo.GetUserByUsername(context.Background(), user.Username)
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(userResponse).ShouldNot(BeNil())
 			Expect(userResponse.Email).Should(Equal("test@example.com"))
 			Expect(userResponse.Role).Should(Equal("admin"))
+			Expect(userResponse.Version).Should(Equal(int64(3)))
 		})
 		It("should return error when user not found", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT username, email, role FROM customer WHERE username = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByUsername)).
 				WithArgs(user.Username).
 				WillReturnError(sql.ErrNoRows)
 
-			userResponse, err = repo.GetUserByUsername(user.Username)
+			userResponse, err = repo.GetUserByUsername(context.Background(), user.Username)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("user not found"))
 			Expect(userResponse).Should(BeNil())
 		})
 		It("should return error on query failure", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT username, email, role FROM customer WHERE username = $1")).
+			mock.ExpectQuery(regexp.QuoteMeta(GetUserByUsername)).
 				WithArgs(user.Username).
 				WillReturnError(errors.New("query error"))
 
-			userResponse, err = repo.GetUserByUsername(user.Username)
+			userResponse, err = repo.GetUserByUsername(context.Background(), user.Username)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to get user: query error"))
 			Expect(userResponse).Should(BeNil())
@@ -83,42 +108,102 @@ var _ = Describe("User Repository", func() {
 	})
 
 	Describe("GetAllUsers", func() {
+		listQuery := regexp.QuoteMeta("SELECT id, username, email, role, created_at FROM customer")
+		countQuery := regexp.QuoteMeta("SELECT COUNT(*) FROM customer")
+		columns := []string{"id", "username", "email", "role", "created_at"}
+
 		It("should return all users successfully", func() {
-			rows := sqlmock.NewRows([]string{"email", "role"}).
-				AddRow("user1@example.com", "admin").
-				AddRow("user2@example.com", "user")
+			rows := sqlmock.NewRows(columns).
+				AddRow("1", "user1", "user1@example.com", "admin", time.Time{}).
+				AddRow("2", "user2", "user2@example.com", "user", time.Time{})
+
+			mock.ExpectQuery(countQuery + regexp.QuoteMeta(" WHERE deleted_at IS NULL")).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			mock.ExpectQuery(listQuery + regexp.QuoteMeta(" WHERE deleted_at IS NULL ORDER BY id ASC, id ASC LIMIT 21")).
+				WillReturnRows(rows)
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT email, role FROM customer")).
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(users.Data).Should(HaveLen(2))
+			Expect(users.Data[0].Email).Should(Equal("user1@example.com"))
+			Expect(users.Data[0].Role).Should(Equal("admin"))
+			Expect(users.Data[1].Email).Should(Equal("user2@example.com"))
+			Expect(users.Data[1].Role).Should(Equal("user"))
+			Expect(users.Meta.Total).Should(Equal(int64(2)))
+			Expect(users.Meta.NextCursor).Should(BeEmpty())
+		})
+		It("should filter, sort and return a cursor for the next page", func() {
+			createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			rows := sqlmock.NewRows(columns).
+				AddRow("2", "user2", "user2@example.com", "admin", createdAt).
+				AddRow("1", "user1", "user1@example.com", "admin", createdAt)
+
+			mock.ExpectQuery(countQuery + regexp.QuoteMeta(" WHERE deleted_at IS NULL AND role = $1")).
+				WithArgs("admin").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			mock.ExpectQuery(listQuery + regexp.QuoteMeta(" WHERE deleted_at IS NULL AND role = $1 ORDER BY created_at DESC, id DESC LIMIT 2")).
+				WithArgs("admin").
 				WillReturnRows(rows)
 
-			users, err = repo.GetAllUsers()
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{
+				ListOptions: models.ListOptions{Limit: 1, Sort: "-created_at"},
+				Role:        "admin",
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(users.Data).Should(HaveLen(1))
+			Expect(users.Meta.NextCursor).ShouldNot(BeEmpty())
+
+			mock.ExpectQuery(countQuery + regexp.QuoteMeta(" WHERE deleted_at IS NULL AND role = $1")).
+				WithArgs("admin").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			mock.ExpectQuery(listQuery+regexp.QuoteMeta(" WHERE deleted_at IS NULL AND role = $1 AND (created_at, id) < ($2, $3) ORDER BY created_at DESC, id DESC LIMIT 2")).
+				WithArgs("admin", createdAt.Format(time.RFC3339Nano), "2").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "user1", "user1@example.com", "admin", createdAt))
+
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{
+				ListOptions: models.ListOptions{Limit: 1, Sort: "-created_at", Cursor: users.Meta.NextCursor},
+				Role:        "admin",
+			})
 			Expect(err).ShouldNot(HaveOccurred())
-			Expect(users).Should(HaveLen(2))
-			Expect(users[0].Email).Should(Equal("user1@example.com"))
-			Expect(users[0].Role).Should(Equal("admin"))
-			Expect(users[1].Email).Should(Equal("user2@example.com"))
-			Expect(users[1].Role).Should(Equal("user"))
+			Expect(users.Data).Should(HaveLen(1))
+			Expect(users.Data[0].ID).Should(Equal("1"))
+			Expect(users.Meta.NextCursor).Should(BeEmpty())
+		})
+		It("should return error on unknown sort column", func() {
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{
+				ListOptions: models.ListOptions{Sort: "password"},
+			})
+			Expect(errors.Is(err, ErrInvalidSort)).Should(BeTrue())
+			Expect(users).Should(BeNil())
+		})
+		It("should return error when the cursor belongs to another sort", func() {
+			mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{
+				ListOptions: models.ListOptions{Sort: "email", Cursor: encodeCursor(cursor{Sort: "username", Value: "a", ID: "1"})},
+			})
+			Expect(errors.Is(err, ErrInvalidCursor)).Should(BeTrue())
+			Expect(users).Should(BeNil())
 		})
 		It("should return error on query failure", func() {
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT email, role FROM customer")).
-				WillReturnError(errors.New("query error"))
+			mock.ExpectQuery(countQuery).WillReturnError(errors.New("query error"))
 
-			users, err = repo.GetAllUsers()
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{})
 			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).Should(ContainSubstring("failed to get all users: query error"))
+			Expect(err.Error()).Should(ContainSubstring("failed to get all users: failed to count rows: query error"))
 			Expect(users).Should(BeNil())
 		})
 		It("should return error on scan failure", func() {
-			rows := sqlmock.NewRows([]string{"email", "role"}).
-				AddRow("user1@example.com", "admin").
-				AddRow("user2@example.com", nil)
+			rows := sqlmock.NewRows(columns).
+				AddRow("1", "user1", "user1@example.com", "admin", time.Time{}).
+				AddRow("2", "user2", "user2@example.com", nil, time.Time{})
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT email, role FROM customer")).
-				WillReturnRows(rows)
+			mock.ExpectQuery(countQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			mock.ExpectQuery(listQuery).WillReturnRows(rows)
 
-			users, err = repo.GetAllUsers()
+			users, err = repo.GetAllUsers(context.Background(), models.UserFilter{})
 			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).Should(ContainSubstring("failed to scan user"))
+			Expect(err.Error()).Should(ContainSubstring("failed to scan row"))
 			Expect(users).Should(BeNil())
 		})
 	})
@@ -133,11 +218,17 @@ var _ = Describe("User Repository", func() {
 				Role:     "user",
 			}
 
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE customer SET username = $2, email = $3, role = $4 WHERE id = $1")).
-				WithArgs(user.ID, user.Username, user.Email, user.Role).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs(user.Email, user.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateUser)).
+				WithArgs(user.ID, user.Username, user.Email, int64(0)).
 				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventUser, models.EventUpdated, "1")
+			mock.ExpectCommit()
 
-			err := repo.UpdateUser(user)
+			err := repo.UpdateUser(context.Background(), user)
 			Expect(err).ShouldNot(HaveOccurred())
 		})
 		It("should return error on database failure", func() {
@@ -149,14 +240,144 @@ var _ = Describe("User Repository", func() {
 				Role:     "user",
 			}
 
-			mock.ExpectExec(regexp.QuoteMeta("UPDATE customer SET username = $2, email = $3, role = $4 WHERE id = $1")).
-				WithArgs(user.ID, user.Username, user.Email, user.Role).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs(user.Email, user.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateUser)).
+				WithArgs(user.ID, user.Username, user.Email, int64(0)).
 				WillReturnError(fmt.Errorf("database error"))
+			mock.ExpectRollback()
 
-			err := repo.UpdateUser(user)
+			err := repo.UpdateUser(context.Background(), user)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to update user"))
 		})
+		It("should return ErrVersionConflict when the user was modified", func() {
+			user.Version = 2
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs(user.Email, user.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta(UpdateUser)).
+				WithArgs(user.ID, user.Username, user.Email, int64(2)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserIDExists)).
+				WithArgs(user.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.UpdateUser(context.Background(), user)
+			Expect(errors.Is(err, ErrVersionConflict)).Should(BeTrue())
+		})
+		It("should return ErrUserAlreadyExists when another user has the email", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs(user.Email, user.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.UpdateUser(context.Background(), user)
+			Expect(errors.Is(err, ErrUserAlreadyExists)).Should(BeTrue())
+		})
+	})
+
+	Describe("UpdateUserRole", func() {
+		It("should change only the role", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(UpdateUserRole)).
+				WithArgs("2", "editor", int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventUser, models.EventUpdated, "2")
+			mock.ExpectCommit()
+
+			err := repo.UpdateUserRole(context.Background(), "2", "editor", 0)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+		It("should return ErrUserNotFound for an unknown user", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(UpdateUserRole)).
+				WithArgs("9", "editor", int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserIDExists)).
+				WithArgs("9").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.UpdateUserRole(context.Background(), "9", "editor", 0)
+			Expect(errors.Is(err, ErrUserNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("PatchUser", func() {
+		It("should write only the changed columns", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs("new@example.com", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE customer SET email = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)")).
+				WithArgs("1", "new@example.com", int64(5)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventUser, models.EventUpdated, "1")
+			mock.ExpectCommit()
+
+			err := repo.PatchUser(context.Background(), "1", models.UserPatch{
+				Username: "ignored",
+				Email:    "new@example.com",
+				Fields:   models.PatchFields{"email": true},
+				Version:  5,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+		It("should return ErrUserNotFound when the user is gone", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs("new@example.com", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE customer SET")).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserIDExists)).
+				WithArgs("1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectRollback()
+
+			err := repo.PatchUser(context.Background(), "1", models.UserPatch{
+				Email:   "new@example.com",
+				Fields:  models.PatchFields{"email": true},
+				Version: 5,
+			})
+			Expect(errors.Is(err, ErrUserNotFound)).Should(BeTrue())
+		})
+		It("should not check the email when it is left unchanged", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE customer SET username = $2, version = version + 1")).
+				WithArgs("1", "renamed", int64(5)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectEvents(mock, models.EventUser, models.EventUpdated, "1")
+			mock.ExpectCommit()
+
+			err := repo.PatchUser(context.Background(), "1", models.UserPatch{
+				Username: "renamed",
+				Fields:   models.PatchFields{"username": true},
+				Version:  5,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+		It("should return ErrUserAlreadyExists when another user has the new email", func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserEmailTaken)).
+				WithArgs("taken@example.com", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			err := repo.PatchUser(context.Background(), "1", models.UserPatch{
+				Email:   "taken@example.com",
+				Fields:  models.PatchFields{"email": true},
+				Version: 5,
+			})
+			Expect(errors.Is(err, ErrUserAlreadyExists)).Should(BeTrue())
+		})
 	})
 
 	Describe("CreateUser", func() {
@@ -168,16 +389,20 @@ var _ = Describe("User Repository", func() {
 				Role:     "user",
 			}
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM customer WHERE email = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
 				WithArgs(user.Email).
 				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO customer (username, email, password, role) VALUES ($1, $2, $3, $4)")).
+			mock.ExpectQuery(regexp.QuoteMeta(AddCustomer)).
 				WithArgs(user.Username, user.Email, user.Password, user.Role).
-				WillReturnResult(sqlmock.NewResult(1, 1))
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+			expectEvents(mock, models.EventUser, models.EventCreated, "7")
+			mock.ExpectCommit()
 
-			err := repo.CreateUser(user)
+			err := repo.CreateUser(context.Background(), user)
 			Expect(err).ShouldNot(HaveOccurred())
+			Expect(user.ID).Should(Equal("7"))
 		})
 		It("should return error user already exists", func() {
 			user := &models.User{
@@ -187,11 +412,13 @@ var _ = Describe("User Repository", func() {
 				Role:     "user",
 			}
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM customer WHERE email = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
 				WithArgs(user.Email).
 				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
 
-			err := repo.CreateUser(user)
+			err := repo.CreateUser(context.Background(), user)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("user already exists"))
 		})
@@ -204,15 +431,17 @@ var _ = Describe("User Repository", func() {
 				Role:     "user",
 			}
 
-			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM customer WHERE email = $1)")).
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(CheckUserExists)).
 				WithArgs(user.Email).
 				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO customer (username, email, password, role) VALUES ($1, $2, $3, $4)")).
+			mock.ExpectQuery(regexp.QuoteMeta(AddCustomer)).
 				WithArgs(user.Username, user.Email, user.Password, user.Role).
 				WillReturnError(fmt.Errorf("database error"))
+			mock.ExpectRollback()
 
-			err := repo.CreateUser(user)
+			err := repo.CreateUser(context.Background(), user)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to create user"))
 		})
@@ -222,21 +451,41 @@ var _ = Describe("User Repository", func() {
 		It("should delete user successfully", func() {
 			userID := "123"
 
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM customer WHERE id = $1")).
-				WithArgs(userID).
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteUser)).
+				WithArgs(userID, sqlmock.AnyArg(), int64(0)).
 				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(RevokeUserSessions)).
+				WithArgs(userID).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			expectEvents(mock, models.EventUser, models.EventDeleted, userID)
+			mock.ExpectCommit()
 
-			err := repo.DeleteUser(userID)
+			err := repo.DeleteUser(context.Background(), userID, 0)
 			Expect(err).ShouldNot(HaveOccurred())
 		})
+		It("should return ErrUserNotFound when no live user matches", func() {
+			userID := "123"
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteUser)).
+				WithArgs(userID, sqlmock.AnyArg(), int64(0)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.DeleteUser(context.Background(), userID, 0)
+			Expect(errors.Is(err, ErrUserNotFound)).Should(BeTrue())
+		})
 		It("should return error on database failure", func() {
 			userID := "123"
 
-			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM customer WHERE id = $1")).
-				WithArgs(userID).
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DeleteUser)).
+				WithArgs(userID, sqlmock.AnyArg(), int64(0)).
 				WillReturnError(fmt.Errorf("database error"))
+			mock.ExpectRollback()
 
-			err := repo.DeleteUser(userID)
+			err := repo.DeleteUser(context.Background(), userID, 0)
 			Expect(err).Should(HaveOccurred())
 			Expect(err.Error()).Should(ContainSubstring("failed to delete user"))
 		})
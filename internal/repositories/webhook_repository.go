@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, subscription *models.WebhookSubscription) error
+	ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	DisableWebhook(ctx context.Context, id string) error
+	DispatchEvents(ctx context.Context, limit int) (int64, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int) error
+	RetryDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int, nextAttemptAt time.Time, message string) error
+	FailDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int, message string) error
+	DeleteDispatchedEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Webhook struct {
+	db database.Database
+}
+
+func NewWebhook(db database.Database) WebhookRepository {
+	return &Webhook{db: db}
+}
+
+// CreateWebhook registers subscription and fills in the columns the
+// database sets.
+func (w *Webhook) CreateWebhook(ctx context.Context, subscription *models.WebhookSubscription) error {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	var createdBy sql.NullString
+	if subscription.CreatedBy != "" {
+		createdBy = sql.NullString{String: subscription.CreatedBy, Valid: true}
+	}
+
+	err := w.db.QueryRowContext(ctx, CreateWebhook, subscription.URL, subscription.Secret, pq.Array(eventTypes), createdBy).
+		Scan(&subscription.ID, &subscription.Active, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	subscription.EventTypes = eventTypes
+
+	return nil
+}
+
+// ListWebhooks returns every subscription, disabled ones included, without
+// their secrets.
+func (w *Webhook) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := w.db.QueryContext(ctx, ListWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+
+	for rows.Next() {
+		var subscription models.WebhookSubscription
+
+		err = rows.Scan(&subscription.ID, &subscription.URL, pq.Array(&subscription.EventTypes), &subscription.Active,
+			&subscription.CreatedAt, &subscription.UpdatedAt, &subscription.DisabledAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list webhooks: %w", err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetWebhook returns the subscription with its secret.
+func (w *Webhook) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+
+	err := w.db.QueryRowContext(ctx, GetWebhook, id).
+		Scan(&subscription.ID, &subscription.URL, &subscription.Secret, pq.Array(&subscription.EventTypes), &subscription.Active,
+			&subscription.CreatedAt, &subscription.UpdatedAt, &subscription.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// DisableWebhook stops the subscription from receiving events and cancels
+// its pending deliveries. Disabling a disabled subscription does nothing.
+func (w *Webhook) DisableWebhook(ctx context.Context, id string) error {
+	return database.WithTx(ctx, w.db, func(tx database.Querier) error {
+		result, err := tx.ExecContext(ctx, DisableWebhook, id)
+		if err != nil {
+			return fmt.Errorf("failed to disable webhook: %w", err)
+		}
+
+		err = requireAffected(result, ErrWebhookNotFound)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, CancelWebhookDeliveries, id)
+		if err != nil {
+			return fmt.Errorf("failed to cancel webhook deliveries: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// DispatchEvents fans up to limit outbox events out to a delivery per
+// matching active subscription and returns the number of deliveries added.
+func (w *Webhook) DispatchEvents(ctx context.Context, limit int) (int64, error) {
+	result, err := w.db.ExecContext(ctx, DispatchEvents, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dispatch events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ClaimDeliveries returns up to limit due deliveries and holds them for
+// lease. A delivery that is neither completed, retried nor failed within
+// the lease is claimed again, its dispatcher is presumed dead.
+func (w *Webhook) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := w.db.QueryContext(ctx, ClaimWebhookDeliveries, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+
+	for rows.Next() {
+		var (
+			delivery   models.WebhookDelivery
+			resourceID sql.NullString
+		)
+
+		err = rows.Scan(&delivery.ID, &delivery.Attempts, &delivery.SubscriptionID, &delivery.URL, &delivery.Secret,
+			&delivery.Event.ID, &delivery.Event.Type, &resourceID, &delivery.Event.OccurredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+
+		delivery.Event.ResourceID = resourceID.String
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CompleteDelivery records that the endpoint accepted the delivery.
+func (w *Webhook) CompleteDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int) error {
+	result, err := w.db.ExecContext(ctx, CompleteWebhookDelivery, delivery.ID, delivery.Attempts, responseStatus(status))
+	if err != nil {
+		return fmt.Errorf("failed to complete webhook delivery: %w", err)
+	}
+
+	return requireAffected(result, ErrDeliveryLost)
+}
+
+// RetryDelivery records a failed attempt and makes the delivery due again
+// at nextAttemptAt.
+func (w *Webhook) RetryDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int, nextAttemptAt time.Time, message string) error {
+	result, err := w.db.ExecContext(ctx, RetryWebhookDelivery, delivery.ID, delivery.Attempts, responseStatus(status), message, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+
+	return requireAffected(result, ErrDeliveryLost)
+}
+
+// FailDelivery dead-letters a delivery. It is kept with its last error and
+// never attempted again.
+func (w *Webhook) FailDelivery(ctx context.Context, delivery *models.WebhookDelivery, status int, message string) error {
+	result, err := w.db.ExecContext(ctx, FailWebhookDelivery, delivery.ID, delivery.Attempts, responseStatus(status), message)
+	if err != nil {
+		return fmt.Errorf("failed to fail webhook delivery: %w", err)
+	}
+
+	return requireAffected(result, ErrDeliveryLost)
+}
+
+// responseStatus stores no status for an attempt that got no response.
+func responseStatus(status int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(status), Valid: status != 0}
+}
+
+// DeleteDispatchedEvents deletes the events dispatched before before, with
+// their deliveries, and returns how many there were. Events with a pending
+// delivery are kept until it is done.
+func (w *Webhook) DeleteDispatchedEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := w.db.ExecContext(ctx, DeleteDispatchedEvents, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dispatched events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"awesomeProject/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook Repository", func() {
+	var (
+		db       *sql.DB
+		mock     sqlmock.Sqlmock
+		repo     WebhookRepository
+		delivery *models.WebhookDelivery
+		err      error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		repo = NewWebhook(db)
+		delivery = &models.WebhookDelivery{ID: "9", Attempts: 3}
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("CreateWebhook", func() {
+		It("should subscribe to every event type without a list", func() {
+			now := time.Now()
+			subscription := &models.WebhookSubscription{URL: "https://example.com/hook", Secret: "whsec_1", CreatedBy: "1"}
+
+			mock.ExpectQuery(regexp.QuoteMeta(CreateWebhook)).
+				WithArgs("https://example.com/hook", "whsec_1", "{}", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "active", "created_at", "updated_at"}).
+					AddRow("4", true, now, now))
+
+			err = repo.CreateWebhook(context.Background(), subscription)
+			Expect(err).Should(BeNil())
+			Expect(subscription.ID).Should(Equal("4"))
+			Expect(subscription.Active).Should(BeTrue())
+			Expect(subscription.EventTypes).Should(BeEmpty())
+		})
+	})
+
+	Describe("ListWebhooks", func() {
+		It("should return the subscriptions with their event types", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(ListWebhooks)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types", "active", "created_at", "updated_at", "disabled_at"}).
+					AddRow("4", "https://example.com/hook", "{product.created,product.deleted}", true, now, now, nil).
+					AddRow("5", "https://example.com/old", "{}", false, now, now, now))
+
+			subscriptions, err := repo.ListWebhooks(context.Background())
+			Expect(err).Should(BeNil())
+			Expect(subscriptions).Should(HaveLen(2))
+			Expect(subscriptions[0].EventTypes).Should(Equal([]string{"product.created", "product.deleted"}))
+			Expect(subscriptions[0].Secret).Should(BeEmpty())
+			Expect(subscriptions[1].DisabledAt).ShouldNot(BeNil())
+		})
+	})
+
+	Describe("GetWebhook", func() {
+		It("should return ErrWebhookNotFound for an unknown subscription", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(GetWebhook)).
+				WithArgs("4").
+				WillReturnError(sql.ErrNoRows)
+
+			_, err = repo.GetWebhook(context.Background(), "4")
+			Expect(errors.Is(err, ErrWebhookNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("DisableWebhook", func() {
+		It("should cancel the pending deliveries of the subscription", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DisableWebhook)).
+				WithArgs("4").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(CancelWebhookDeliveries)).
+				WithArgs("4").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectCommit()
+
+			err = repo.DisableWebhook(context.Background(), "4")
+			Expect(err).Should(BeNil())
+		})
+		It("should return ErrWebhookNotFound for an unknown subscription", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(DisableWebhook)).
+				WithArgs("4").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err = repo.DisableWebhook(context.Background(), "4")
+			Expect(errors.Is(err, ErrWebhookNotFound)).Should(BeTrue())
+		})
+	})
+
+	Describe("DispatchEvents", func() {
+		It("should return the number of deliveries added", func() {
+			mock.ExpectExec(regexp.QuoteMeta(DispatchEvents)).
+				WithArgs(100).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+
+			added, err := repo.DispatchEvents(context.Background(), 100)
+			Expect(err).Should(BeNil())
+			Expect(added).Should(Equal(int64(3)))
+		})
+	})
+
+	Describe("DeleteDispatchedEvents", func() {
+		It("should return the number of events deleted", func() {
+			before := time.Now().Add(-7 * 24 * time.Hour)
+
+			mock.ExpectExec(regexp.QuoteMeta(DeleteDispatchedEvents)).
+				WithArgs(before).
+				WillReturnResult(sqlmock.NewResult(0, 12))
+
+			deleted, err := repo.DeleteDispatchedEvents(context.Background(), before)
+			Expect(err).Should(BeNil())
+			Expect(deleted).Should(Equal(int64(12)))
+		})
+	})
+
+	Describe("ClaimDeliveries", func() {
+		It("should return the claimed deliveries with their events", func() {
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(ClaimWebhookDeliveries)).
+				WithArgs(10, float64(30)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "attempts", "subscription_id", "url", "secret",
+					"event_id", "type", "resource_id", "created_at"}).
+					AddRow("9", 1, "4", "https://example.com/hook", "whsec_1", "12", "product.created", "7", now))
+
+			deliveries, err := repo.ClaimDeliveries(context.Background(), 10, 30*time.Second)
+			Expect(err).Should(BeNil())
+			Expect(deliveries).Should(HaveLen(1))
+			Expect(deliveries[0].Secret).Should(Equal("whsec_1"))
+			Expect(deliveries[0].Event).Should(Equal(models.Event{ID: "12", Type: "product.created", ResourceID: "7", OccurredAt: now}))
+		})
+	})
+
+	Describe("CompleteDelivery", func() {
+		It("should return ErrDeliveryLost once another dispatcher claimed the delivery", func() {
+			mock.ExpectExec(regexp.QuoteMeta(CompleteWebhookDelivery)).
+				WithArgs("9", 3, int64(204)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err = repo.CompleteDelivery(context.Background(), delivery, 204)
+			Expect(errors.Is(err, ErrDeliveryLost)).Should(BeTrue())
+		})
+	})
+
+	Describe("RetryDelivery", func() {
+		It("should store no status when the endpoint did not answer", func() {
+			nextAttemptAt := time.Now().Add(time.Minute)
+
+			mock.ExpectExec(regexp.QuoteMeta(RetryWebhookDelivery)).
+				WithArgs("9", 3, nil, "connection refused", nextAttemptAt).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = repo.RetryDelivery(context.Background(), delivery, 0, nextAttemptAt, "connection refused")
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Describe("FailDelivery", func() {
+		It("should dead-letter the delivery", func() {
+			mock.ExpectExec(regexp.QuoteMeta(FailWebhookDelivery)).
+				WithArgs("9", 3, int64(500), "endpoint answered 500").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = repo.FailDelivery(context.Background(), delivery, 500, "endpoint answered 500")
+			Expect(err).Should(BeNil())
+		})
+	})
+})
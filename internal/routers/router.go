@@ -2,73 +2,235 @@ package routers
 
 import (
 	"awesomeProject/internal/handlers"
-	"awesomeProject/internal/middlewares/authentication"
+	"awesomeProject/internal/metrics"
+	"awesomeProject/internal/middlewares/authorization"
 	"awesomeProject/internal/middlewares/logging"
 	"awesomeProject/internal/middlewares/middleware"
+	"awesomeProject/internal/middlewares/ratelimit"
+	"awesomeProject/internal/tracing"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(users handlers.Userer, categories handlers.Categorer, products handlers.Producter, auth handlers.Auther) *mux.Router {
+func NewRouter(users handlers.Userer, categories handlers.Categorer, products handlers.Producter, trash handlers.Trasher, jobs handlers.Jober, webhooks handlers.Webhooker, health handlers.Healther,
+	auth handlers.Auther, sessions handlers.Sessioner, jwks handlers.JWKSer, isAuthenticated middleware.Middleware, authorizer *authorization.Authorizer, observer *metrics.Metrics, logger *logging.Logger, limiter *ratelimit.Limiter) *mux.Router {
 	router := mux.NewRouter()
 
-	middlewares := []middleware.Middleware{
-		logging.LoggingMiddleware,
-		authentication.IsAuthenticated,
+	// The logger comes first in every chain, so that every request is logged
+	// with its request ID, including those refused by a later middleware.
+	public := []middleware.Middleware{
+		logger.Middleware,
+		tracing.Middleware,
+		observer.Middleware,
 	}
 
+	// Credentials are guessed against these routes, so they are limited by
+	// client before the handlers run.
+	credentials := []middleware.Middleware{
+		logger.Middleware,
+		tracing.Middleware,
+		observer.Middleware,
+		limiter.Group("auth"),
+	}
+
+	// Every route group can have a rate limit of its own, see
+	// configs.RateLimitGroups.
+	authenticated := func(group string) []middleware.Middleware {
+		return []middleware.Middleware{
+			logger.Middleware,
+			tracing.Middleware,
+			observer.Middleware,
+			isAuthenticated,
+			limiter.Group(group),
+		}
+	}
+
+	sessionRoutes := authenticated("sessions")
+	userRoutes := authenticated("users")
+	categoryRoutes := authenticated("categories")
+	productRoutes := authenticated("products")
+	trashRoutes := authenticated("trash")
+	jobRoutes := authenticated("jobs")
+	webhookRoutes := authenticated("webhooks")
+
+	router.HandleFunc("/healthz", middleware.ChainMiddleware(health.LivenessHandler, public...)).Methods("GET")
+	router.HandleFunc("/readyz", middleware.ChainMiddleware(health.ReadinessHandler, public...)).Methods("GET")
+	router.HandleFunc("/.well-known/jwks.json", middleware.ChainMiddleware(jwks.GetJWKS, public...)).Methods("GET")
+
 	r := router.PathPrefix("/api/v1").Subrouter()
 
-	r.HandleFunc("/signup", auth.Register).Methods("POST")
-	r.HandleFunc("/login", auth.Login).Methods("POST")
-	r.HandleFunc("/logout", auth.Logout).Methods("POST")
+	r.HandleFunc("/signup", middleware.ChainMiddleware(auth.Register, credentials...)).Methods("POST")
+	r.HandleFunc("/login", middleware.ChainMiddleware(auth.Login, credentials...)).Methods("POST")
+	r.HandleFunc("/logout", middleware.ChainMiddleware(auth.Logout, credentials...)).Methods("POST")
+	r.HandleFunc("/token/refresh", middleware.ChainMiddleware(auth.RefreshToken, credentials...)).Methods("POST")
+
+	r.HandleFunc("/sessions", middleware.ChainMiddleware(
+		sessions.GetSessions,
+		sessionRoutes...,
+	)).Methods("GET")
+	r.HandleFunc("/sessions/{session_id}", middleware.ChainMiddleware(
+		sessions.RevokeSession,
+		sessionRoutes...,
+	)).Methods("DELETE")
 
 	r.HandleFunc("/users/{username}", middleware.ChainMiddleware(
 		users.GetUserByUsername,
-		middlewares...,
+		withPermission(userRoutes, authorizer, authorization.UsersRead)...,
 	)).Methods("GET")
 	r.HandleFunc("/users", middleware.ChainMiddleware(
 		users.GetAllUsers,
-		middlewares...,
+		withPermission(userRoutes, authorizer, authorization.UsersRead)...,
 	)).Methods("GET")
 	r.HandleFunc("/users/{user_id}", middleware.ChainMiddleware(
 		users.UpdateUser,
-		middlewares...,
+		userRoutes...,
 	)).Methods("PUT")
+	r.HandleFunc("/users/{user_id}/role", middleware.ChainMiddleware(
+		users.UpdateUserRole,
+		withPermission(userRoutes, authorizer, authorization.UsersWrite)...,
+	)).Methods("PUT")
+	r.HandleFunc("/users/{user_id}", middleware.ChainMiddleware(
+		users.PatchUser,
+		userRoutes...,
+	)).Methods("PATCH")
 	r.HandleFunc("/users", middleware.ChainMiddleware(
 		users.CreateUser,
-		middlewares...,
+		withPermission(userRoutes, authorizer, authorization.UsersWrite)...,
 	)).Methods("POST")
 	r.HandleFunc("/users/{user_id}", middleware.ChainMiddleware(
 		users.DeleteUser,
-		middlewares...,
+		withPermission(userRoutes, authorizer, authorization.UsersDelete)...,
 	)).Methods("DELETE")
 
 	r.HandleFunc("/categories", middleware.ChainMiddleware(
 		categories.CreateCategoryHandler,
-		middlewares...,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...,
 	)).Methods("POST")
+	r.HandleFunc("/categories", middleware.ChainMiddleware(
+		categories.ListCategoriesHandler,
+		categoryRoutes...)).Methods("GET")
+	r.HandleFunc("/categories/bulk", middleware.ChainMiddleware(
+		categories.CreateCategoriesHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("POST")
+	r.HandleFunc("/categories/bulk", middleware.ChainMiddleware(
+		categories.UpdateCategoriesHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("PUT")
+	r.HandleFunc("/categories/bulk/delete", middleware.ChainMiddleware(
+		categories.DeleteCategoriesHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesDelete)...)).Methods("POST")
+	r.HandleFunc("/categories/export", middleware.ChainMiddleware(
+		categories.ExportCategoriesHandler,
+		categoryRoutes...)).Methods("GET")
+	r.HandleFunc("/categories/import", middleware.ChainMiddleware(
+		categories.ImportCategoriesHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("POST")
+	r.HandleFunc("/categories/tree", middleware.ChainMiddleware(
+		categories.GetCategoryTreeHandler,
+		categoryRoutes...)).Methods("GET")
 	r.HandleFunc("/categories/{category_id}", middleware.ChainMiddleware(
 		categories.GetCategoryHandler,
-		middlewares...)).Methods("GET")
+		categoryRoutes...)).Methods("GET")
 	r.HandleFunc("/categories/{category_id}", middleware.ChainMiddleware(
 		categories.UpdateCategoryHandler,
-		middlewares...)).Methods("PUT")
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("PUT")
+	r.HandleFunc("/categories/{category_id}", middleware.ChainMiddleware(
+		categories.PatchCategoryHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("PATCH")
 	r.HandleFunc("/categories/{category_id}", middleware.ChainMiddleware(
 		categories.DeleteCategoryHandler,
-		middlewares...)).Methods("DELETE")
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesDelete)...)).Methods("DELETE")
+	r.HandleFunc("/categories/{category_id}/tree", middleware.ChainMiddleware(
+		categories.GetCategorySubtreeHandler,
+		categoryRoutes...)).Methods("GET")
+	r.HandleFunc("/categories/{category_id}/breadcrumb", middleware.ChainMiddleware(
+		categories.GetCategoryBreadcrumbHandler,
+		categoryRoutes...)).Methods("GET")
+	r.HandleFunc("/categories/{category_id}/parent", middleware.ChainMiddleware(
+		categories.MoveCategoryHandler,
+		withPermission(categoryRoutes, authorizer, authorization.CategoriesWrite)...)).Methods("PUT")
+	r.HandleFunc("/categories/{category_id}/products", middleware.ChainMiddleware(
+		products.ListCategoryProductsHandler,
+		categoryRoutes...)).Methods("GET")
 
 	r.HandleFunc("/products", middleware.ChainMiddleware(
 		products.CreateProductHandler,
-		middlewares...)).Methods("POST")
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("POST")
+	r.HandleFunc("/products", middleware.ChainMiddleware(
+		products.ListProductsHandler,
+		productRoutes...)).Methods("GET")
+	r.HandleFunc("/products/bulk", middleware.ChainMiddleware(
+		products.CreateProductsHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("POST")
+	r.HandleFunc("/products/bulk", middleware.ChainMiddleware(
+		products.UpdateProductsHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("PUT")
+	r.HandleFunc("/products/bulk/delete", middleware.ChainMiddleware(
+		products.DeleteProductsHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsDelete)...)).Methods("POST")
+	r.HandleFunc("/products/export", middleware.ChainMiddleware(
+		products.ExportProductsHandler,
+		productRoutes...)).Methods("GET")
+	r.HandleFunc("/products/import", middleware.ChainMiddleware(
+		products.ImportProductsHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("POST")
+	r.HandleFunc("/products/search", middleware.ChainMiddleware(
+		products.SearchProductsHandler,
+		productRoutes...)).Methods("GET")
 	r.HandleFunc("/products/{product_id}", middleware.ChainMiddleware(
 		products.GetProductHandler,
-		middlewares...)).Methods("GET")
+		productRoutes...)).Methods("GET")
 	r.HandleFunc("/products/{product_id}", middleware.ChainMiddleware(
 		products.UpdateProductHandler,
-		middlewares...)).Methods("PUT")
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("PUT")
+	r.HandleFunc("/products/{product_id}", middleware.ChainMiddleware(
+		products.PatchProductHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("PATCH")
 	r.HandleFunc("/products/{product_id}", middleware.ChainMiddleware(
 		products.DeleteProductHandler,
-		middlewares...)).Methods("DELETE")
+		withPermission(productRoutes, authorizer, authorization.ProductsDelete)...)).Methods("DELETE")
+	r.HandleFunc("/products/{product_id}/categories", middleware.ChainMiddleware(
+		products.SetProductCategoriesHandler,
+		withPermission(productRoutes, authorizer, authorization.ProductsWrite)...)).Methods("PUT")
+
+	r.HandleFunc("/trash/{kind:products|categories|users}", middleware.ChainMiddleware(
+		trash.ListTrashHandler,
+		withPermission(trashRoutes, authorizer, authorization.TrashManage)...)).Methods("GET")
+	r.HandleFunc("/trash/{kind:products|categories|users}/{id}/restore", middleware.ChainMiddleware(
+		trash.RestoreTrashHandler,
+		withPermission(trashRoutes, authorizer, authorization.TrashManage)...)).Methods("POST")
+	r.HandleFunc("/trash/{kind:products|categories|users}/{id}", middleware.ChainMiddleware(
+		trash.PurgeTrashHandler,
+		withPermission(trashRoutes, authorizer, authorization.TrashManage)...)).Methods("DELETE")
+
+	// Jobs check the permission of their kind when they are queued.
+	r.HandleFunc("/jobs", middleware.ChainMiddleware(
+		jobs.CreateJobHandler,
+		jobRoutes...)).Methods("POST")
+	r.HandleFunc("/jobs/{job_id}", middleware.ChainMiddleware(
+		jobs.GetJobHandler,
+		jobRoutes...)).Methods("GET")
+	r.HandleFunc("/jobs/{job_id}/file", middleware.ChainMiddleware(
+		jobs.GetJobFileHandler,
+		jobRoutes...)).Methods("GET")
+
+	r.HandleFunc("/webhooks", middleware.ChainMiddleware(
+		webhooks.CreateWebhookHandler,
+		withPermission(webhookRoutes, authorizer, authorization.WebhooksManage)...)).Methods("POST")
+	r.HandleFunc("/webhooks", middleware.ChainMiddleware(
+		webhooks.ListWebhooksHandler,
+		withPermission(webhookRoutes, authorizer, authorization.WebhooksManage)...)).Methods("GET")
+	r.HandleFunc("/webhooks/{webhook_id}/test", middleware.ChainMiddleware(
+		webhooks.TestWebhookHandler,
+		withPermission(webhookRoutes, authorizer, authorization.WebhooksManage)...)).Methods("POST")
+	r.HandleFunc("/webhooks/{webhook_id}/disable", middleware.ChainMiddleware(
+		webhooks.DisableWebhookHandler,
+		withPermission(webhookRoutes, authorizer, authorization.WebhooksManage)...)).Methods("POST")
+
+	return router
+}
+
+func withPermission(middlewares []middleware.Middleware, authorizer *authorization.Authorizer, permission string) []middleware.Middleware {
+	guarded := make([]middleware.Middleware, 0, len(middlewares)+1)
+	guarded = append(guarded, middlewares...)
 
-	return r
+	return append(guarded, authorizer.RequirePermission(permission))
 }
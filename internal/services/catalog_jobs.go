@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"awesomeProject/internal/middlewares/authorization"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/internal/transfer"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+)
+
+// Catalog job kinds run the imports and exports of the catalog endpoints in
+// the background, for files too large to wait for.
+const (
+	JobImportProducts   = "products.import"
+	JobExportProducts   = "products.export"
+	JobImportCategories = "categories.import"
+	JobExportCategories = "categories.export"
+)
+
+var errNoImportData = apperrors.Validation(validation.CodeValidationFailed, "job payload is not valid",
+	map[string][]string{"data": {"is required"}})
+
+// RegisterCatalogJobs registers the catalog job kinds with queue. Uploads
+// and exports are stored in files.
+func RegisterCatalogJobs(queue *JobQueue, products repositories.ProductRepository, categories repositories.Categorer, files *JobFiles) {
+	storeUpload := func(_ context.Context, payload *models.ImportJobPayload) error {
+		return storeImportData(payload, files)
+	}
+
+	queue.Register(JobImportProducts, WithPrepare(NewJobKind(authorization.ProductsWrite,
+		func(ctx context.Context, payload *models.ImportJobPayload, progress func(int)) (interface{}, error) {
+			return importJob(ctx, payload, files, progress, transfer.ProductColumns, transfer.ProductFromRecord, products.ImportProducts)
+		}), storeUpload))
+	queue.Register(JobExportProducts, NewJobKind("",
+		func(ctx context.Context, payload *models.ExportJobPayload, _ func(int)) (interface{}, error) {
+			return exportJob(ctx, payload, files, transfer.ProductColumns, transfer.ProductRecord, products.ExportProducts)
+		}))
+	queue.Register(JobImportCategories, WithPrepare(NewJobKind(authorization.CategoriesWrite,
+		func(ctx context.Context, payload *models.ImportJobPayload, progress func(int)) (interface{}, error) {
+			return importJob(ctx, payload, files, progress, transfer.CategoryColumns, transfer.CategoryFromRecord, categories.ImportCategories)
+		}), storeUpload))
+	queue.Register(JobExportCategories, NewJobKind("",
+		func(ctx context.Context, payload *models.ExportJobPayload, _ func(int)) (interface{}, error) {
+			return exportJob(ctx, payload, files, transfer.CategoryColumns, transfer.CategoryRecord, categories.ExportCategories)
+		}))
+}
+
+// storeImportData moves the inline file of payload to files, so that the
+// stored payload stays small. A file named by the request is never read.
+func storeImportData(payload *models.ImportJobPayload, files *JobFiles) error {
+	if payload.Data == "" {
+		return errNoImportData
+	}
+
+	name, _, err := files.Write(func(w io.Writer) error {
+		_, err := io.WriteString(w, payload.Data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	payload.Data = ""
+	payload.File = name
+
+	return nil
+}
+
+// importJob reads the file of payload and writes its rows. The result is
+// the same as the one of the import endpoints. Jobs queued before uploads
+// were stored in files still carry theirs inline.
+func importJob[T any](ctx context.Context, payload *models.ImportJobPayload, files *JobFiles, progress func(int), columns transfer.Columns,
+	fromRecord func(map[string]string) (T, error), write func(context.Context, []T, *models.BulkResult) error) (interface{}, error) {
+	format, err := transfer.ParseFormat(payload.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var upload io.Reader = strings.NewReader(payload.Data)
+
+	if payload.File != "" {
+		file, err := files.Open(payload.File)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		upload = file
+	}
+
+	rows, err := transfer.Read(upload, format, columns, fromRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	items, result, err := transfer.Batch(rows, payload.Mode, payload.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	progress(50)
+
+	err = write(ctx, items, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// exportJob writes every row read by read to a file in files. The result
+// references the file, which is downloaded separately.
+func exportJob[T any](ctx context.Context, payload *models.ExportJobPayload, files *JobFiles, columns transfer.Columns,
+	record func(T) []string, read func(context.Context, func(T) error) error) (interface{}, error) {
+	format, err := transfer.ParseFormat(payload.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows int
+
+	name, size, err := files.Write(func(w io.Writer) error {
+		writer, err := transfer.NewWriter(w, format, columns)
+		if err != nil {
+			return err
+		}
+
+		err = read(ctx, func(item T) error {
+			return writer.Write(item, record(item))
+		})
+		if err != nil {
+			return err
+		}
+
+		rows = writer.Rows()
+
+		return writer.Flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ExportJobResult{
+		Format:      format,
+		ContentType: transfer.ContentType(format),
+		Rows:        rows,
+		Size:        size,
+		File:        name,
+	}, nil
+}
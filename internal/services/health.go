@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/database"
+)
+
+var errWorkersStopped = errors.New("workers are not running")
+
+// ReadinessChecker tells whether the instance can serve traffic.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) models.HealthReport
+}
+
+// Pinger checks that the database accepts connections.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// MigrationLister returns the migrations not applied yet.
+type MigrationLister interface {
+	Pending(ctx context.Context) ([]database.Migration, error)
+}
+
+// WorkerPool reports the status of background workers.
+type WorkerPool interface {
+	Status() models.WorkerStatus
+}
+
+// Health checks the dependencies of the instance for the readiness endpoint.
+// Once Drain is called the instance reports not ready for good, so that load
+// balancers stop sending it requests before the server shuts down.
+type Health struct {
+	db         Pinger
+	migrations MigrationLister
+	workers    map[string]WorkerPool
+	timeout    time.Duration
+	draining   atomic.Bool
+}
+
+// NewHealth checks db and migrations within timeout each. workers are
+// reported by name.
+func NewHealth(db Pinger, migrations MigrationLister, workers map[string]WorkerPool, timeout time.Duration) *Health {
+	return &Health{
+		db:         db,
+		migrations: migrations,
+		workers:    workers,
+		timeout:    timeout,
+	}
+}
+
+// Drain makes the instance report not ready from now on.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs every check. A draining instance reports so without running
+// them.
+func (h *Health) Ready(ctx context.Context) models.HealthReport {
+	if h.draining.Load() {
+		return models.HealthReport{Status: models.HealthDraining}
+	}
+
+	report := models.HealthReport{
+		Status: models.HealthOK,
+		Checks: map[string]models.HealthCheck{
+			"database":   h.check(ctx, h.checkDatabase),
+			"migrations": h.check(ctx, h.checkMigrations),
+		},
+	}
+
+	names := make([]string, 0, len(h.workers))
+	for name := range h.workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		report.Checks[name] = h.check(ctx, func(context.Context) (interface{}, error) {
+			return checkWorkers(h.workers[name])
+		})
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != models.HealthOK {
+			report.Status = models.HealthUnavailable
+		}
+	}
+
+	return report
+}
+
+// check runs fn within the timeout and records how it went.
+func (h *Health) check(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	started := time.Now()
+	details, err := fn(ctx)
+
+	check := models.HealthCheck{
+		Status:     models.HealthOK,
+		DurationMS: float64(time.Since(started).Microseconds()) / 1000,
+		Details:    details,
+	}
+
+	if err != nil {
+		check.Status = models.HealthFailing
+		check.Error = err.Error()
+	}
+
+	return check
+}
+
+func (h *Health) checkDatabase(ctx context.Context) (interface{}, error) {
+	return nil, h.db.PingContext(ctx)
+}
+
+// checkMigrations fails while migrations are pending, the code of this
+// instance expects the schema they create.
+func (h *Health) checkMigrations(ctx context.Context) (interface{}, error) {
+	pending, err := h.migrations.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, len(pending))
+	for i, migration := range pending {
+		names[i] = fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+	}
+
+	return map[string]interface{}{"pending": names}, fmt.Errorf("%d migrations pending", len(pending))
+}
+
+func checkWorkers(workers WorkerPool) (interface{}, error) {
+	status := workers.Status()
+
+	if status.Running < status.Configured {
+		return status, errWorkersStopped
+	}
+
+	if status.LastError != "" {
+		return status, errors.New(status.LastError)
+	}
+
+	return status, nil
+}
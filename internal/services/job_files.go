@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrJobFileNotFound = apperrors.NotFound("job_file_expired", "job file not found, files are deleted after the retention period")
+
+// JobFiles keeps the files of jobs in a directory, the uploads of import
+// jobs and the results of export jobs, so that jobs only reference them.
+// With several instances the directory must be shared, a file is written by
+// any instance and read from any other.
+type JobFiles struct {
+	dir string
+}
+
+func NewJobFiles(dir string) *JobFiles {
+	return &JobFiles{dir: dir}
+}
+
+// Write stores the file written by write under a new name and returns the
+// name and the size of the file. The file only appears once it is complete.
+func (f *JobFiles) Write(write func(w io.Writer) error) (string, int64, error) {
+	err := os.MkdirAll(f.dir, 0o750)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create job file directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(f.dir, ".job-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create job file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	buffered := bufio.NewWriter(file)
+
+	err = write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		return "", 0, err
+	}
+
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat job file: %w", err)
+	}
+
+	name := uuid.NewString()
+
+	err = os.Rename(file.Name(), filepath.Join(f.dir, name))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store job file: %w", err)
+	}
+
+	return name, info.Size(), nil
+}
+
+// Open opens the file stored as name.
+func (f *JobFiles) Open(name string) (*os.File, error) {
+	// Names are generated by Write, anything else could point outside the
+	// directory.
+	if _, err := uuid.Parse(name); err != nil {
+		return nil, ErrJobFileNotFound
+	}
+
+	file, err := os.Open(filepath.Join(f.dir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrJobFileNotFound.Wrap(err)
+		}
+		return nil, fmt.Errorf("failed to open job file: %w", err)
+	}
+
+	return file, nil
+}
+
+// Sweep deletes the files last written before cutoff, including the partial
+// ones of writes that never completed, and returns how many it deleted.
+func (f *JobFiles) Sweep(cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to list job files: %w", err)
+	}
+
+	var (
+		deleted int
+		errs    []error
+	)
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// Deleted by another instance since it was listed.
+			if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(f.dir, entry.Name()))
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		deleted++
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// JobFileSweeper periodically deletes job files older than the retention
+// period.
+type JobFileSweeper struct {
+	files     *JobFiles
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewJobFileSweeper(files *JobFiles, retention, interval time.Duration) *JobFileSweeper {
+	return &JobFileSweeper{
+		files:     files,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run sweeps once immediately and then on every interval until ctx is done.
+// A failed sweep is logged and retried on the next tick.
+func (s *JobFileSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *JobFileSweeper) sweep() {
+	deleted, err := s.files.Sweep(time.Now().Add(-s.retention))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to sweep job files")
+	}
+
+	if deleted > 0 {
+		logrus.WithField("deleted", deleted).Info("Deleted expired job files")
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+
+	"github.com/sirupsen/logrus"
+)
+
+// finishTimeout bounds the write that records the outcome of a job. It runs
+// on its own context so that the outcome of a job cancelled by shutdown is
+// still recorded.
+const finishTimeout = 10 * time.Second
+
+var (
+	errUnknownJobKind = apperrors.Validation(validation.CodeValidationFailed, "job request is not valid",
+		map[string][]string{"kind": {"is not a known job kind"}})
+	errInvalidJobPayload = apperrors.BadRequest(apperrors.CodeInvalidBody, "job payload is not valid JSON")
+)
+
+// JobScheduler queues jobs on behalf of a user.
+type JobScheduler interface {
+	Enqueue(ctx context.Context, userID, role string, request models.JobRequest) (*models.Job, error)
+}
+
+// PermissionChecker tells whether a role holds a permission.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+// JobKind describes a kind of job: what it takes to queue one and how it
+// runs.
+type JobKind struct {
+	// Permission is required to queue the kind, none if empty.
+	Permission string
+	decode     func(payload json.RawMessage) (interface{}, error)
+	prepare    func(ctx context.Context, payload interface{}) error
+	run        func(ctx context.Context, payload interface{}, progress func(percent int)) (interface{}, error)
+}
+
+// NewJobKind returns a kind whose payload is decoded into P and checked
+// against its validate tags, both when the job is queued and when it runs.
+// The value run returns is stored as the result of the job.
+//
+// An attempt that fails with an error other than an internal one fails the
+// same way on every retry, so the job is dead-lettered right away.
+func NewJobKind[P any](permission string, run func(ctx context.Context, payload *P, progress func(percent int)) (interface{}, error)) JobKind {
+	return JobKind{
+		Permission: permission,
+		decode: func(raw json.RawMessage) (interface{}, error) {
+			payload := new(P)
+
+			if len(raw) > 0 {
+				err := json.Unmarshal(raw, payload)
+				if err != nil {
+					return nil, errInvalidJobPayload.Wrap(err)
+				}
+			}
+
+			err := validation.Struct(payload)
+			if err != nil {
+				return nil, err
+			}
+
+			return payload, nil
+		},
+		run: func(ctx context.Context, payload interface{}, progress func(percent int)) (interface{}, error) {
+			return run(ctx, payload.(*P), progress)
+		},
+	}
+}
+
+// WithPrepare returns kind with prepare run on the decoded payload when a
+// job is queued. The payload is stored as prepare leaves it, e.g. with a
+// large field moved elsewhere.
+func WithPrepare[P any](kind JobKind, prepare func(ctx context.Context, payload *P) error) JobKind {
+	kind.prepare = func(ctx context.Context, payload interface{}) error {
+		return prepare(ctx, payload.(*P))
+	}
+
+	return kind
+}
+
+// JobQueue runs the jobs queued in the database on a pool of workers. Any
+// number of instances may run workers against the same database.
+type JobQueue struct {
+	jobs        repositories.JobRepository
+	permissions PermissionChecker
+	config      configs.Jobs
+	kinds       map[string]JobKind
+
+	// stop tells the workers to claim no further jobs. ctx is the context
+	// of running jobs, cancelled when Shutdown runs out of time.
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	state workerState
+}
+
+func NewJobQueue(jobs repositories.JobRepository, permissions PermissionChecker, config configs.Jobs) *JobQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &JobQueue{
+		jobs:        jobs,
+		permissions: permissions,
+		config:      config,
+		kinds:       make(map[string]JobKind),
+		stop:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Register adds a kind of job. It must be called before Start.
+func (q *JobQueue) Register(name string, kind JobKind) {
+	q.kinds[name] = kind
+}
+
+// Enqueue checks request and queues it as a job of userID. The role of the
+// user must hold the permission of the kind.
+func (q *JobQueue) Enqueue(ctx context.Context, userID, role string, request models.JobRequest) (*models.Job, error) {
+	kind, ok := q.kinds[request.Kind]
+	if !ok {
+		return nil, errUnknownJobKind
+	}
+
+	if kind.Permission != "" {
+		allowed, err := q.permissions.HasPermission(ctx, role, kind.Permission)
+		if err != nil {
+			return nil, err
+		}
+
+		if !allowed {
+			return nil, apperrors.Forbidden(apperrors.CodeForbidden, "missing permission "+kind.Permission)
+		}
+	}
+
+	payload, err := kind.decode(request.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := request.Payload
+
+	if kind.prepare != nil {
+		err = kind.prepare(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		stored, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+	}
+
+	job := &models.Job{
+		Kind:        request.Kind,
+		Payload:     stored,
+		MaxAttempts: q.config.MaxAttempts,
+		CreatedBy:   userID,
+	}
+
+	err = q.jobs.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Start starts the configured number of workers.
+func (q *JobQueue) Start() {
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Shutdown stops the workers from claiming jobs and waits for the running
+// ones to finish. When ctx is done first, the running jobs are cancelled and
+// queued again, and ctx's error is returned once they have stopped.
+func (q *JobQueue) Shutdown(ctx context.Context) error {
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.cancel()
+	<-done
+
+	return ctx.Err()
+}
+
+// Status reports how many workers run and whether claiming jobs fails.
+func (q *JobQueue) Status() models.WorkerStatus {
+	return q.state.status(q.config.Workers)
+}
+
+// work runs jobs until the queue stops, and polls for new ones whenever none
+// is due.
+func (q *JobQueue) work() {
+	defer q.wg.Done()
+
+	q.state.started()
+	defer q.state.stopped()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		if q.runNext() {
+			continue
+		}
+
+		timer := time.NewTimer(q.config.PollInterval)
+
+		select {
+		case <-q.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// runNext claims and runs one job and reports whether there was one.
+func (q *JobQueue) runNext() bool {
+	job, err := q.jobs.ClaimJob(q.ctx, q.config.Lease)
+	if err != nil {
+		if q.ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to claim job")
+			q.state.polled(err)
+		}
+		return false
+	}
+
+	q.state.polled(nil)
+
+	if job == nil {
+		return false
+	}
+
+	q.run(job)
+
+	return true
+}
+
+func (q *JobQueue) run(job *models.Job) {
+	log := logrus.WithFields(logrus.Fields{"job_id": job.ID, "job_kind": job.Kind, "attempt": job.Attempts})
+
+	// Only a worker that died while running the job can have claimed it
+	// more often than it may run.
+	if job.Attempts > job.MaxAttempts {
+		q.finish(log, job, nil, errors.New("worker stopped while running the job"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+
+	var progress atomic.Int32
+
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		q.heartbeat(ctx, cancel, log, job, &progress)
+	}()
+
+	started := time.Now()
+	result, err := q.execute(ctx, job, func(percent int) {
+		progress.Store(int32(min(max(percent, 0), 100)))
+	})
+
+	cancel()
+	<-heartbeat
+
+	log.WithField("duration", time.Since(started)).Debug("Job attempt finished")
+
+	q.finish(log, job, result, err)
+}
+
+// heartbeat renews the lease of job until ctx is done, recording its
+// progress on the way. When another worker took the job over, the attempt
+// is cancelled.
+func (q *JobQueue) heartbeat(ctx context.Context, cancel context.CancelFunc, log *logrus.Entry, job *models.Job, progress *atomic.Int32) {
+	ticker := time.NewTicker(q.config.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := q.jobs.UpdateJobProgress(ctx, job, int(progress.Load()))
+		if errors.Is(err, repositories.ErrJobLost) {
+			log.Warn("Job was claimed by another worker")
+			cancel()
+			return
+		}
+
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Error("Failed to renew job lease")
+		}
+	}
+}
+
+// execute decodes the payload of job and runs it. A panic fails the attempt
+// instead of the worker.
+func (q *JobQueue) execute(ctx context.Context, job *models.Job, progress func(percent int)) (result interface{}, err error) {
+	kind, ok := q.kinds[job.Kind]
+	if !ok {
+		return nil, apperrors.BadRequest("unknown_job_kind", "unknown job kind "+job.Kind)
+	}
+
+	payload, err := kind.decode(job.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return kind.run(ctx, payload, progress)
+}
+
+// finish records the outcome of an attempt. Failures are retried with
+// backoff until the job runs out of attempts, failures that would only
+// repeat are dead-lettered at once. A job interrupted by shutdown is queued
+// again right away.
+func (q *JobQueue) finish(log *logrus.Entry, job *models.Job, result interface{}, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		var data []byte
+
+		data, err = json.Marshal(result)
+		if err == nil {
+			err = q.jobs.CompleteJob(ctx, job, data)
+		}
+	case q.ctx.Err() != nil:
+		log.Info("Job interrupted by shutdown")
+		err = q.jobs.RetryJob(ctx, job, time.Now(), "interrupted by shutdown")
+	case permanent(err) || job.Attempts >= job.MaxAttempts:
+		log.WithError(err).Error("Job failed, dead-lettering")
+		err = q.jobs.FailJob(ctx, job, jobError(err))
+	default:
+		delay := backoff(q.config.BackoffBase, q.config.BackoffMax, job.Attempts)
+		log.WithError(err).WithField("retry_in", delay).Warn("Job failed, retrying")
+		err = q.jobs.RetryJob(ctx, job, time.Now().Add(delay), jobError(err))
+	}
+
+	if errors.Is(err, repositories.ErrJobLost) {
+		log.Warn("Job was claimed by another worker, dropping its outcome")
+		return
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Failed to record job outcome")
+	}
+}
+
+// backoff returns the delay before the attempt after attempt, doubling from
+// base up to limit.
+func backoff(base, limit time.Duration, attempt int) time.Duration {
+	delay := base
+
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+
+	return min(delay, limit)
+}
+
+// permanent tells whether err would fail every retry the same way, like a
+// payload that does not validate.
+func permanent(err error) bool {
+	var appErr *apperrors.Error
+	return errors.As(err, &appErr) && appErr.Kind != apperrors.KindInternal
+}
+
+// jobError is the message stored with a failed job for its owner to read.
+// Internal errors are only logged.
+func jobError(err error) string {
+	if permanent(err) {
+		return err.Error()
+	}
+
+	return "job failed with an internal error"
+}
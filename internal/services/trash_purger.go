@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"awesomeProject/internal/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TrashPurger periodically deletes rows that have been in the trash for
+// longer than the retention period.
+type TrashPurger struct {
+	trash     repositories.TrashRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(trash repositories.TrashRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		trash:     trash,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+// A failed purge is logged and retried on the next tick.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.trash.PurgeExpired(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to purge trash")
+		}
+		return
+	}
+
+	if purged > 0 {
+		logrus.WithField("purged", purged).Info("Purged expired trash")
+	}
+}
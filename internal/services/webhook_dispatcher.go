@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Headers of a webhook request. The event id stays the same across retries
+// so that receivers can drop duplicates, the delivery id changes with the
+// subscription.
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// eventPurgeInterval is how often the dispatcher deletes old events.
+const eventPurgeInterval = time.Hour
+
+// WebhookTester sends a ping event to a subscription right away.
+type WebhookTester interface {
+	Test(ctx context.Context, subscription *models.WebhookSubscription) models.WebhookTestResult
+}
+
+// WebhookDispatcher delivers the events of the outbox to the webhook
+// subscriptions. Any number of instances may dispatch against the same
+// database.
+type WebhookDispatcher struct {
+	webhooks repositories.WebhookRepository
+	client   *http.Client
+	config   configs.Webhooks
+	state    workerState
+
+	// nextPurge is when Run deletes old events next.
+	nextPurge time.Time
+}
+
+func NewWebhookDispatcher(webhooks repositories.WebhookRepository, config configs.Webhooks) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: config.Timeout},
+		config:   config,
+	}
+}
+
+// SignWebhook returns the signature header of a webhook request with body,
+// sent at timestamp. The signature is the hex HMAC-SHA256 of the timestamp
+// and the body joined by a dot, keyed with the secret of the subscription.
+// Receivers recompute it and reject requests with an old timestamp.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run dispatches on every poll interval until ctx is done. As long as full
+// batches are claimed, the next batch is sent right away.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.state.started()
+	defer d.state.stopped()
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for d.dispatch(ctx) {
+		}
+
+		d.purgeEvents(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status reports whether the dispatcher runs and whether claiming
+// deliveries fails.
+func (d *WebhookDispatcher) Status() models.WorkerStatus {
+	configured := 0
+	if d.config.PollInterval > 0 {
+		configured = 1
+	}
+
+	return d.state.status(configured)
+}
+
+// Test sends a ping event to subscription and reports how its endpoint
+// answered. The ping is not recorded and not retried.
+func (d *WebhookDispatcher) Test(ctx context.Context, subscription *models.WebhookSubscription) models.WebhookTestResult {
+	delivery := &models.WebhookDelivery{
+		ID:             "test",
+		Attempts:       1,
+		SubscriptionID: subscription.ID,
+		URL:            subscription.URL,
+		Secret:         subscription.Secret,
+		Event:          models.Event{ID: randomID(), Type: models.EventPing, OccurredAt: time.Now()},
+	}
+
+	status, err := d.send(ctx, delivery)
+	if err != nil {
+		return models.WebhookTestResult{Status: status, Error: err.Error()}
+	}
+
+	return models.WebhookTestResult{Delivered: true, Status: status}
+}
+
+// dispatch fans new events out to their subscriptions and sends a batch of
+// due deliveries. It reports whether the batch was full.
+func (d *WebhookDispatcher) dispatch(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	_, err := d.webhooks.DispatchEvents(ctx, d.config.BatchSize)
+	if err != nil && ctx.Err() == nil {
+		logrus.WithError(err).Error("Failed to dispatch events")
+	}
+
+	deliveries, err := d.webhooks.ClaimDeliveries(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to claim webhook deliveries")
+			d.state.polled(err)
+		}
+		return false
+	}
+
+	d.state.polled(nil)
+
+	var wg sync.WaitGroup
+
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(&deliveries[i])
+	}
+
+	wg.Wait()
+
+	return len(deliveries) == d.config.BatchSize
+}
+
+// purgeEvents deletes the events older than the retention, at most once
+// per eventPurgeInterval.
+func (d *WebhookDispatcher) purgeEvents(ctx context.Context) {
+	now := time.Now()
+	if d.config.EventRetention <= 0 || now.Before(d.nextPurge) {
+		return
+	}
+
+	d.nextPurge = now.Add(eventPurgeInterval)
+
+	deleted, err := d.webhooks.DeleteDispatchedEvents(ctx, now.Add(-d.config.EventRetention))
+	if err != nil {
+		if ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to delete dispatched events")
+		}
+		return
+	}
+
+	if deleted > 0 {
+		logrus.WithField("deleted", deleted).Info("Deleted dispatched events")
+	}
+}
+
+// deliver sends delivery and records the outcome. Failures are retried with
+// backoff until the delivery runs out of attempts and is dead-lettered.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	log := logrus.WithFields(logrus.Fields{
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+		"event_type":      delivery.Event.Type,
+		"attempt":         delivery.Attempts,
+	})
+
+	status, err := d.send(ctx, delivery)
+
+	// The outcome is recorded on its own context, like the one of a job, so
+	// that a delivery interrupted by shutdown is retried instead of waiting
+	// for its lease.
+	finishCtx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		err = d.webhooks.CompleteDelivery(finishCtx, delivery, status)
+	case delivery.Attempts >= d.config.MaxAttempts:
+		log.WithError(err).Error("Webhook delivery failed, dead-lettering")
+		err = d.webhooks.FailDelivery(finishCtx, delivery, status, err.Error())
+	default:
+		delay := backoff(d.config.BackoffBase, d.config.BackoffMax, delivery.Attempts)
+		log.WithError(err).WithField("retry_in", delay).Warn("Webhook delivery failed, retrying")
+		err = d.webhooks.RetryDelivery(finishCtx, delivery, status, time.Now().Add(delay), err.Error())
+	}
+
+	if errors.Is(err, repositories.ErrDeliveryLost) {
+		log.Warn("Webhook delivery was claimed by another dispatcher, dropping its outcome")
+		return
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Failed to record webhook delivery outcome")
+	}
+}
+
+// send posts the event of delivery to its endpoint and returns the status
+// it answered with, zero when there was no answer. Any status but 2xx is an
+// error.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderWebhookEvent, delivery.Event.Type)
+	request.Header.Set(HeaderWebhookID, delivery.Event.ID)
+	request.Header.Set(HeaderWebhookDelivery, delivery.ID)
+	request.Header.Set(HeaderWebhookSignature, SignWebhook(delivery.Secret, time.Now(), body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	// Reading a bounded part of the body lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("endpoint answered %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// randomID returns a random hex id for events that are not stored.
+func randomID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"awesomeProject/internal/models"
+)
+
+// workerState tracks the workers of a background service for its Status.
+type workerState struct {
+	running atomic.Int32
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+func (s *workerState) started() {
+	s.running.Add(1)
+}
+
+func (s *workerState) stopped() {
+	s.running.Add(-1)
+}
+
+// polled records the outcome of the last poll.
+func (s *workerState) polled(err error) {
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+}
+
+func (s *workerState) status(configured int) models.WorkerStatus {
+	status := models.WorkerStatus{
+		Configured: configured,
+		Running:    int(s.running.Load()),
+	}
+
+	s.mu.Lock()
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	s.mu.Unlock()
+
+	return status
+}
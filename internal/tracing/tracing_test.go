@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"awesomeProject/configs"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing", func() {
+	var recorder *tracetest.SpanRecorder
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		values := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			values[kv.Key] = kv.Value
+		}
+
+		return values
+	}
+
+	BeforeEach(func() {
+		_, err := Setup(context.Background(), configs.DefaultConfig().Tracing)
+		Expect(err).NotTo(HaveOccurred())
+
+		recorder = tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(provider)
+
+		DeferCleanup(func() {
+			otel.SetTracerProvider(previous)
+			Expect(provider.Shutdown(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe("Middleware", func() {
+		It("should continue the trace of the traceparent header", func() {
+			var statementSpan trace.SpanContext
+
+			router := mux.NewRouter()
+			router.HandleFunc("/products/{product_id}", Middleware(func(w http.ResponseWriter, r *http.Request) {
+				_, done := Queries{}.StartQuery(r.Context(), "GetProduct")
+				done(nil)
+
+				statementSpan = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(http.StatusInternalServerError)
+			})).Methods("GET")
+
+			request := httptest.NewRequest("GET", "/products/7", nil)
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+
+			server := spans[1]
+			Expect(server.Name()).To(Equal("GET /products/{product_id}"))
+			Expect(server.SpanKind()).To(Equal(trace.SpanKindServer))
+			Expect(server.SpanContext().TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(server.Parent().SpanID().String()).To(Equal("00f067aa0ba902b7"))
+			Expect(server.Status().Code).To(Equal(codes.Error))
+			Expect(attributes(server)).To(HaveKeyWithValue(attribute.Key("http.response.status_code"), attribute.IntValue(500)))
+
+			Expect(statementSpan.SpanID()).To(Equal(server.SpanContext().SpanID()))
+			Expect(spans[0].Parent().SpanID()).To(Equal(server.SpanContext().SpanID()))
+		})
+	})
+
+	Describe("Queries", func() {
+		It("should name the span after the statement and record its error", func() {
+			_, done := Queries{}.StartQuery(context.Background(), "CheckProductExists")
+			done(errors.New("connection reset"))
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Name()).To(Equal("CheckProductExists"))
+			Expect(spans[0].SpanKind()).To(Equal(trace.SpanKindClient))
+			Expect(attributes(spans[0])).To(HaveKeyWithValue(attribute.Key("db.statement.name"), attribute.StringValue("CheckProductExists")))
+			Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		})
+	})
+
+	Describe("Setup", func() {
+		It("should write the spans of the stdout exporter to the file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "traces.jsonl")
+
+			config := configs.DefaultConfig().Tracing
+			config.Exporter = "stdout"
+			config.File = path
+
+			previous := otel.GetTracerProvider()
+			DeferCleanup(otel.SetTracerProvider, previous)
+
+			shutdown, err := Setup(context.Background(), config)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, span := Start(context.Background(), "decode body")
+			span.End()
+
+			Expect(shutdown(context.Background())).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"Name":"decode body"`))
+			Expect(string(data)).To(ContainSubstring("awesomeProject"))
+		})
+	})
+})
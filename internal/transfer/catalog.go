@@ -0,0 +1,87 @@
+package transfer
+
+import (
+	"strconv"
+	"time"
+
+	"awesomeProject/internal/models"
+)
+
+// ProductColumns are the columns of a products file.
+var ProductColumns = Columns{
+	Export:   []string{"id", "name", "category_ids", "created_at", "updated_at"},
+	Required: []string{"name", "category_ids"},
+	Optional: []string{"id"},
+	Ignored:  []string{"created_at", "updated_at"},
+}
+
+// CategoryColumns are the columns of a categories file. Root categories
+// have an empty parent_id.
+var CategoryColumns = Columns{
+	Export:   []string{"id", "name", "parent_id", "created_at", "updated_at"},
+	Required: []string{"name"},
+	Optional: []string{"id", "parent_id"},
+	Ignored:  []string{"created_at", "updated_at"},
+}
+
+// ProductRecord returns the CSV record of a product.
+func ProductRecord(product models.ProductResponse) []string {
+	return []string{
+		product.ID,
+		product.Name,
+		formatIDs(product.CategoryIDs),
+		formatTime(product.CreatedAt),
+		formatTime(product.UpdatedAt),
+	}
+}
+
+// ProductFromRecord returns the product of a CSV record. category_ids
+// holds the ids joined by IDSeparator.
+func ProductFromRecord(values map[string]string) (models.Product, error) {
+	product := models.Product{ID: values["id"], Name: values["name"]}
+
+	categoryIDs, err := parseIDs(values["category_ids"])
+	if err != nil {
+		return product, invalidField("category_ids", "must be ids separated by "+IDSeparator)
+	}
+
+	product.CategoryIDs = categoryIDs
+
+	return product, nil
+}
+
+// CategoryRecord returns the CSV record of a category.
+func CategoryRecord(category models.CategoryResponse) []string {
+	parentID := ""
+	if category.ParentID != nil {
+		parentID = strconv.FormatInt(*category.ParentID, 10)
+	}
+
+	return []string{
+		category.ID,
+		category.Name,
+		parentID,
+		formatTime(category.CreatedAt),
+		formatTime(category.UpdatedAt),
+	}
+}
+
+// CategoryFromRecord returns the category of a CSV record.
+func CategoryFromRecord(values map[string]string) (models.Category, error) {
+	category := models.Category{ID: values["id"], Name: values["name"]}
+
+	if value := values["parent_id"]; value != "" {
+		parentID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return category, invalidField("parent_id", "must be an id")
+		}
+
+		category.ParentID = &parentID
+	}
+
+	return category, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,372 @@
+// Package transfer reads and writes catalog files as CSV or NDJSON. It is
+// shared by the export and import endpoints and by the jobs that run them in
+// the background.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"awesomeProject/internal/models"
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	CSVContentType    = "text/csv"
+	NDJSONContentType = "application/x-ndjson"
+
+	// IDSeparator joins the ids of a list in a single CSV cell, e.g. "1;2".
+	IDSeparator = ";"
+	// MaxRows bounds the rows of one import.
+	MaxRows = 10000
+	// maxLine bounds a single NDJSON line.
+	maxLine = 1 << 20
+
+	CodeInvalidRow = "invalid_row"
+)
+
+var (
+	ErrUnknownFormat = apperrors.BadRequest(apperrors.CodeInvalidParameter,
+		"format must be "+FormatCSV+" or "+FormatNDJSON)
+	ErrTooLarge = apperrors.PayloadTooLarge("import_too_large",
+		fmt.Sprintf("import must have at most %d rows and lines of at most %d bytes", MaxRows, maxLine))
+	ErrEmpty = apperrors.BadRequest("import_empty", "import has no rows")
+)
+
+// ParseFormat checks a format name. The empty name is CSV.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType returns the media type files of format are served with.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return NDJSONContentType
+	}
+
+	return CSVContentType + "; charset=utf-8"
+}
+
+// Columns describes the CSV columns of a file. Export lists the columns
+// written, in order. On import, columns that are exported but not imported,
+// like timestamps, are accepted and ignored so that an export can be edited
+// and imported again.
+type Columns struct {
+	Export   []string
+	Required []string
+	Optional []string
+	Ignored  []string
+}
+
+// Writer writes the rows of a file. Rows are buffered until Flush.
+type Writer struct {
+	csv  *csv.Writer
+	buf  *bufio.Writer
+	json *json.Encoder
+	rows int
+}
+
+// NewWriter returns a writer of format to w and, for CSV, writes the header
+// row.
+func NewWriter(w io.Writer, format string, columns Columns) (*Writer, error) {
+	writer := &Writer{}
+
+	if format == FormatNDJSON {
+		// json.Encoder writes straight through, buffer it like csv.Writer.
+		writer.buf = bufio.NewWriter(w)
+		writer.json = json.NewEncoder(writer.buf)
+
+		return writer, nil
+	}
+
+	writer.csv = csv.NewWriter(w)
+
+	err := writer.csv.Write(columns.Export)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// Write adds one row, given both as the value NDJSON encodes and as the
+// record CSV writes.
+func (w *Writer) Write(value interface{}, record []string) error {
+	var err error
+
+	if w.csv != nil {
+		err = w.csv.Write(record)
+	} else {
+		err = w.json.Encode(value)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	w.rows++
+
+	return nil
+}
+
+// Rows returns how many rows were written.
+func (w *Writer) Rows() int {
+	return w.rows
+}
+
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	return w.buf.Flush()
+}
+
+// Row is one row of an imported file. Err is set when the row could not be
+// read, which fails the row without failing the file.
+type Row[T any] struct {
+	Item T
+	Line int
+	Err  error
+}
+
+// Read reads the rows of a file. fromRecord turns a CSV record, keyed by
+// column, into an item. NDJSON lines are decoded into an item directly.
+// Errors about the file as a whole are problems, except for those of
+// source itself.
+func Read[T any](source io.Reader, format string, columns Columns, fromRecord func(map[string]string) (T, error)) ([]Row[T], error) {
+	var (
+		rows []Row[T]
+		err  error
+	)
+
+	if format == FormatNDJSON {
+		rows, err = readNDJSON[T](source)
+	} else {
+		rows, err = readCSV(source, columns, fromRecord)
+	}
+
+	if errors.Is(err, bufio.ErrTooLong) {
+		return nil, ErrTooLarge.Wrap(err)
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, apperrors.BadRequest("invalid_csv", parseErr.Error()).Wrap(err)
+	}
+
+	return rows, err
+}
+
+// Batch validates the items of rows and returns them with a result that
+// records the rows that could not be read or are not valid.
+func Batch[T any](rows []Row[T], mode models.BulkMode, dryRun bool) ([]T, *models.BulkResult, error) {
+	if len(rows) == 0 {
+		return nil, nil, ErrEmpty
+	}
+
+	items := make([]T, len(rows))
+	result := models.NewBulkResult(mode, len(rows))
+	result.DryRun = dryRun
+
+	for i, row := range rows {
+		items[i] = row.Item
+		result.Results[i].Line = row.Line
+
+		err := row.Err
+		if err == nil {
+			err = validation.Struct(&items[i])
+		}
+
+		if err != nil {
+			result.Fail(i, err)
+		}
+	}
+
+	return items, result, nil
+}
+
+func readCSV[T any](source io.Reader, columns Columns, fromRecord func(map[string]string) (T, error)) ([]Row[T], error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkColumns(header, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row[T]
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == MaxRows {
+			return nil, ErrTooLarge
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row[T]{Line: line}
+
+		if len(record) != len(header) {
+			row.Err = apperrors.BadRequest(CodeInvalidRow,
+				fmt.Sprintf("row has %d fields, the header has %d", len(record), len(header)))
+		} else {
+			values := make(map[string]string, len(header))
+			for i, column := range header {
+				values[column] = strings.TrimSpace(record[i])
+			}
+
+			row.Item, row.Err = fromRecord(values)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// checkColumns rejects a header that misses a required column, repeats one
+// or has one the file does not know.
+func checkColumns(header []string, columns Columns) error {
+	known := make(map[string]bool)
+	for _, group := range [][]string{columns.Required, columns.Optional, columns.Ignored} {
+		for _, column := range group {
+			known[column] = true
+		}
+	}
+
+	seen := make(map[string]bool, len(header))
+	fields := make(map[string][]string)
+
+	for i, column := range header {
+		// Spreadsheets often save CSV with a byte order mark.
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		header[i] = column
+
+		switch {
+		case !known[column]:
+			fields[column] = []string{"is not a known column"}
+		case seen[column]:
+			fields[column] = []string{"appears more than once"}
+		}
+
+		seen[column] = true
+	}
+
+	for _, column := range columns.Required {
+		if !seen[column] {
+			fields[column] = []string{"is a required column"}
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(validation.CodeValidationFailed, "import header is not valid", fields)
+	}
+
+	return nil
+}
+
+func readNDJSON[T any](source io.Reader) ([]Row[T], error) {
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLine)
+
+	var rows []Row[T]
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if len(rows) == MaxRows {
+			return nil, ErrTooLarge
+		}
+
+		row := Row[T]{Line: line}
+
+		err := json.Unmarshal([]byte(text), &row.Item)
+		if err != nil {
+			row.Err = rowDecodeError(err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+func rowDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return invalidField(typeErr.Field, "has the wrong type")
+	}
+
+	return apperrors.BadRequest(CodeInvalidRow, "row is not a valid JSON object")
+}
+
+func invalidField(field, message string) error {
+	return apperrors.Validation(validation.CodeValidationFailed, "row has invalid fields",
+		map[string][]string{field: {message}})
+}
+
+func formatIDs(ids []int64) string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = strconv.FormatInt(id, 10)
+	}
+
+	return strings.Join(formatted, IDSeparator)
+}
+
+// parseIDs parses a CSV cell of ids joined by IDSeparator.
+func parseIDs(value string) ([]int64, error) {
+	if value == "" {
+		return []int64{}, nil
+	}
+
+	parts := strings.Split(value, IDSeparator)
+	ids := make([]int64, len(parts))
+
+	for i, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[i] = id
+	}
+
+	return ids, nil
+}
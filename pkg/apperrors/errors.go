@@ -0,0 +1,186 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Kind string
+
+const (
+	KindBadRequest   Kind = "bad_request"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	// KindPreconditionFailed is a conditional request, e.g. If-Match, whose
+	// condition no longer holds.
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindPayloadTooLarge      Kind = "payload_too_large"
+	KindUnsupportedMediaType Kind = "unsupported_media_type"
+	KindTooManyRequests      Kind = "too_many_requests"
+	KindInternal             Kind = "internal"
+)
+
+// Codes shared by several handlers. Resource-specific codes are declared next
+// to the error that carries them.
+const (
+	CodeInvalidBody      = "invalid_body"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeInternal         = "internal_error"
+)
+
+// Sentinels for matching on the kind alone, e.g. errors.Is(err, ErrNotFound).
+var (
+	ErrBadRequest           = &Error{Kind: KindBadRequest}
+	ErrValidation           = &Error{Kind: KindValidation}
+	ErrUnauthorized         = &Error{Kind: KindUnauthorized}
+	ErrForbidden            = &Error{Kind: KindForbidden}
+	ErrNotFound             = &Error{Kind: KindNotFound}
+	ErrConflict             = &Error{Kind: KindConflict}
+	ErrPreconditionFailed   = &Error{Kind: KindPreconditionFailed}
+	ErrPayloadTooLarge      = &Error{Kind: KindPayloadTooLarge}
+	ErrUnsupportedMediaType = &Error{Kind: KindUnsupportedMediaType}
+	ErrTooManyRequests      = &Error{Kind: KindTooManyRequests}
+	ErrInternal             = &Error{Kind: KindInternal}
+)
+
+// Error is a domain error with a stable machine-readable Code that clients
+// can switch on, and a human-readable Message.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	// Fields holds per-field messages for validation errors.
+	Fields map[string][]string
+	Err    error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *Error {
+	return New(KindBadRequest, code, message)
+}
+
+func Validation(code, message string, fields map[string][]string) *Error {
+	err := New(KindValidation, code, message)
+	err.Fields = fields
+
+	return err
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func PayloadTooLarge(code, message string) *Error {
+	return New(KindPayloadTooLarge, code, message)
+}
+
+func UnsupportedMediaType(code, message string) *Error {
+	return New(KindUnsupportedMediaType, code, message)
+}
+
+func TooManyRequests(code, message string) *Error {
+	return New(KindTooManyRequests, code, message)
+}
+
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// Wrap returns a copy of e that records err as its cause, so sentinel errors
+// can carry the underlying failure without being mutated.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+
+	return &wrapped
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Kind)
+	}
+
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches another *Error of the same kind, and the same code unless the
+// target leaves the code empty.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	return t.Kind == e.Kind && (t.Code == "" || t.Code == e.Code)
+}
+
+// Status returns the HTTP status code for the kind.
+func (k Kind) Status() int {
+	switch k {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindPayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// From returns the *Error in err's chain, or an internal error wrapping err.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	return Internal(err)
+}
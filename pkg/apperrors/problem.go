@@ -0,0 +1,57 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body extended with the stable error
+// code and, for validation errors, the messages for each field.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   map[string][]string `json:"errors,omitempty"`
+}
+
+// Write is the single place errors are turned into HTTP responses. Errors
+// that are not an *Error are reported as internal without leaking details.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := From(err)
+	status := appErr.Kind.Status()
+
+	detail := appErr.Message
+	if status >= http.StatusInternalServerError {
+		logrus.WithError(err).WithField("request_url", r.URL.String()).Error("Request failed")
+		detail = "internal server error"
+	}
+
+	code := appErr.Code
+	if code == "" {
+		code = string(appErr.Kind)
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+		Errors:   appErr.Fields,
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	// The status line is already sent, nothing useful can be done on failure.
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,86 @@
+package apperrors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"awesomeProject/pkg/apperrors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error", func() {
+	errWidgetNotFound := apperrors.NotFound("widget_not_found", "widget not found")
+
+	It("should match on kind and code through wrapping", func() {
+		err := fmt.Errorf("loading widget: %w", errWidgetNotFound.Wrap(errors.New("no rows")))
+
+		Expect(errors.Is(err, errWidgetNotFound)).To(BeTrue())
+		Expect(errors.Is(err, apperrors.ErrNotFound)).To(BeTrue())
+		Expect(errors.Is(err, apperrors.ErrConflict)).To(BeFalse())
+		Expect(errors.Is(err, apperrors.NotFound("gadget_not_found", ""))).To(BeFalse())
+	})
+
+	It("should not mutate the sentinel when wrapping", func() {
+		_ = errWidgetNotFound.Wrap(errors.New("cause"))
+
+		Expect(errWidgetNotFound.Err).To(BeNil())
+	})
+
+	It("should treat unknown errors as internal", func() {
+		Expect(apperrors.From(errors.New("boom")).Kind).To(Equal(apperrors.KindInternal))
+	})
+})
+
+var _ = Describe("Write", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		request  *http.Request
+	)
+
+	decode := func() apperrors.Problem {
+		var problem apperrors.Problem
+		Expect(json.NewDecoder(recorder.Body).Decode(&problem)).To(Succeed())
+		return problem
+	}
+
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+		request = httptest.NewRequest(http.MethodGet, "/api/v1/widgets/1", nil)
+	})
+
+	It("should write a problem with the stable code", func() {
+		apperrors.Write(recorder, request, apperrors.NotFound("widget_not_found", "widget not found"))
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal(apperrors.ProblemContentType))
+
+		problem := decode()
+		Expect(problem.Code).To(Equal("widget_not_found"))
+		Expect(problem.Title).To(Equal("Not Found"))
+		Expect(problem.Detail).To(Equal("widget not found"))
+		Expect(problem.Instance).To(Equal("/api/v1/widgets/1"))
+	})
+
+	It("should include field errors for validation failures", func() {
+		apperrors.Write(recorder, request, apperrors.Validation("invalid_widget", "widget is invalid",
+			map[string][]string{"name": {"is required"}}))
+
+		Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(decode().Errors).To(HaveKeyWithValue("name", []string{"is required"}))
+	})
+
+	It("should hide the details of internal errors", func() {
+		apperrors.Write(recorder, request, errors.New("pq: connection refused"))
+
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+
+		problem := decode()
+		Expect(problem.Code).To(Equal(apperrors.CodeInternal))
+		Expect(problem.Detail).NotTo(ContainSubstring("connection refused"))
+	})
+})
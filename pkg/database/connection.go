@@ -1,38 +1,41 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"time"
+
+	"awesomeProject/configs"
 
 	_ "github.com/lib/pq"
 )
 
+// Querier is implemented by both the connection pool and a transaction, so
+// repository helpers can run either standalone or as part of a transaction.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 type Database interface {
+	Querier
 	Close() error
 	Ping() error
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRow(query string, args ...interface{}) *sql.Row
-	Exec(query string, args ...interface{}) (sql.Result, error)
-}
-
-type ConnectionConfig struct {
-	DriverName      string
-	DataSourceName  string
-	MaxOpenConns    int
-	MaxIdleConns    int
-	ConnMaxLifetime time.Duration
+	PingContext(ctx context.Context) error
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	Conn(ctx context.Context) (*sql.Conn, error)
+	Stats() sql.DBStats
 }
 
 type Connection struct {
 	db *sql.DB
 }
 
-func NewConnection(config ConnectionConfig) (Database, error) {
-	db, err := sql.Open(config.DriverName, config.DataSourceName)
+func NewConnection(config configs.Database) (Database, error) {
+	db, err := sql.Open(config.Driver, config.DataSource)
 	if err != nil {
-		fmt.Errorf("error opening connection to %s due to %s", config.DriverName, err)
-		return nil, err
+		return nil, fmt.Errorf("error opening connection to %s: %w", config.Driver, err)
 	}
 
 	db.SetMaxIdleConns(config.MaxIdleConns)
@@ -50,14 +53,30 @@ func (c *Connection) Ping() error {
 	return c.db.Ping()
 }
 
-func (c *Connection) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return c.db.Query(query, args...)
+func (c *Connection) PingContext(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+func (c *Connection) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+func (c *Connection) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(ctx, query, args...)
+}
+
+func (c *Connection) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+func (c *Connection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.db.BeginTx(ctx, opts)
 }
 
-func (c *Connection) QueryRow(query string, args ...interface{}) *sql.Row {
-	return c.db.QueryRow(query, args...)
+func (c *Connection) Conn(ctx context.Context) (*sql.Conn, error) {
+	return c.db.Conn(ctx)
 }
 
-func (c *Connection) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return c.db.Exec(query, args...)
+func (c *Connection) Stats() sql.DBStats {
+	return c.db.Stats()
 }
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// QueryObserver is told about every statement run through an instrumented
+// database, including the statements of transactions begun by WithTx.
+type QueryObserver interface {
+	// StartQuery is called before the statement named name runs. The
+	// statement runs with the returned context and done is called with its
+	// error once it returned.
+	StartQuery(ctx context.Context, name string) (_ context.Context, done func(err error))
+}
+
+// Instrumented is a Database whose statements are reported to observers,
+// named by name.
+type Instrumented struct {
+	Database
+	name      func(query string) string
+	observers []QueryObserver
+}
+
+// Instrument wraps db so that its statements are reported to observers.
+// name turns the text of a statement into a name of low cardinality, e.g.
+// the constant it is declared as.
+func Instrument(db Database, name func(query string) string, observers ...QueryObserver) *Instrumented {
+	return &Instrumented{
+		Database:  db,
+		name:      name,
+		observers: observers,
+	}
+}
+
+func (i *Instrumented) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return i.wrapTx(i.Database).QueryContext(ctx, query, args...)
+}
+
+func (i *Instrumented) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return i.wrapTx(i.Database).QueryRowContext(ctx, query, args...)
+}
+
+func (i *Instrumented) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return i.wrapTx(i.Database).ExecContext(ctx, query, args...)
+}
+
+// wrapTx returns q reporting its statements like the database does.
+func (i *Instrumented) wrapTx(q Querier) Querier {
+	return &instrumentedQuerier{Querier: q, db: i}
+}
+
+// start tells every observer that query starts and returns the context to
+// run it with and the function to call once it returned.
+func (i *Instrumented) start(ctx context.Context, query string) (context.Context, func(err error)) {
+	name := i.name(query)
+	done := make([]func(err error), len(i.observers))
+
+	for j, observer := range i.observers {
+		ctx, done[j] = observer.StartQuery(ctx, name)
+	}
+
+	return ctx, func(err error) {
+		for j := len(done) - 1; j >= 0; j-- {
+			done[j](err)
+		}
+	}
+}
+
+type instrumentedQuerier struct {
+	Querier
+	db *Instrumented
+}
+
+func (q *instrumentedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := q.db.start(ctx, query)
+
+	rows, err := q.Querier.QueryContext(ctx, query, args...)
+	done(err)
+
+	return rows, err
+}
+
+func (q *instrumentedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, done := q.db.start(ctx, query)
+
+	// A row defers sql.ErrNoRows to Scan, Err only holds real failures.
+	row := q.Querier.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+
+	return row
+}
+
+func (q *instrumentedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := q.db.start(ctx, query)
+
+	result, err := q.Querier.ExecContext(ctx, query, args...)
+	done(err)
+
+	return result, err
+}
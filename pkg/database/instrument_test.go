@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordingObserver records the name and error of every statement.
+type recordingObserver struct {
+	names  []string
+	errors []error
+}
+
+func (o *recordingObserver) StartQuery(ctx context.Context, name string) (context.Context, func(err error)) {
+	o.names = append(o.names, name)
+
+	return ctx, func(err error) {
+		o.errors = append(o.errors, err)
+	}
+}
+
+var _ = Describe("Instrument", func() {
+	var (
+		db           *sql.DB
+		mock         sqlmock.Sqlmock
+		observer     *recordingObserver
+		instrumented *Instrumented
+		err          error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		observer = &recordingObserver{}
+		instrumented = Instrument(db, func(query string) string {
+			return map[string]string{"DELETE FROM products": "DeleteProducts"}[query]
+		}, observer)
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	It("should report statements with their name and error", func() {
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM products")).
+			WillReturnError(errors.New("exec error"))
+
+		_, err = instrumented.ExecContext(context.Background(), "DELETE FROM products")
+		Expect(err).Should(HaveOccurred())
+		Expect(observer.names).Should(Equal([]string{"DeleteProducts"}))
+		Expect(observer.errors).Should(HaveLen(1))
+		Expect(observer.errors[0]).Should(MatchError("exec error"))
+	})
+
+	It("should not report a row that was not found as an error", func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT name FROM products")).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+		var name string
+
+		err = instrumented.QueryRowContext(context.Background(), "SELECT name FROM products").Scan(&name)
+		Expect(errors.Is(err, sql.ErrNoRows)).Should(BeTrue())
+		Expect(observer.errors).Should(Equal([]error{nil}))
+	})
+
+	It("should report the statements of a transaction", func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM products")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = WithTx(context.Background(), instrumented, func(tx Querier) error {
+			_, err := tx.ExecContext(context.Background(), "DELETE FROM products")
+			return err
+		})
+		Expect(err).Should(BeNil())
+		Expect(observer.names).Should(Equal([]string{"DeleteProducts"}))
+	})
+})
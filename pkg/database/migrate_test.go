@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrator", func() {
+	var (
+		db       *sql.DB
+		mock     sqlmock.Sqlmock
+		migrator *Migrator
+		err      error
+	)
+
+	migrations := fstest.MapFS{
+		"migrations/0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT)")},
+		"migrations/0001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		"migrations/0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT)")},
+		"migrations/0002_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+	}
+
+	expectLocked := func() {
+		mock.ExpectExec(regexp.QuoteMeta(lockMigrations)).WithArgs(migrationLockID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(createSchemaMigrations)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	expectUnlocked := func() {
+		mock.ExpectExec(regexp.QuoteMeta(unlockMigrations)).WithArgs(migrationLockID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	appliedRows := func(versions ...int64) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"version", "applied_at"})
+		for _, version := range versions {
+			rows.AddRow(version, time.Time{})
+		}
+		return rows
+	}
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+
+		loaded, err := LoadMigrations(migrations, "migrations")
+		Expect(err).Should(BeNil())
+
+		migrator = &Migrator{db: db, migrations: loaded}
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	Describe("LoadMigrations", func() {
+		It("should load the embedded migrations", func() {
+			loaded, err := LoadMigrations(migrationsFS, "migrations")
+			Expect(err).Should(BeNil())
+			Expect(loaded).ShouldNot(BeEmpty())
+			Expect(loaded[0].Version).Should(Equal(int64(1)))
+		})
+
+		It("should return error when the down file is missing", func() {
+			_, err := LoadMigrations(fstest.MapFS{
+				"migrations/0001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INT)")},
+			}, "migrations")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("must have both up and down files"))
+		})
+
+		It("should return error on an invalid file name", func() {
+			_, err := LoadMigrations(fstest.MapFS{
+				"migrations/create_a.sql": {Data: []byte("CREATE TABLE a (id INT)")},
+			}, "migrations")
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("invalid migration file name"))
+		})
+	})
+
+	Describe("Up", func() {
+		It("should apply only pending migrations", func() {
+			expectLocked()
+			mock.ExpectQuery(regexp.QuoteMeta(getAppliedMigrations)).WillReturnRows(appliedRows(1))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT)")).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(insertMigration)).WithArgs(int64(2), "create_b").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			expectUnlocked()
+
+			applied, err := migrator.Up(context.Background())
+			Expect(err).Should(BeNil())
+			Expect(applied).Should(HaveLen(1))
+			Expect(applied[0].Version).Should(Equal(int64(2)))
+		})
+
+		It("should roll back a failed migration and release the lock", func() {
+			expectLocked()
+			mock.ExpectQuery(regexp.QuoteMeta(getAppliedMigrations)).WillReturnRows(appliedRows(1))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT)")).
+				WillReturnError(errors.New("syntax error"))
+			mock.ExpectRollback()
+			expectUnlocked()
+
+			_, err = migrator.Up(context.Background())
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("failed to apply migration 2_create_b: syntax error"))
+		})
+	})
+
+	Describe("Down", func() {
+		It("should roll back the latest applied migration", func() {
+			expectLocked()
+			mock.ExpectQuery(regexp.QuoteMeta(getAppliedMigrations)).WillReturnRows(appliedRows(1, 2))
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("DROP TABLE b")).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(deleteMigration)).WithArgs(int64(2)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			expectUnlocked()
+
+			rolledBack, err := migrator.Down(context.Background())
+			Expect(err).Should(BeNil())
+			Expect(rolledBack).Should(HaveLen(1))
+			Expect(rolledBack[0].Version).Should(Equal(int64(2)))
+		})
+	})
+
+	Describe("To", func() {
+		It("should return error for an unknown version", func() {
+			_, err = migrator.To(context.Background(), 42)
+			Expect(errors.Is(err, ErrUnknownMigration)).Should(BeTrue())
+		})
+	})
+
+	Describe("Baseline", func() {
+		It("should record the migrations up to the version without running them", func() {
+			expectLocked()
+			mock.ExpectQuery(regexp.QuoteMeta(getAppliedMigrations)).WillReturnRows(appliedRows())
+			mock.ExpectExec(regexp.QuoteMeta(insertMigration)).WithArgs(int64(1), "create_a").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectUnlocked()
+
+			recorded, err := migrator.Baseline(context.Background(), 1)
+			Expect(err).Should(BeNil())
+			Expect(recorded).Should(HaveLen(1))
+			Expect(recorded[0].Version).Should(Equal(int64(1)))
+		})
+
+		It("should return error for an unknown version", func() {
+			_, err = migrator.Baseline(context.Background(), 42)
+			Expect(errors.Is(err, ErrUnknownMigration)).Should(BeTrue())
+		})
+	})
+
+	Describe("Status", func() {
+		It("should report applied and pending migrations", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(schemaMigrationsExist)).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectQuery(regexp.QuoteMeta(getAppliedMigrations)).WillReturnRows(appliedRows(1))
+
+			statuses, err := migrator.Status(context.Background())
+			Expect(err).Should(BeNil())
+			Expect(statuses).Should(HaveLen(2))
+			Expect(statuses[0].AppliedAt).ShouldNot(BeNil())
+			Expect(statuses[1].AppliedAt).Should(BeNil())
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+
+		It("should report every migration pending without creating schema_migrations", func() {
+			mock.ExpectQuery(regexp.QuoteMeta(schemaMigrationsExist)).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+			pending, err := migrator.Pending(context.Background())
+			Expect(err).Should(BeNil())
+			Expect(pending).Should(HaveLen(2))
+			Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		})
+	})
+})
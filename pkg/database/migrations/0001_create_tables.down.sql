@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS Category DROP CONSTRAINT IF EXISTS fk_product;
+
+DROP TABLE IF EXISTS Customer;
+DROP TABLE IF EXISTS Products;
+DROP TABLE IF EXISTS Category;
@@ -1,40 +0,0 @@
-CREATE TABLE IF NOT EXISTS Products (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    category_id INTEGER,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
-
-CREATE TABLE IF NOT EXISTS Category (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    product_id INTEGER,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
-
-CREATE TABLE IF NOT EXISTS Customer (
-    id SERIAL PRIMARY KEY,
-    username VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL,
-    password VARCHAR(255) NOT NULL,
-    role VARCHAR(100),
-    product_id INTEGER,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
-
-ALTER TABLE Products
-ADD CONSTRAINT fk_category
-FOREIGN KEY (category_id)
-REFERENCES Category(id);
-
-ALTER TABLE Category
-ADD CONSTRAINT fk_product
-FOREIGN KEY (product_id)
-REFERENCES Products(id);
-
-ALTER TABLE Customer
-ADD CONSTRAINT fk_product
-FOREIGN KEY (product_id)
-REFERENCES Products(id)
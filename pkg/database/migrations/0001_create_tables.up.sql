@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS Products (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    category_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS Category (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    product_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS Customer (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(100),
+    product_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Databases created before migrations were tracked already have these
+-- constraints, so they are only added when missing.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_category' AND conrelid = 'products'::regclass) THEN
+        ALTER TABLE Products
+        ADD CONSTRAINT fk_category
+        FOREIGN KEY (category_id)
+        REFERENCES Category(id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_product' AND conrelid = 'category'::regclass) THEN
+        ALTER TABLE Category
+        ADD CONSTRAINT fk_product
+        FOREIGN KEY (product_id)
+        REFERENCES Products(id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_product' AND conrelid = 'customer'::regclass) THEN
+        ALTER TABLE Customer
+        ADD CONSTRAINT fk_product
+        FOREIGN KEY (product_id)
+        REFERENCES Products(id);
+    END IF;
+END
+$$;
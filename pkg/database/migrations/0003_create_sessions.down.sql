@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS Refresh_Tokens;
+DROP TABLE IF EXISTS Sessions;
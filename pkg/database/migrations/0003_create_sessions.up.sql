@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS Sessions (
+    id UUID PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES Customer(id) ON DELETE CASCADE,
+    user_agent VARCHAR(512),
+    ip_address VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_sessions_customer_id ON Sessions(customer_id);
+
+CREATE TABLE IF NOT EXISTS Refresh_Tokens (
+    token_hash CHAR(64) PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES Sessions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON Refresh_Tokens(session_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS Role_Permissions;
+DROP TABLE IF EXISTS Roles;
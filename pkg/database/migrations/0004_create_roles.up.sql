@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS Roles (
+    name VARCHAR(100) PRIMARY KEY,
+    description VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS Role_Permissions (
+    role VARCHAR(100) NOT NULL REFERENCES Roles(name) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission)
+    );
+
+INSERT INTO Roles (name, description) VALUES
+    ('admin', 'Full access'),
+    ('editor', 'Manages the catalog'),
+    ('user', 'Read-only access')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO Role_Permissions (role, permission) VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:write'),
+    ('admin', 'users:delete'),
+    ('admin', 'products:write'),
+    ('admin', 'products:delete'),
+    ('admin', 'categories:write'),
+    ('admin', 'categories:delete'),
+    ('editor', 'users:read'),
+    ('editor', 'products:write'),
+    ('editor', 'categories:write'),
+    ('user', 'users:read')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_customer_role;
+DROP INDEX IF EXISTS idx_customer_created_at_id;
+
+DROP INDEX IF EXISTS idx_category_created_at_id;
+DROP INDEX IF EXISTS idx_category_name_id;
+
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_products_created_at_id;
+DROP INDEX IF EXISTS idx_products_name_id;
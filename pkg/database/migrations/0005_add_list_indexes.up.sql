@@ -0,0 +1,9 @@
+CREATE INDEX IF NOT EXISTS idx_products_name_id ON Products(name, id);
+CREATE INDEX IF NOT EXISTS idx_products_created_at_id ON Products(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON Products(category_id);
+
+CREATE INDEX IF NOT EXISTS idx_category_name_id ON Category(name, id);
+CREATE INDEX IF NOT EXISTS idx_category_created_at_id ON Category(created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_customer_created_at_id ON Customer(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_customer_role ON Customer(role);
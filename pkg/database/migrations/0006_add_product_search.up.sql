@@ -0,0 +1,8 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE Products
+ADD COLUMN IF NOT EXISTS search_vector tsvector
+GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON Products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON Products USING GIN (name gin_trgm_ops);
@@ -0,0 +1,24 @@
+ALTER TABLE Products ADD COLUMN IF NOT EXISTS category_id INTEGER;
+ALTER TABLE Category ADD COLUMN IF NOT EXISTS product_id INTEGER;
+
+-- Single columns can only hold one side of each membership, keep the lowest.
+UPDATE Products p SET category_id = (
+    SELECT MIN(pc.category_id) FROM Product_Categories pc WHERE pc.product_id = p.id
+);
+UPDATE Category c SET product_id = (
+    SELECT MIN(pc.product_id) FROM Product_Categories pc WHERE pc.category_id = c.id
+);
+
+ALTER TABLE Products
+ADD CONSTRAINT fk_category
+FOREIGN KEY (category_id)
+REFERENCES Category(id);
+
+ALTER TABLE Category
+ADD CONSTRAINT fk_product
+FOREIGN KEY (product_id)
+REFERENCES Products(id);
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON Products(category_id);
+
+DROP TABLE IF EXISTS Product_Categories;
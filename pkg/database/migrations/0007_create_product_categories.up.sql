@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS Product_Categories (
+    product_id INTEGER NOT NULL REFERENCES Products(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES Category(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, category_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_product_categories_category_id ON Product_Categories(category_id, product_id);
+
+-- Both sides of the old circular reference described a membership, keep
+-- every pair either of them recorded.
+INSERT INTO Product_Categories (product_id, category_id)
+SELECT id, category_id FROM Products WHERE category_id IS NOT NULL
+UNION
+SELECT product_id, id FROM Category WHERE product_id IS NOT NULL
+ON CONFLICT (product_id, category_id) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_products_category_id;
+
+ALTER TABLE Products DROP CONSTRAINT IF EXISTS fk_category;
+ALTER TABLE Category DROP CONSTRAINT IF EXISTS fk_product;
+
+ALTER TABLE Products DROP COLUMN IF EXISTS category_id;
+ALTER TABLE Category DROP COLUMN IF EXISTS product_id;
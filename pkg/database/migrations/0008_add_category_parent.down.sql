@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_category_parent_id;
+
+ALTER TABLE Category DROP CONSTRAINT IF EXISTS chk_category_parent_not_self;
+ALTER TABLE Category DROP CONSTRAINT IF EXISTS fk_category_parent;
+
+ALTER TABLE Category DROP COLUMN IF EXISTS parent_id;
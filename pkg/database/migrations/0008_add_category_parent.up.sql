@@ -0,0 +1,12 @@
+ALTER TABLE Category ADD COLUMN IF NOT EXISTS parent_id INTEGER;
+
+ALTER TABLE Category
+ADD CONSTRAINT fk_category_parent
+FOREIGN KEY (parent_id)
+REFERENCES Category(id);
+
+ALTER TABLE Category
+ADD CONSTRAINT chk_category_parent_not_self
+CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_category_parent_id ON Category(parent_id);
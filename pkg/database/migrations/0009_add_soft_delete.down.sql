@@ -0,0 +1,26 @@
+DELETE FROM Role_Permissions WHERE permission = 'trash:manage';
+
+ALTER TABLE Customer DROP CONSTRAINT IF EXISTS fk_product;
+ALTER TABLE Customer
+ADD CONSTRAINT fk_product
+FOREIGN KEY (product_id)
+REFERENCES Products(id);
+
+ALTER TABLE Category DROP CONSTRAINT IF EXISTS fk_category_parent;
+ALTER TABLE Category
+ADD CONSTRAINT fk_category_parent
+FOREIGN KEY (parent_id)
+REFERENCES Category(id);
+
+DROP INDEX IF EXISTS idx_customer_deleted_at;
+DROP INDEX IF EXISTS idx_category_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+
+-- Trashed rows would reappear as live ones.
+DELETE FROM Customer WHERE deleted_at IS NOT NULL;
+DELETE FROM Products WHERE deleted_at IS NOT NULL;
+DELETE FROM Category WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE Customer DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE Category DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE Products DROP COLUMN IF EXISTS deleted_at;
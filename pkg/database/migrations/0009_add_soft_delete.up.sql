@@ -0,0 +1,28 @@
+ALTER TABLE Products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE Category ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE Customer ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Only trashed rows are looked up by deleted_at, live rows are filtered with
+-- IS NULL which the existing indexes serve well.
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON Products(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_category_deleted_at ON Category(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_customer_deleted_at ON Customer(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Purging a trashed row must not be blocked by rows still pointing at it.
+ALTER TABLE Category DROP CONSTRAINT IF EXISTS fk_category_parent;
+ALTER TABLE Category
+ADD CONSTRAINT fk_category_parent
+FOREIGN KEY (parent_id)
+REFERENCES Category(id)
+ON DELETE SET NULL;
+
+ALTER TABLE Customer DROP CONSTRAINT IF EXISTS fk_product;
+ALTER TABLE Customer
+ADD CONSTRAINT fk_product
+FOREIGN KEY (product_id)
+REFERENCES Products(id)
+ON DELETE SET NULL;
+
+INSERT INTO Role_Permissions (role, permission) VALUES
+    ('admin', 'trash:manage')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE Customer DROP COLUMN IF EXISTS version;
+ALTER TABLE Category DROP COLUMN IF EXISTS version;
+ALTER TABLE Products DROP COLUMN IF EXISTS version;
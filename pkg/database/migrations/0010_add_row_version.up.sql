@@ -0,0 +1,5 @@
+-- version is bumped by every write and exposed as the ETag, so concurrent
+-- editors can detect that the row changed under them.
+ALTER TABLE Products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE Category ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE Customer ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
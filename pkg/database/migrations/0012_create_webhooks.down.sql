@@ -0,0 +1,4 @@
+DELETE FROM Role_Permissions WHERE permission = 'webhooks:manage';
+DROP TABLE IF EXISTS Webhook_Deliveries;
+DROP TABLE IF EXISTS Webhook_Subscriptions;
+DROP TABLE IF EXISTS Outbox_Events;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS Outbox_Events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMP
+    );
+
+-- The dispatcher only ever reads the events it has not fanned out yet.
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON Outbox_Events(id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS Webhook_Subscriptions (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INTEGER REFERENCES Customer(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled_at TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS Webhook_Deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES Webhook_Subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES Outbox_Events(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    response_status INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON Webhook_Deliveries(next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON Webhook_Deliveries(subscription_id);
+
+INSERT INTO Role_Permissions (role, permission) VALUES
+    ('admin', 'webhooks:manage')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Token buckets of the rate limiter, shared by every instance. Losing them
+-- on a crash only resets the limits, so the table skips the WAL.
+CREATE UNLOGGED TABLE IF NOT EXISTS Rate_Limits (
+    key VARCHAR(200) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_updated_at ON Rate_Limits(updated_at);
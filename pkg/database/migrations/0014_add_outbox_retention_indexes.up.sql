@@ -0,0 +1,5 @@
+-- Old dispatched events are deleted by the dispatcher, which looks for
+-- pending deliveries of each of them. Deleting an event cascades to its
+-- deliveries through event_id as well.
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dispatched_at ON Outbox_Events(dispatched_at) WHERE dispatched_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON Webhook_Deliveries(event_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_customer_email_live;
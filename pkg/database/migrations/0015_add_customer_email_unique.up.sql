@@ -0,0 +1,5 @@
+-- Emails are unique among live users, whatever their case. Trashed users keep
+-- their email so they can be restored, unless a live user has taken it.
+-- Creating the index fails while live users share an email, those have to be
+-- merged or trashed first.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_email_live ON Customer(lower(email)) WHERE deleted_at IS NULL;
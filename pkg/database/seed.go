@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+)
+
+//go:embed seeds/dev.sql
+var devSeed string
+
+// Seed inserts the sample catalog used in development. It expects a fully
+// migrated database and can be run again without duplicating rows.
+func Seed(ctx context.Context, db TxBeginner) error {
+	return WithTx(ctx, db, func(tx Querier) error {
+		_, err := tx.ExecContext(ctx, devSeed)
+		if err != nil {
+			return fmt.Errorf("failed to seed database: %w", err)
+		}
+
+		return nil
+	})
+}
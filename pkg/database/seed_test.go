@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Seed", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	It("should insert the sample data in one transaction", func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(devSeed)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		err = Seed(context.Background(), db)
+		Expect(err).Should(BeNil())
+	})
+
+	It("should roll back when the sample data cannot be inserted", func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(devSeed)).
+			WillReturnError(errors.New("relation \"product_categories\" does not exist"))
+		mock.ExpectRollback()
+
+		err = Seed(context.Background(), db)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("failed to seed database"))
+	})
+})
@@ -0,0 +1,21 @@
+-- Sample catalog for local development. Every insert skips rows that exist
+-- already, so seeding twice changes nothing.
+INSERT INTO Category (name)
+SELECT name FROM (VALUES ('Electronics'), ('Books'), ('Clothing')) AS seed(name)
+WHERE NOT EXISTS (SELECT 1 FROM Category WHERE Category.name = seed.name AND deleted_at IS NULL);
+
+INSERT INTO Products (name)
+SELECT name FROM (VALUES ('Laptop'), ('Smartphone'), ('Novel'), ('Hoodie')) AS seed(name)
+WHERE NOT EXISTS (SELECT 1 FROM Products WHERE Products.name = seed.name AND deleted_at IS NULL);
+
+INSERT INTO Product_Categories (product_id, category_id)
+SELECT Products.id, Category.id
+FROM (VALUES
+    ('Laptop', 'Electronics'),
+    ('Smartphone', 'Electronics'),
+    ('Novel', 'Books'),
+    ('Hoodie', 'Clothing')
+) AS seed(product, category)
+JOIN Products ON Products.name = seed.product AND Products.deleted_at IS NULL
+JOIN Category ON Category.name = seed.category AND Category.deleted_at IS NULL
+ON CONFLICT (product_id, category_id) DO NOTHING;
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/lib/pq"
+)
+
+// serializableAttempts bounds how often WithSerializableTx runs a
+// transaction that keeps conflicting with concurrent ones.
+const serializableAttempts = 5
+
+// ErrTxConflict is returned once a serializable transaction conflicted on
+// every attempt. The request can be retried as is.
+var ErrTxConflict = apperrors.Conflict("transaction_conflict", "the request conflicted with concurrent changes, retry it")
+
+// TxBeginner is implemented by Database, *sql.DB and *sql.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// txWrapper is implemented by databases that wrap the statements of their
+// transactions, like Instrumented.
+type txWrapper interface {
+	wrapTx(tx Querier) Querier
+}
+
+// WithTx runs fn inside a transaction that is committed when fn returns nil
+// and rolled back otherwise, including when fn panics.
+func WithTx(ctx context.Context, db TxBeginner, fn func(tx Querier) error) error {
+	return WithTxOptions(ctx, db, nil, fn)
+}
+
+// WithTxOptions is WithTx with explicit isolation level and read-only mode.
+func WithTxOptions(ctx context.Context, db TxBeginner, opts *sql.TxOptions, fn func(tx Querier) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	var q Querier = tx
+	if wrapper, ok := db.(txWrapper); ok {
+		q = wrapper.wrapTx(tx)
+	}
+
+	err = fn(q)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("failed to rollback transaction: %w", rollbackErr))
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WithSerializableTx runs fn in a SERIALIZABLE transaction like
+// WithTxOptions. A transaction failing with a serialization failure or a
+// deadlock is run again from the start, so fn must only change state
+// through tx. ErrTxConflict is returned when every attempt failed that way.
+func WithSerializableTx(ctx context.Context, db TxBeginner, fn func(tx Querier) error) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+	for attempt := 1; ; attempt++ {
+		err := WithTxOptions(ctx, db, opts, fn)
+		if !retryable(err) {
+			return err
+		}
+
+		if attempt == serializableAttempts {
+			return ErrTxConflict.Wrap(err)
+		}
+
+		// Spreading the retries keeps the conflicting transactions from
+		// meeting again.
+		delay := time.Duration(attempt)*10*time.Millisecond + time.Duration(rand.Int63n(int64(10*time.Millisecond)))
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable reports whether err is a serialization failure or a deadlock,
+// which Postgres resolves by aborting one of the transactions involved.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
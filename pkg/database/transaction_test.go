@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"awesomeProject/pkg/apperrors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithTx", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		err  error
+	)
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	It("should commit when fn succeeds", func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM products")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = WithTx(context.Background(), db, func(tx Querier) error {
+			_, err := tx.ExecContext(context.Background(), "DELETE FROM products")
+			return err
+		})
+		Expect(err).Should(BeNil())
+	})
+
+	It("should rollback and return the error when fn fails", func() {
+		fnErr := errors.New("fn error")
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err = WithTx(context.Background(), db, func(tx Querier) error {
+			return fnErr
+		})
+		Expect(errors.Is(err, fnErr)).Should(BeTrue())
+	})
+
+	It("should rollback and re-panic when fn panics", func() {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		Expect(func() {
+			_ = WithTx(context.Background(), db, func(tx Querier) error {
+				panic("boom")
+			})
+		}).Should(PanicWith("boom"))
+	})
+
+	It("should return error when begin fails", func() {
+		mock.ExpectBegin().WillReturnError(errors.New("begin error"))
+
+		err = WithTx(context.Background(), db, func(tx Querier) error {
+			return nil
+		})
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("failed to begin transaction: begin error"))
+	})
+
+	It("should return error when commit fails", func() {
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+
+		err = WithTx(context.Background(), db, func(tx Querier) error {
+			return nil
+		})
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("failed to commit transaction: commit error"))
+	})
+})
+
+var _ = Describe("WithSerializableTx", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		err  error
+	)
+
+	serializationFailure := &pq.Error{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"}
+
+	BeforeEach(func() {
+		db, mock, err = sqlmock.New()
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).Should(Succeed())
+		db.Close()
+	})
+
+	It("should run fn again after a serialization failure", func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products")).WillReturnError(serializationFailure)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products")).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		runs := 0
+		err = WithSerializableTx(context.Background(), db, func(tx Querier) error {
+			runs++
+			_, err := tx.ExecContext(context.Background(), "INSERT INTO products")
+			return err
+		})
+		Expect(err).Should(BeNil())
+		Expect(runs).Should(Equal(2))
+	})
+
+	It("should retry a deadlock found on commit", func() {
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(&pq.Error{Code: "40P01", Message: "deadlock detected"})
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err = WithSerializableTx(context.Background(), db, func(tx Querier) error {
+			return nil
+		})
+		Expect(err).Should(BeNil())
+	})
+
+	It("should return a conflict once every attempt failed", func() {
+		for i := 0; i < serializableAttempts; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		err = WithSerializableTx(context.Background(), db, func(tx Querier) error {
+			return fmt.Errorf("failed to create product: %w", serializationFailure)
+		})
+		Expect(errors.Is(err, ErrTxConflict)).Should(BeTrue())
+		Expect(apperrors.From(err).Kind.Status()).Should(Equal(http.StatusConflict))
+	})
+
+	It("should not retry other errors", func() {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err = WithSerializableTx(context.Background(), db, func(tx Querier) error {
+			return &pq.Error{Code: "23505", Message: "duplicate key value"}
+		})
+		Expect(err).Should(HaveOccurred())
+		Expect(errors.Is(err, ErrTxConflict)).Should(BeFalse())
+	})
+})
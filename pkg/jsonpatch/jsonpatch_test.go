@@ -0,0 +1,84 @@
+package jsonpatch_test
+
+import (
+	"errors"
+
+	"awesomeProject/pkg/jsonpatch"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MergePatch", func() {
+	DescribeTable("should follow RFC 7396",
+		func(doc, patch, expected string) {
+			result, err := jsonpatch.MergePatch([]byte(doc), []byte(patch))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(MatchJSON(expected))
+		},
+		Entry("replace a member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`),
+		Entry("add a member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`),
+		Entry("remove a member with null", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`),
+		Entry("replace arrays as a whole", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`),
+		Entry("merge nested objects", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`),
+		Entry("replace the document with a non-object", `{"a":"b"}`, `["c"]`, `["c"]`),
+		Entry("keep large integers", `{"id":9007199254740993}`, `{}`, `{"id":9007199254740993}`),
+	)
+
+	It("should reject a patch that is not JSON", func() {
+		_, err := jsonpatch.MergePatch([]byte(`{}`), []byte(`{"a":`))
+		Expect(errors.Is(err, jsonpatch.ErrInvalidPatch)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Apply", func() {
+	doc := `{"name":"Laptop","category_ids":[1,2],"meta":{"a/b":1,"m~n":2}}`
+
+	DescribeTable("should apply RFC 6902 operations",
+		func(patch, expected string) {
+			result, err := jsonpatch.Apply([]byte(doc), []byte(patch))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(MatchJSON(expected))
+		},
+		Entry("add a member",
+			`[{"op":"add","path":"/color","value":"red"}]`,
+			`{"name":"Laptop","category_ids":[1,2],"meta":{"a/b":1,"m~n":2},"color":"red"}`),
+		Entry("insert into an array",
+			`[{"op":"add","path":"/category_ids/1","value":5}]`,
+			`{"name":"Laptop","category_ids":[1,5,2],"meta":{"a/b":1,"m~n":2}}`),
+		Entry("append to an array",
+			`[{"op":"add","path":"/category_ids/-","value":5}]`,
+			`{"name":"Laptop","category_ids":[1,2,5],"meta":{"a/b":1,"m~n":2}}`),
+		Entry("remove an array element",
+			`[{"op":"remove","path":"/category_ids/0"}]`,
+			`{"name":"Laptop","category_ids":[2],"meta":{"a/b":1,"m~n":2}}`),
+		Entry("replace escaped members",
+			`[{"op":"replace","path":"/meta/a~1b","value":3},{"op":"remove","path":"/meta/m~0n"}]`,
+			`{"name":"Laptop","category_ids":[1,2],"meta":{"a/b":3}}`),
+		Entry("move a member",
+			`[{"op":"move","from":"/name","path":"/title"}]`,
+			`{"title":"Laptop","category_ids":[1,2],"meta":{"a/b":1,"m~n":2}}`),
+		Entry("copy a member",
+			`[{"op":"copy","from":"/category_ids/1","path":"/category_ids/0"}]`,
+			`{"name":"Laptop","category_ids":[2,1,2],"meta":{"a/b":1,"m~n":2}}`),
+		Entry("pass a matching test",
+			`[{"op":"test","path":"/category_ids","value":[1,2.0]},{"op":"replace","path":"/name","value":"Desktop"}]`,
+			`{"name":"Desktop","category_ids":[1,2],"meta":{"a/b":1,"m~n":2}}`),
+	)
+
+	DescribeTable("should reject invalid operations",
+		func(patch string, expected error) {
+			_, err := jsonpatch.Apply([]byte(doc), []byte(patch))
+			Expect(errors.Is(err, expected)).To(BeTrue(), "got %v", err)
+		},
+		Entry("unknown operation", `[{"op":"merge","path":"/name"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("missing value", `[{"op":"add","path":"/name"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("replace a missing member", `[{"op":"replace","path":"/color","value":"red"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("remove out of range", `[{"op":"remove","path":"/category_ids/2"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("index with a leading zero", `[{"op":"remove","path":"/category_ids/01"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("relative path", `[{"op":"remove","path":"name"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("move into a child", `[{"op":"move","from":"/meta","path":"/meta/inner"}]`, jsonpatch.ErrInvalidPatch),
+		Entry("not an array", `{"op":"remove","path":"/name"}`, jsonpatch.ErrInvalidPatch),
+		Entry("failing test", `[{"op":"test","path":"/name","value":"Desktop"}]`, jsonpatch.ErrTestFailed),
+	)
+})
@@ -1,24 +1,27 @@
 package utils
 
 import (
-	"fmt"
 	"time"
 
 	"awesomeProject/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateJWT(user models.UserResponse) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func GenerateJWT(user models.UserResponse, sessionID string, keys *KeyManager, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	tokenString, err := keys.Sign(jwt.MapClaims{
+		"jti":      uuid.NewString(),
+		"sid":      sessionID,
 		"userID":   user.ID,
 		"username": user.Username,
 		"email":    user.Email,
 		"role":     user.Role,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"iat":      now.Unix(),
+		"exp":      now.Add(ttl).Unix(),
 	})
-
-	tokenString, err := token.SignedString([]byte("secret"))
 	if err != nil {
 		return "", err
 	}
@@ -26,15 +29,8 @@ func GenerateJWT(user models.UserResponse) (string, error) {
 	return tokenString, nil
 }
 
-func VerifyJWT(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return []byte("secret"), nil
-	})
-
+func VerifyJWT(tokenString string, keys *KeyManager) (jwt.MapClaims, error) {
+	token, err := keys.Parse(tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"awesomeProject/configs"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const DefaultKeyID = "default"
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeyManager signs tokens with a single active key and verifies them against
+// every configured key, so a key can be rotated without invalidating tokens
+// that were issued before the switch.
+type KeyManager struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	methods []string
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeyManager(config configs.JWT) (*KeyManager, error) {
+	manager := &KeyManager{keys: make(map[string]*SigningKey)}
+
+	keys := config.Keys
+	signingKeyID := config.SigningKeyID
+
+	if len(keys) == 0 {
+		keys = []configs.JWTKey{{ID: DefaultKeyID, Algorithm: jwt.SigningMethodHS256.Alg(), Secret: config.Secret}}
+		signingKeyID = DefaultKeyID
+	}
+
+	for _, keyConfig := range keys {
+		key, err := loadKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", keyConfig.ID, err)
+		}
+
+		if _, ok := manager.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+
+		manager.keys[key.ID] = key
+		manager.methods = appendUnique(manager.methods, key.Method.Alg())
+	}
+
+	signing, ok := manager.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s: %w", signingKeyID, ErrUnknownKey)
+	}
+
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKeyID)
+	}
+
+	manager.signing = signing
+
+	return manager, nil
+}
+
+func (k *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+
+	return token.SignedString(k.signing.signKey)
+}
+
+func (k *KeyManager) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, k.keyFunc, jwt.WithValidMethods(k.methods))
+}
+
+// JWKS returns the public halves of the asymmetric keys. HMAC secrets are
+// never published.
+func (k *KeyManager) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range k.keys {
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "EC",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				Curve:     publicKey.Curve.Params().Name,
+				X:         base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size))),
+				Y:         base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size))),
+			})
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+
+	return jwks
+}
+
+func (k *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := k.signing
+
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, ok = k.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("key %s: %w", kid, ErrUnknownKey)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+func loadKey(config configs.JWTKey) (*SigningKey, error) {
+	method := jwt.GetSigningMethod(config.Algorithm)
+	key := &SigningKey{ID: config.ID, Method: method}
+
+	switch method {
+	case jwt.SigningMethodHS256:
+		if config.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+
+		key.signKey = []byte(config.Secret)
+		key.verifyKey = key.signKey
+	case jwt.SigningMethodRS256:
+		privatePEM, publicPEM, err := readPEM(config)
+		if err != nil {
+			return nil, err
+		}
+
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+
+			key.signKey = privateKey
+			key.verifyKey = &privateKey.PublicKey
+		} else {
+			key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case jwt.SigningMethodES256:
+		privatePEM, publicPEM, err := readPEM(config)
+		if err != nil {
+			return nil, err
+		}
+
+		var publicKey *ecdsa.PublicKey
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseECPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+
+			key.signKey = privateKey
+			publicKey = &privateKey.PublicKey
+		} else {
+			publicKey, err = jwt.ParseECPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if publicKey.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+
+		key.verifyKey = publicKey
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", config.Algorithm)
+	}
+
+	return key, nil
+}
+
+func readPEM(config configs.JWTKey) ([]byte, []byte, error) {
+	privatePEM, err := readKeyMaterial(config.PrivateKey, config.PrivateKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicPEM, err := readKeyMaterial(config.PublicKey, config.PublicKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if privatePEM == nil && publicPEM == nil {
+		return nil, nil, errors.New("private or public key is required")
+	}
+
+	return privatePEM, publicPEM, nil
+}
+
+func readKeyMaterial(inline, path string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
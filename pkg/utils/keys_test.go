@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"awesomeProject/configs"
+	"awesomeProject/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KeyManager", func() {
+	var (
+		rsaPEM    string
+		rsaPubPEM string
+		ecPEM     string
+		user      models.UserResponse
+	)
+
+	BeforeEach(func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		rsaPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+		publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		rsaPubPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		ecDER, err := x509.MarshalECPrivateKey(ecKey)
+		Expect(err).NotTo(HaveOccurred())
+		ecPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))
+
+		user = models.UserResponse{ID: "1", Username: "test", Email: "test@example.com", Role: "user"}
+	})
+
+	Describe("NewKeyManager", func() {
+		It("should fall back to the HS256 secret", func() {
+			keys, err := NewKeyManager(configs.JWT{Secret: "secret"})
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := GenerateJWT(user, "session", keys, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			parsed, err := keys.Parse(token)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Header["kid"]).To(Equal(DefaultKeyID))
+			Expect(parsed.Method).To(Equal(jwt.SigningMethodHS256))
+		})
+
+		It("should return error when signing key is unknown", func() {
+			_, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "missing",
+				Keys:         []configs.JWTKey{{ID: "rsa", Algorithm: "RS256", PrivateKey: rsaPEM}},
+			})
+			Expect(errors.Is(err, ErrUnknownKey)).To(BeTrue())
+		})
+
+		It("should return error when signing key has no private key", func() {
+			_, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "rsa",
+				Keys:         []configs.JWTKey{{ID: "rsa", Algorithm: "RS256", PublicKey: rsaPubPEM}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("has no private key"))
+		})
+
+		It("should return error on unsupported algorithm", func() {
+			_, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "none",
+				Keys:         []configs.JWTKey{{ID: "none", Algorithm: "none"}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported algorithm"))
+		})
+	})
+
+	Describe("rotation", func() {
+		It("should verify tokens signed with a retired key", func() {
+			oldKeys, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "old",
+				Keys:         []configs.JWTKey{{ID: "old", Algorithm: "ES256", PrivateKey: ecPEM}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			oldToken, err := GenerateJWT(user, "session", oldKeys, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			keys, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "new",
+				Keys: []configs.JWTKey{
+					{ID: "new", Algorithm: "RS256", PrivateKey: rsaPEM},
+					{ID: "old", Algorithm: "ES256", PrivateKey: ecPEM},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			claims, err := VerifyJWT(oldToken, keys)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims["email"]).To(Equal(user.Email))
+
+			newToken, err := GenerateJWT(user, "session", keys, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			parsed, err := keys.Parse(newToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Header["kid"]).To(Equal("new"))
+		})
+
+		It("should reject tokens signed with an unknown key", func() {
+			other, err := NewKeyManager(configs.JWT{Secret: "other"})
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := GenerateJWT(user, "session", other, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			keys, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "rsa",
+				Keys:         []configs.JWTKey{{ID: "rsa", Algorithm: "RS256", PrivateKey: rsaPEM}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = VerifyJWT(token, keys)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("JWKS", func() {
+		It("should publish only asymmetric public keys", func() {
+			keys, err := NewKeyManager(configs.JWT{
+				SigningKeyID: "rsa",
+				Keys: []configs.JWTKey{
+					{ID: "rsa", Algorithm: "RS256", PrivateKey: rsaPEM},
+					{ID: "ec", Algorithm: "ES256", PrivateKey: ecPEM},
+					{ID: "hmac", Algorithm: "HS256", Secret: "secret"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			jwks := keys.JWKS()
+			Expect(jwks.Keys).To(HaveLen(2))
+			Expect(jwks.Keys[0].KeyID).To(Equal("ec"))
+			Expect(jwks.Keys[0].Curve).To(Equal("P-256"))
+			Expect(jwks.Keys[0].X).NotTo(BeEmpty())
+			Expect(jwks.Keys[1].KeyID).To(Equal("rsa"))
+			Expect(jwks.Keys[1].KeyType).To(Equal("RSA"))
+			Expect(jwks.Keys[1].E).To(Equal("AQAB"))
+		})
+	})
+})
@@ -1,16 +1,21 @@
 package utils
 
 import (
+	"errors"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrEmptyPassword = errors.New("password must not be empty")
+
 func GenerateHashPassword(password string) (string, error) {
-	if password != "" {
-		bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-		return string(bytes), err
+	if password == "" {
+		return "", ErrEmptyPassword
 	}
 
-	return password, nil
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	return string(bytes), err
 }
 
 func CheckPasswordHash(hash, password string) bool {
@@ -0,0 +1,262 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"awesomeProject/pkg/apperrors"
+)
+
+const (
+	CodeValidationFailed = "validation_failed"
+
+	// bcrypt ignores everything after the first 72 bytes.
+	maxPasswordBytes  = 72
+	minPasswordLength = 8
+)
+
+// rule checks one constraint against a field value and returns the message
+// to report, or "" when the value is valid.
+type rule func(value reflect.Value, param string) string
+
+// Rules are referenced from `validate` struct tags, e.g.
+// `validate:"required,email,max=255"`. omitempty skips the remaining rules
+// when the field holds its zero value, and dive applies them to each element
+// of a slice.
+var rules = map[string]rule{
+	"required": required,
+	"email":    email,
+	"min":      minimum,
+	"max":      maximum,
+	"gt":       greaterThan,
+	"oneof":    oneOf,
+	"password": password,
+}
+
+// Struct validates the `validate` tags on the fields of v, a struct or a
+// pointer to one. Failures are collected per field, keyed by the JSON name,
+// and returned as a single validation error.
+func Struct(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: expected a struct, got %T", v))
+	}
+
+	fields := make(map[string][]string)
+	validateStruct(value, fields)
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return apperrors.Validation(CodeValidationFailed, "request has invalid fields", fields)
+}
+
+func validateStruct(value reflect.Value, fields map[string][]string) {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateStruct(value.Field(i), fields)
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		validateField(value.Field(i), tag, fieldName(field), fields)
+	}
+}
+
+// validateField applies the rules in tag to value. Rules after dive apply to
+// each element of a slice and are reported under name[index].
+func validateField(value reflect.Value, tag, name string, fields map[string][]string) {
+	// Optional values are pointers. Only nil counts as empty for them, a
+	// pointer to a zero value is checked like the value itself.
+	empty := isEmpty(value)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	entries := strings.Split(tag, ",")
+
+	for i, entry := range entries {
+		ruleName, param, _ := strings.Cut(entry, "=")
+
+		switch ruleName {
+		case "omitempty":
+			if empty {
+				return
+			}
+			continue
+		case "dive":
+			elementTag := strings.Join(entries[i+1:], ",")
+			for j := 0; j < value.Len(); j++ {
+				validateField(value.Index(j), elementTag, fmt.Sprintf("%s[%d]", name, j), fields)
+			}
+			return
+		}
+
+		check, ok := rules[ruleName]
+		if !ok {
+			panic(fmt.Sprintf("validation: unknown rule %q", ruleName))
+		}
+
+		message := check(value, param)
+		if message == "" {
+			continue
+		}
+
+		fields[name] = append(fields[name], message)
+
+		// Other rules are meaningless for a missing value.
+		if ruleName == "required" {
+			return
+		}
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+func required(value reflect.Value, _ string) string {
+	if isEmpty(value) || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+		return "is required"
+	}
+
+	return ""
+}
+
+func isEmpty(value reflect.Value) bool {
+	if value.Kind() == reflect.Slice {
+		return value.Len() == 0
+	}
+
+	return value.IsZero()
+}
+
+func email(value reflect.Value, _ string) string {
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+func minimum(value reflect.Value, param string) string {
+	limit := mustParseInt(param)
+
+	switch value.Kind() {
+	case reflect.String:
+		if int64(utf8.RuneCountInString(value.String())) < limit {
+			return fmt.Sprintf("must be at least %d characters long", limit)
+		}
+	case reflect.Slice:
+		if int64(value.Len()) < limit {
+			return fmt.Sprintf("must have at least %d items", limit)
+		}
+	default:
+		if value.Int() < limit {
+			return fmt.Sprintf("must be at least %d", limit)
+		}
+	}
+
+	return ""
+}
+
+func maximum(value reflect.Value, param string) string {
+	limit := mustParseInt(param)
+
+	switch value.Kind() {
+	case reflect.String:
+		if int64(utf8.RuneCountInString(value.String())) > limit {
+			return fmt.Sprintf("must be at most %d characters long", limit)
+		}
+	case reflect.Slice:
+		if int64(value.Len()) > limit {
+			return fmt.Sprintf("must have at most %d items", limit)
+		}
+	default:
+		if value.Int() > limit {
+			return fmt.Sprintf("must be at most %d", limit)
+		}
+	}
+
+	return ""
+}
+
+func greaterThan(value reflect.Value, param string) string {
+	limit := mustParseInt(param)
+
+	if value.Int() <= limit {
+		if limit == 0 {
+			return "must be a positive number"
+		}
+		return fmt.Sprintf("must be greater than %d", limit)
+	}
+
+	return ""
+}
+
+// oneOf takes its allowed values separated by spaces, e.g. oneof=admin user.
+func oneOf(value reflect.Value, param string) string {
+	allowed := strings.Fields(param)
+
+	for _, candidate := range allowed {
+		if value.String() == candidate {
+			return ""
+		}
+	}
+
+	return "must be one of: " + strings.Join(allowed, ", ")
+}
+
+// password requires 8 to 72 bytes with at least one letter and one digit.
+func password(value reflect.Value, _ string) string {
+	secret := value.String()
+
+	if utf8.RuneCountInString(secret) < minPasswordLength {
+		return fmt.Sprintf("must be at least %d characters long", minPasswordLength)
+	}
+
+	if len(secret) > maxPasswordBytes {
+		return fmt.Sprintf("must be at most %d bytes long", maxPasswordBytes)
+	}
+
+	hasLetter := strings.IndexFunc(secret, unicode.IsLetter) >= 0
+	hasDigit := strings.IndexFunc(secret, unicode.IsDigit) >= 0
+
+	if !hasLetter || !hasDigit {
+		return "must contain at least one letter and one digit"
+	}
+
+	return ""
+}
+
+func mustParseInt(param string) int64 {
+	limit, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid rule parameter %q", param))
+	}
+
+	return limit
+}
@@ -0,0 +1,112 @@
+package validation_test
+
+import (
+	"strings"
+
+	"awesomeProject/pkg/apperrors"
+	"awesomeProject/pkg/validation"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type signup struct {
+	Name     string `json:"name" validate:"required,min=3,max=10"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,password"`
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=admin user"`
+	OwnerID  int    `json:"owner_id" validate:"omitempty,gt=0"`
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gt=0"`
+	Note     string
+}
+
+var _ = Describe("Struct", func() {
+	fieldErrors := func(err error) map[string][]string {
+		Expect(err).To(HaveOccurred())
+
+		appErr := apperrors.From(err)
+		Expect(appErr.Kind).To(Equal(apperrors.KindValidation))
+		Expect(appErr.Code).To(Equal(validation.CodeValidationFailed))
+
+		return appErr.Fields
+	}
+
+	It("should accept a valid struct", func() {
+		Expect(validation.Struct(&signup{
+			Name:     "alice",
+			Email:    "alice@example.com",
+			Password: "hunter22",
+			Role:     "user",
+		})).To(Succeed())
+	})
+
+	It("should report only required for missing fields", func() {
+		fields := fieldErrors(validation.Struct(signup{Name: "   "}))
+
+		Expect(fields).To(HaveKeyWithValue("name", []string{"is required"}))
+		Expect(fields).To(HaveKeyWithValue("email", []string{"is required"}))
+		Expect(fields).To(HaveKeyWithValue("password", []string{"is required"}))
+		Expect(fields).NotTo(HaveKey("role"))
+		Expect(fields).NotTo(HaveKey("owner_id"))
+	})
+
+	It("should aggregate errors per field by JSON name", func() {
+		fields := fieldErrors(validation.Struct(signup{
+			Name:     "al",
+			Email:    "Alice <alice@example.com>",
+			Password: "password",
+			Role:     "root",
+			OwnerID:  -1,
+		}))
+
+		Expect(fields).To(HaveKeyWithValue("name", []string{"must be at least 3 characters long"}))
+		Expect(fields).To(HaveKeyWithValue("email", []string{"must be a valid email address"}))
+		Expect(fields).To(HaveKeyWithValue("password", []string{"must contain at least one letter and one digit"}))
+		Expect(fields).To(HaveKeyWithValue("role", []string{"must be one of: admin, user"}))
+		Expect(fields).To(HaveKeyWithValue("owner_id", []string{"must be a positive number"}))
+	})
+
+	It("should check the value behind a pointer", func() {
+		negative := int64(-1)
+
+		fields := fieldErrors(validation.Struct(signup{
+			Name:     "alice",
+			Email:    "alice@example.com",
+			Password: "hunter22",
+			ParentID: &negative,
+		}))
+
+		Expect(fields).To(HaveKeyWithValue("parent_id", []string{"must be a positive number"}))
+	})
+
+	It("should validate a pointer to a zero value", func() {
+		zero := int64(0)
+
+		fields := fieldErrors(validation.Struct(signup{
+			Name:     "alice",
+			Email:    "alice@example.com",
+			Password: "hunter22",
+			ParentID: &zero,
+		}))
+
+		Expect(fields).To(HaveKeyWithValue("parent_id", []string{"must be a positive number"}))
+	})
+
+	It("should reject passwords bcrypt would truncate", func() {
+		fields := fieldErrors(validation.Struct(signup{
+			Name:     "alice",
+			Email:    "alice@example.com",
+			Password: strings.Repeat("a1", 40),
+		}))
+
+		Expect(fields).To(HaveKeyWithValue("password", []string{"must be at most 72 bytes long"}))
+	})
+
+	It("should panic on an unknown rule", func() {
+		type broken struct {
+			Name string `validate:"shiny"`
+		}
+
+		Expect(func() { _ = validation.Struct(broken{}) }).To(Panic())
+	})
+})